@@ -52,6 +52,9 @@ const (
 	ReasonReconcileSuccess ConditionReason = "ReconcileSuccess"
 	ReasonReconcileError   ConditionReason = "ReconcileError"
 	ReasonReconcilePaused  ConditionReason = "ReconcilePaused"
+
+	ReasonWaitingForReferences ConditionReason = "WaitingForReferences"
+	ReasonReferenceCycle       ConditionReason = "ReferenceCycle"
 )
 
 // A Condition that may apply to a resource.
@@ -264,3 +267,30 @@ func ReconcilePaused() Condition {
 		Reason:             ReasonReconcilePaused,
 	}
 }
+
+// WaitingForReferences returns a condition indicating that Crossplane cannot
+// reconcile the resource until the resources it references are ready. The
+// supplied error should name the references Crossplane is waiting for.
+func WaitingForReferences(err error) Condition {
+	return Condition{
+		Type:               TypeSynced,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonWaitingForReferences,
+		Message:            err.Error(),
+	}
+}
+
+// ReferenceCycle returns a condition indicating that Crossplane cannot
+// reconcile the resource because its references form a cycle; it depends,
+// directly or indirectly, on itself. The supplied error should describe the
+// cycle.
+func ReferenceCycle(err error) Condition {
+	return Condition{
+		Type:               TypeSynced,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonReferenceCycle,
+		Message:            err.Error(),
+	}
+}
@@ -84,7 +84,7 @@ func (in *ConnectionSecretMetadata) GetOwnerUID() string {
 }
 
 // SecretStoreType represents a secret store type.
-// +kubebuilder:validation:Enum=Kubernetes;Vault;Plugin
+// +kubebuilder:validation:Enum=Kubernetes;Vault;Plugin;Filesystem
 type SecretStoreType string
 
 const (
@@ -95,6 +95,16 @@ const (
 
 	// SecretStorePlugin indicates that secret store type is Plugin and will be used with external secret stores.
 	SecretStorePlugin SecretStoreType = "Plugin"
+
+	// SecretStoreFilesystem indicates that secret store type is Filesystem.
+	// In other words, connection secrets will be stored as files in a local
+	// directory.
+	SecretStoreFilesystem SecretStoreType = "Filesystem"
+
+	// SecretStoreVault indicates that secret store type is Vault. In other
+	// words, connection secrets will be stored in a HashiCorp Vault KV v2
+	// secrets engine.
+	SecretStoreVault SecretStoreType = "Vault"
 )
 
 // SecretStoreConfig represents configuration of a Secret Store.
@@ -122,6 +132,14 @@ type SecretStoreConfig struct {
 	// Plugin configures External secret store as a plugin.
 	// +optional
 	Plugin *PluginStoreConfig `json:"plugin,omitempty"`
+
+	// Filesystem configures a local filesystem secret store.
+	// +optional
+	Filesystem *FilesystemSecretStoreConfig `json:"filesystem,omitempty"`
+
+	// Vault configures a HashiCorp Vault secret store.
+	// +optional
+	Vault *VaultSecretStoreConfig `json:"vault,omitempty"`
 }
 
 // PluginStoreConfig represents configuration of an External Secret Store.
@@ -142,6 +160,135 @@ type Config struct {
 	Name string `json:"name"`
 }
 
+// FilesystemSecretStoreConfig represents the required configuration for a
+// local filesystem secret store.
+type FilesystemSecretStoreConfig struct {
+	// Path of the directory secrets are stored under. Each secret is stored
+	// as a directory named <path>/<scope>/<name>, with one file per key.
+	Path string `json:"path"`
+}
+
+// VaultSecretStoreConfig represents the required configuration for a Vault
+// secret store.
+type VaultSecretStoreConfig struct {
+	// Server is the url of the Vault server, e.g. "https://vault.acme.org"
+	Server string `json:"server"`
+
+	// MountPath is the mount path of the KV v2 secrets engine.
+	MountPath string `json:"mountPath"`
+
+	// Namespace is the Vault Enterprise namespace secrets are stored in.
+	// +optional
+	Namespace *string `json:"namespace,omitempty"`
+
+	// CABundle configures CA bundle for Vault Server.
+	// +optional
+	CABundle *VaultCABundleConfig `json:"caBundle,omitempty"`
+
+	// Auth configures an authentication method for Vault.
+	Auth VaultAuthConfig `json:"auth"`
+}
+
+// VaultCABundleConfig represents configuration for configuring a CA bundle.
+type VaultCABundleConfig struct {
+	// Source of the credentials.
+	// +kubebuilder:validation:Enum=None;Secret;Environment;Filesystem
+	Source CredentialsSource `json:"source"`
+
+	// CommonCredentialSelectors provides common selectors for extracting
+	// credentials.
+	CommonCredentialSelectors `json:",inline"`
+}
+
+// VaultAuthMethod represent a Vault authentication method.
+// https://www.vaultproject.io/docs/auth
+type VaultAuthMethod string
+
+const (
+	// VaultAuthToken indicates that "Token Auth" will be used to
+	// authenticate to Vault.
+	// https://www.vaultproject.io/docs/auth/token
+	VaultAuthToken VaultAuthMethod = "Token"
+
+	// VaultAuthAppRole indicates that "AppRole Auth" will be used to
+	// authenticate to Vault.
+	// https://www.vaultproject.io/docs/auth/approle
+	VaultAuthAppRole VaultAuthMethod = "AppRole"
+
+	// VaultAuthKubernetes indicates that "Kubernetes Auth" will be used to
+	// authenticate to Vault, using the provider's service account token.
+	// https://www.vaultproject.io/docs/auth/kubernetes
+	VaultAuthKubernetes VaultAuthMethod = "Kubernetes"
+)
+
+// VaultAuthConfig required to authenticate to a Vault API.
+type VaultAuthConfig struct {
+	// Method configures which auth method will be used.
+	// +kubebuilder:validation:Enum=Token;AppRole;Kubernetes
+	Method VaultAuthMethod `json:"method"`
+
+	// Token configures Token Auth for Vault.
+	// +optional
+	Token *VaultAuthTokenConfig `json:"token,omitempty"`
+
+	// AppRole configures AppRole Auth for Vault.
+	// +optional
+	AppRole *VaultAuthAppRoleConfig `json:"appRole,omitempty"`
+
+	// Kubernetes configures Kubernetes Auth for Vault.
+	// +optional
+	Kubernetes *VaultAuthKubernetesConfig `json:"kubernetes,omitempty"`
+}
+
+// VaultAuthTokenConfig represents configuration for Vault Token Auth Method.
+// https://www.vaultproject.io/docs/auth/token
+type VaultAuthTokenConfig struct {
+	// Source of the credentials.
+	// +kubebuilder:validation:Enum=None;Secret;Environment;Filesystem
+	Source CredentialsSource `json:"source"`
+
+	// CommonCredentialSelectors provides common selectors for extracting
+	// credentials.
+	CommonCredentialSelectors `json:",inline"`
+}
+
+// VaultAuthAppRoleConfig represents configuration for Vault AppRole Auth
+// Method.
+// https://www.vaultproject.io/docs/auth/approle
+type VaultAuthAppRoleConfig struct {
+	// MountPath of the AppRole auth method. Defaults to "approle".
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
+	// RoleID of the AppRole.
+	RoleID string `json:"roleID"`
+
+	// Source of the AppRole's secret ID.
+	// +kubebuilder:validation:Enum=None;Secret;Environment;Filesystem
+	Source CredentialsSource `json:"source"`
+
+	// CommonCredentialSelectors provides common selectors for extracting
+	// the AppRole's secret ID.
+	CommonCredentialSelectors `json:",inline"`
+}
+
+// VaultAuthKubernetesConfig represents configuration for Vault Kubernetes
+// Auth Method.
+// https://www.vaultproject.io/docs/auth/kubernetes
+type VaultAuthKubernetesConfig struct {
+	// MountPath of the Kubernetes auth method. Defaults to "kubernetes".
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
+	// Role to authenticate as.
+	Role string `json:"role"`
+
+	// TokenPath is the path of the service account token used to
+	// authenticate. Defaults to the token Kubernetes mounts into pods.
+	// +optional
+	TokenPath string `json:"tokenPath,omitempty"`
+}
+
 // KubernetesAuthConfig required to authenticate to a K8s API. It expects
 // a "kubeconfig" file to be provided.
 type KubernetesAuthConfig struct {
@@ -118,3 +118,40 @@ const (
 	// execution could continue even if the reference cannot be resolved.
 	ResolutionPolicyOptional ResolutionPolicy = "Optional"
 )
+
+// ReadinessPolicy is a type for readiness policy.
+type ReadinessPolicy string
+
+const (
+	// ReadinessPolicyIgnore is a readiness option.
+	// When the ReadinessPolicy is set to ReadinessPolicyIgnore the reference
+	// is resolved regardless of whether the referenced object is ready.
+	ReadinessPolicyIgnore ReadinessPolicy = "Ignore"
+
+	// ReadinessPolicyWaitForReady is a readiness option.
+	// When the ReadinessPolicy is set to ReadinessPolicyWaitForReady the
+	// reference is not resolved until the referenced object is ready.
+	ReadinessPolicyWaitForReady ReadinessPolicy = "WaitForReady"
+)
+
+// A SelectionStrategy determines which object a Selector selects when several
+// objects match.
+type SelectionStrategy string
+
+const (
+	// SelectionStrategyAlphabetical selects the matching object whose name
+	// sorts first.
+	SelectionStrategyAlphabetical SelectionStrategy = "Alphabetical"
+
+	// SelectionStrategyOldest selects the matching object that was created
+	// first.
+	SelectionStrategyOldest SelectionStrategy = "Oldest"
+
+	// SelectionStrategyNewest selects the matching object that was created
+	// last.
+	SelectionStrategyNewest SelectionStrategy = "Newest"
+
+	// SelectionStrategyFailIfAmbiguous fails selection if more than one
+	// object matches.
+	SelectionStrategyFailIfAmbiguous SelectionStrategy = "FailIfAmbiguous"
+)
@@ -18,6 +18,7 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -94,6 +95,15 @@ type Policy struct {
 	// +kubebuilder:default=Required
 	// +kubebuilder:validation:Enum=Required;Optional
 	Resolution *ResolutionPolicy `json:"resolution,omitempty"`
+
+	// Readiness specifies whether the referenced object must be ready before
+	// this reference is resolved. The default is 'Ignore', which resolves the
+	// reference as soon as the referenced field is set. 'WaitForReady' means
+	// the reference will not be resolved until the referenced object has a
+	// Ready condition with status True.
+	// +optional
+	// +kubebuilder:validation:Enum=Ignore;WaitForReady
+	Readiness *ReadinessPolicy `json:"readiness,omitempty"`
 }
 
 // IsResolutionPolicyOptional checks whether the resolution policy of relevant reference is Optional.
@@ -104,6 +114,14 @@ func (p *Policy) IsResolutionPolicyOptional() bool {
 	return *p.Resolution == ResolutionPolicyOptional
 }
 
+// IsReadinessPolicyWaitForReady checks whether the readiness policy of relevant reference is WaitForReady.
+func (p *Policy) IsReadinessPolicyWaitForReady() bool {
+	if p == nil || p.Readiness == nil {
+		return false
+	}
+	return *p.Readiness == ReadinessPolicyWaitForReady
+}
+
 // IsResolvePolicyAlways checks whether the resolution policy of relevant reference is Always.
 func (p *Policy) IsResolvePolicyAlways() bool {
 	if p == nil || p.Resolve == nil {
@@ -120,6 +138,11 @@ type Reference struct {
 	// Policies for referencing.
 	// +optional
 	Policy *Policy `json:"policy,omitempty"`
+
+	// SelectionStrategy that was used to choose the referenced object, if it
+	// was chosen by a Selector.
+	// +optional
+	SelectionStrategy *SelectionStrategy `json:"selectionStrategy,omitempty"`
 }
 
 // A TypedReference refers to an object by Name, Kind, and APIVersion. It is
@@ -145,15 +168,44 @@ type Selector struct {
 	// MatchLabels ensures an object with matching labels is selected.
 	MatchLabels map[string]string `json:"matchLabels,omitempty"`
 
+	// MatchExpressions ensures an object whose labels satisfy all of the
+	// supplied requirements is selected.
+	// +optional
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+
+	// MatchAnnotations ensures an object with matching annotations is
+	// selected.
+	// +optional
+	MatchAnnotations map[string]string `json:"matchAnnotations,omitempty"`
+
 	// MatchControllerRef ensures an object with the same controller reference
 	// as the selecting object is selected.
 	MatchControllerRef *bool `json:"matchControllerRef,omitempty"`
 
+	// Strategy determines which object is selected when several objects
+	// match. The default is 'Alphabetical', which selects the object whose
+	// name sorts first. 'Oldest' and 'Newest' select the object that was
+	// created first or last. 'FailIfAmbiguous' means selection will fail if
+	// more than one object matches. When several objects are selected the
+	// strategy determines their order.
+	// +optional
+	// +kubebuilder:validation:Enum=Alphabetical;Oldest;Newest;FailIfAmbiguous
+	Strategy *SelectionStrategy `json:"strategy,omitempty"`
+
 	// Policies for selection.
 	// +optional
 	Policy *Policy `json:"policy,omitempty"`
 }
 
+// GetStrategy returns the selection strategy of the Selector, or the default
+// strategy if none was specified.
+func (s *Selector) GetStrategy() SelectionStrategy {
+	if s == nil || s.Strategy == nil {
+		return SelectionStrategyAlphabetical
+	}
+	return *s.Strategy
+}
+
 // SetGroupVersionKind sets the Kind and APIVersion of a TypedReference.
 func (obj *TypedReference) SetGroupVersionKind(gvk schema.GroupVersionKind) {
 	obj.APIVersion, obj.Kind = gvk.ToAPIVersionAndKind()
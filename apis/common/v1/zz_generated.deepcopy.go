@@ -22,6 +22,7 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -156,6 +157,21 @@ func (in *EnvSelector) DeepCopy() *EnvSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FilesystemSecretStoreConfig) DeepCopyInto(out *FilesystemSecretStoreConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FilesystemSecretStoreConfig.
+func (in *FilesystemSecretStoreConfig) DeepCopy() *FilesystemSecretStoreConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FilesystemSecretStoreConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FsSelector) DeepCopyInto(out *FsSelector) {
 	*out = *in
@@ -291,6 +307,11 @@ func (in *Policy) DeepCopyInto(out *Policy) {
 		*out = new(ResolutionPolicy)
 		**out = **in
 	}
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(ReadinessPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Policy.
@@ -369,6 +390,11 @@ func (in *Reference) DeepCopyInto(out *Reference) {
 		*out = new(Policy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SelectionStrategy != nil {
+		in, out := &in.SelectionStrategy, &out.SelectionStrategy
+		*out = new(SelectionStrategy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Reference.
@@ -481,6 +507,16 @@ func (in *SecretStoreConfig) DeepCopyInto(out *SecretStoreConfig) {
 		*out = new(PluginStoreConfig)
 		**out = **in
 	}
+	if in.Filesystem != nil {
+		in, out := &in.Filesystem, &out.Filesystem
+		*out = new(FilesystemSecretStoreConfig)
+		**out = **in
+	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultSecretStoreConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretStoreConfig.
@@ -503,11 +539,30 @@ func (in *Selector) DeepCopyInto(out *Selector) {
 			(*out)[key] = val
 		}
 	}
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]metav1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MatchAnnotations != nil {
+		in, out := &in.MatchAnnotations, &out.MatchAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.MatchControllerRef != nil {
 		in, out := &in.MatchControllerRef, &out.MatchControllerRef
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(SelectionStrategy)
+		**out = **in
+	}
 	if in.Policy != nil {
 		in, out := &in.Policy, &out.Policy
 		*out = new(Policy)
@@ -580,3 +635,122 @@ func (in *TypedReference) DeepCopy() *TypedReference {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAuthAppRoleConfig) DeepCopyInto(out *VaultAuthAppRoleConfig) {
+	*out = *in
+	in.CommonCredentialSelectors.DeepCopyInto(&out.CommonCredentialSelectors)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuthAppRoleConfig.
+func (in *VaultAuthAppRoleConfig) DeepCopy() *VaultAuthAppRoleConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAuthAppRoleConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAuthConfig) DeepCopyInto(out *VaultAuthConfig) {
+	*out = *in
+	if in.Token != nil {
+		in, out := &in.Token, &out.Token
+		*out = new(VaultAuthTokenConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AppRole != nil {
+		in, out := &in.AppRole, &out.AppRole
+		*out = new(VaultAuthAppRoleConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Kubernetes != nil {
+		in, out := &in.Kubernetes, &out.Kubernetes
+		*out = new(VaultAuthKubernetesConfig)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuthConfig.
+func (in *VaultAuthConfig) DeepCopy() *VaultAuthConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAuthConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAuthKubernetesConfig) DeepCopyInto(out *VaultAuthKubernetesConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuthKubernetesConfig.
+func (in *VaultAuthKubernetesConfig) DeepCopy() *VaultAuthKubernetesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAuthKubernetesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAuthTokenConfig) DeepCopyInto(out *VaultAuthTokenConfig) {
+	*out = *in
+	in.CommonCredentialSelectors.DeepCopyInto(&out.CommonCredentialSelectors)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuthTokenConfig.
+func (in *VaultAuthTokenConfig) DeepCopy() *VaultAuthTokenConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAuthTokenConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultCABundleConfig) DeepCopyInto(out *VaultCABundleConfig) {
+	*out = *in
+	in.CommonCredentialSelectors.DeepCopyInto(&out.CommonCredentialSelectors)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultCABundleConfig.
+func (in *VaultCABundleConfig) DeepCopy() *VaultCABundleConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultCABundleConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretStoreConfig) DeepCopyInto(out *VaultSecretStoreConfig) {
+	*out = *in
+	if in.Namespace != nil {
+		in, out := &in.Namespace, &out.Namespace
+		*out = new(string)
+		**out = **in
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(VaultCABundleConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Auth.DeepCopyInto(&out.Auth)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretStoreConfig.
+func (in *VaultSecretStoreConfig) DeepCopy() *VaultSecretStoreConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretStoreConfig)
+	in.DeepCopyInto(out)
+	return out
+}
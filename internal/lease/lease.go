@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lease tracks the members of a group of replicas using
+// coordination.k8s.io Leases.
+package lease
+
+import (
+	"context"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Error strings.
+const (
+	errGet    = "cannot get lease"
+	errCreate = "cannot create lease"
+	errUpdate = "cannot update lease"
+	errList   = "cannot list leases"
+	errDelete = "cannot delete lease"
+)
+
+// A Member of a group of replicas. Members announce themselves by renewing a
+// Lease, and are removed from the group when their Lease expires. A member's
+// Lease is labelled with the group it's a member of.
+type Member struct {
+	// Client used to read and write Leases.
+	Client client.Client
+
+	// Namespace the group's Leases are stored in.
+	Namespace string
+
+	// LabelKey is the key of the label whose value is the group.
+	LabelKey string
+
+	// Group the member is a member of.
+	Group string
+
+	// Identity of the member. It must be unique within the group, and a valid
+	// Kubernetes object name.
+	Identity string
+
+	// Duration the member's Lease is valid for after it was last renewed.
+	Duration time.Duration
+}
+
+// Name of the member's Lease.
+func (m Member) Name() string {
+	return m.Group + "-" + m.Identity
+}
+
+// Renew the member's Lease, creating it if it doesn't exist.
+func (m Member) Renew(ctx context.Context, t time.Time) error {
+	now := metav1.NewMicroTime(t)
+	identity := m.Identity
+	seconds := int32(m.Duration.Seconds())
+	ls := &coordinationv1.Lease{}
+	err := m.Client.Get(ctx, types.NamespacedName{Namespace: m.Namespace, Name: m.Name()}, ls)
+	if kerrors.IsNotFound(err) {
+		ls = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: m.Namespace,
+				Name:      m.Name(),
+				Labels:    map[string]string{m.LabelKey: m.Group},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &identity,
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		return errors.Wrap(m.Client.Create(ctx, ls), errCreate)
+	}
+	if err != nil {
+		return errors.Wrap(err, errGet)
+	}
+
+	ls.Spec.HolderIdentity = &identity
+	ls.Spec.LeaseDurationSeconds = &seconds
+	ls.Spec.RenewTime = &now
+	return errors.Wrap(m.Client.Update(ctx, ls), errUpdate)
+}
+
+// Live returns the identities of the members of the group whose Leases had not
+// expired at the supplied time, in the order their Leases were listed. The
+// member's own identity is only included if its Lease is listed.
+func (m Member) Live(ctx context.Context, now time.Time) ([]string, error) {
+	l := &coordinationv1.LeaseList{}
+	if err := m.Client.List(ctx, l, client.InNamespace(m.Namespace), client.MatchingLabels{m.LabelKey: m.Group}); err != nil {
+		return nil, errors.Wrap(err, errList)
+	}
+
+	live := make([]string, 0, len(l.Items))
+	for _, ls := range l.Items {
+		if ls.Spec.HolderIdentity == nil || expired(ls, now) {
+			continue
+		}
+		live = append(live, *ls.Spec.HolderIdentity)
+	}
+	return live, nil
+}
+
+// Leave the group by deleting the member's Lease, so that other members don't
+// have to wait for it to expire.
+func (m Member) Leave(ctx context.Context) error {
+	ls := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Namespace: m.Namespace, Name: m.Name()}}
+	return errors.Wrap(client.IgnoreNotFound(m.Client.Delete(ctx, ls)), errDelete)
+}
+
+func expired(ls coordinationv1.Lease, now time.Time) bool {
+	if ls.Spec.RenewTime == nil || ls.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return ls.Spec.RenewTime.Add(time.Duration(*ls.Spec.LeaseDurationSeconds) * time.Second).Before(now)
+}
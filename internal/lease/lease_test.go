@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestMember(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+
+	member := func(id string) Member {
+		return Member{Client: c, Namespace: "crossplane-system", LabelKey: "example.org/group", Group: "cool", Identity: id, Duration: 15 * time.Second}
+	}
+	a, b := member("a"), member("b")
+
+	// Renewing a Lease should create it if it doesn't exist, and update it
+	// if it does.
+	for _, m := range []Member{a, b, a} {
+		if err := m.Renew(ctx, now); err != nil {
+			t.Fatalf("m.Renew(...): %s", err)
+		}
+	}
+	live, err := a.Live(ctx, now)
+	if err != nil {
+		t.Fatalf("a.Live(...): %s", err)
+	}
+	if diff := cmp.Diff([]string{"a", "b"}, live); diff != "" {
+		t.Errorf("a.Live(...): -want, +got:\n%s", diff)
+	}
+
+	// Members whose Lease expired are not live.
+	if err := a.Renew(ctx, now.Add(time.Minute)); err != nil {
+		t.Fatalf("a.Renew(...): %s", err)
+	}
+	live, err = a.Live(ctx, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("a.Live(...): %s", err)
+	}
+	if diff := cmp.Diff([]string{"a"}, live); diff != "" {
+		t.Errorf("a.Live(...): -want, +got:\n%s", diff)
+	}
+
+	// Members that left the group are not live, and leaving twice is fine.
+	for i := 0; i < 2; i++ {
+		if err := a.Leave(ctx); err != nil {
+			t.Fatalf("a.Leave(...): %s", err)
+		}
+	}
+	live, err = b.Live(ctx, now)
+	if err != nil {
+		t.Fatalf("b.Live(...): %s", err)
+	}
+	if diff := cmp.Diff([]string{"b"}, live); diff != "" {
+		t.Errorf("b.Live(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestMemberErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	m := Member{
+		Client: &test.MockClient{
+			MockGet:  test.NewMockGetFn(errBoom),
+			MockList: test.NewMockListFn(errBoom),
+		},
+		Group:    "cool",
+		Identity: "a",
+	}
+
+	err := m.Renew(context.Background(), time.Now())
+	if diff := cmp.Diff(errors.Wrap(errBoom, errGet), err, test.EquateErrors()); diff != "" {
+		t.Errorf("m.Renew(...): -want error, +got error:\n%s", diff)
+	}
+	_, err = m.Live(context.Background(), time.Now())
+	if diff := cmp.Diff(errors.Wrap(errBoom, errList), err, test.EquateErrors()); diff != "" {
+		t.Errorf("m.Live(...): -want error, +got error:\n%s", diff)
+	}
+}
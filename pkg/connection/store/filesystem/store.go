@@ -0,0 +1,390 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package filesystem implements a secret store backed by a local filesystem.
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/connection/store"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Error strings.
+const (
+	errNoPath        = "filesystem secret store path is required"
+	errReadSecret    = "cannot read secret"
+	errReadMetadata  = "cannot read secret metadata"
+	errWriteSecret   = "cannot write secret"
+	errWriteMetadata = "cannot write secret metadata"
+	errDeleteSecret  = "cannot delete secret"
+
+	errFmtInvalidName = "invalid secret name or scope %q: must be a single path element"
+	errFmtInvalidKey  = "invalid secret key %q: must be a single path element that does not start with '..'"
+)
+
+// Like the files Kubernetes uses to atomically update projected volumes, the
+// names of the files and directories that aren't keys start with '..' so that
+// they can't collide with a key.
+const (
+	// metadataFile stores a secret's metadata alongside its keys.
+	metadataFile = "..metadata.json"
+
+	// dataDir is a symlink to the timestamped directory that holds the
+	// current version of a secret's keys and metadata.
+	dataDir = "..data"
+
+	// dataDirTmp is the name a new dataDir symlink is created with before
+	// it's renamed into place.
+	dataDirTmp = "..data_tmp"
+
+	// versionFormat is the time format of the prefix of a timestamped
+	// directory's name.
+	versionFormat = "..2006_01_02_15_04_05."
+
+	dirMode  = 0o700
+	fileMode = 0o600
+)
+
+// SecretStore is a local filesystem secret store. Each secret is stored as a
+// directory named <path>/<scope>/<name>, with one file per key. Like the
+// Kubernetes atomic writer that updates projected volumes, each version of a
+// secret's keys and metadata is written to a new timestamped directory, which
+// a '..data' symlink is atomically renamed to point to. Each key is a symlink
+// to the file of the same name in '..data', so readers see either the old or
+// the new version of a secret, never a mix of both. Files are only readable by
+// their owner.
+type SecretStore struct {
+	root         string
+	defaultScope string
+
+	mx sync.Mutex
+}
+
+// NewSecretStore returns a new filesystem SecretStore.
+func NewSecretStore(_ context.Context, _ client.Client, _ *tls.Config, cfg v1.SecretStoreConfig) (*SecretStore, error) {
+	if cfg.Filesystem == nil || cfg.Filesystem.Path == "" {
+		return nil, errors.New(errNoPath)
+	}
+	return &SecretStore{root: cfg.Filesystem.Path, defaultScope: cfg.DefaultScope}, nil
+}
+
+// ReadKeyValues reads and returns key value pairs for a given secret.
+func (ss *SecretStore) ReadKeyValues(_ context.Context, n store.ScopedName, s *store.Secret) error {
+	dir, err := ss.dirForSecret(n)
+	if err != nil {
+		return err
+	}
+
+	ss.mx.Lock()
+	defer ss.mx.Unlock()
+
+	cs, _, err := read(dir)
+	if err != nil {
+		return err
+	}
+	s.Data = cs.Data
+	s.Metadata = cs.Metadata
+	return nil
+}
+
+// WriteKeyValues writes key value pairs to a given secret. Like the Kubernetes
+// secret store it replaces all of the secret's data; keys that exist but
+// aren't supplied are removed.
+func (ss *SecretStore) WriteKeyValues(ctx context.Context, s *store.Secret, wo ...store.WriteOption) (bool, error) {
+	dir, err := ss.dirForSecret(s.ScopedName)
+	if err != nil {
+		return false, err
+	}
+	for k := range s.Data {
+		if !validKey(k) {
+			return false, errors.Errorf(errFmtInvalidKey, k)
+		}
+	}
+
+	ss.mx.Lock()
+	defer ss.mx.Unlock()
+
+	cs, exists, err := read(dir)
+	if err != nil {
+		return false, err
+	}
+
+	ds := &store.Secret{ScopedName: s.ScopedName, Metadata: &v1.ConnectionSecretMetadata{}, Data: s.Data}
+	if s.Metadata != nil {
+		ds.Metadata = s.Metadata.DeepCopy()
+	}
+
+	// Like the Kubernetes secret store we only call write options when we're
+	// updating an existing secret.
+	if exists {
+		for _, o := range wo {
+			if err := o(ctx, cs, ds); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	md, err := json.Marshal(ds.Metadata)
+	if err != nil {
+		return false, errors.Wrap(err, errWriteMetadata)
+	}
+	if exists && equal(cs.Data, ds.Data) && sameMetadata(cs.Metadata, md) {
+		return false, nil
+	}
+
+	return true, errors.Wrap(write(dir, cs.Data, ds.Data, md), errWriteSecret)
+}
+
+// DeleteKeyValues delete key value pairs from a given secret. If no keys are
+// supplied the whole secret is deleted. If keys are supplied only those keys
+// are deleted, and the secret is deleted only if no keys are left.
+func (ss *SecretStore) DeleteKeyValues(ctx context.Context, s *store.Secret, do ...store.DeleteOption) error {
+	dir, err := ss.dirForSecret(s.ScopedName)
+	if err != nil {
+		return err
+	}
+
+	ss.mx.Lock()
+	defer ss.mx.Unlock()
+
+	cs, exists, err := read(dir)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		// Secret already deleted, nothing to do.
+		return nil
+	}
+
+	for _, o := range do {
+		if err := o(ctx, cs); err != nil {
+			return err
+		}
+	}
+
+	remaining := make(store.KeyValues, len(cs.Data))
+	for k, v := range cs.Data {
+		if _, ok := s.Data[k]; !ok {
+			remaining[k] = v
+		}
+	}
+	if len(s.Data) == 0 || len(remaining) == 0 {
+		return errors.Wrap(os.RemoveAll(dir), errDeleteSecret)
+	}
+
+	md := cs.Metadata
+	if md == nil {
+		md = &v1.ConnectionSecretMetadata{}
+	}
+	b, err := json.Marshal(md)
+	if err != nil {
+		return errors.Wrap(err, errDeleteSecret)
+	}
+	return errors.Wrap(write(dir, cs.Data, remaining, b), errDeleteSecret)
+}
+
+func (ss *SecretStore) dirForSecret(n store.ScopedName) (string, error) {
+	scope := n.Scope
+	if scope == "" {
+		scope = ss.defaultScope
+	}
+	if !validName(n.Name) {
+		return "", errors.Errorf(errFmtInvalidName, n.Name)
+	}
+	if scope != "" && !validName(scope) {
+		return "", errors.Errorf(errFmtInvalidName, scope)
+	}
+	return filepath.Join(ss.root, scope, n.Name), nil
+}
+
+// read the current version of the secret stored in the supplied directory. It
+// returns false if the secret doesn't exist.
+func read(dir string) (*store.Secret, bool, error) {
+	s := &store.Secret{}
+	version, err := os.Readlink(filepath.Join(dir, dataDir))
+	if os.IsNotExist(err) {
+		return s, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, errReadSecret)
+	}
+	vdir := filepath.Join(dir, version)
+	entries, err := os.ReadDir(vdir)
+	if err != nil {
+		return nil, false, errors.Wrap(err, errReadSecret)
+	}
+
+	s.Data = make(store.KeyValues, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !validKey(e.Name()) {
+			continue
+		}
+		v, err := os.ReadFile(filepath.Join(vdir, e.Name()))
+		if err != nil {
+			return nil, false, errors.Wrap(err, errReadSecret)
+		}
+		s.Data[e.Name()] = v
+	}
+
+	md, err := os.ReadFile(filepath.Join(vdir, metadataFile))
+	if os.IsNotExist(err) {
+		return s, true, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, errReadMetadata)
+	}
+	s.Metadata = &v1.ConnectionSecretMetadata{}
+	return s, true, errors.Wrap(json.Unmarshal(md, s.Metadata), errReadMetadata)
+}
+
+// write a new version of the secret stored in the supplied directory, with the
+// supplied data and encoded metadata. The new version is written to a new
+// timestamped directory, then the directory's dataDir symlink is atomically
+// replaced with one that points to it. Symlinks to keys that are no longer
+// supplied are removed, and older versions are removed once they're no longer
+// current.
+func write(dir string, current, desired store.KeyValues, md []byte) error {
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return err
+	}
+
+	// MkdirTemp creates directories with mode 0700.
+	vdir, err := os.MkdirTemp(dir, time.Now().UTC().Format(versionFormat))
+	if err != nil {
+		return err
+	}
+	for k, v := range desired {
+		if err := writeFile(filepath.Join(vdir, k), v); err != nil {
+			_ = os.RemoveAll(vdir)
+			return err
+		}
+	}
+	if err := writeFile(filepath.Join(vdir, metadataFile), md); err != nil {
+		_ = os.RemoveAll(vdir)
+		return err
+	}
+
+	// Rename is atomic, so dataDir always points to a complete version.
+	tmp := filepath.Join(dir, dataDirTmp)
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		_ = os.RemoveAll(vdir)
+		return err
+	}
+	if err := os.Symlink(filepath.Base(vdir), tmp); err != nil {
+		_ = os.RemoveAll(vdir)
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, dataDir)); err != nil {
+		_ = os.RemoveAll(vdir)
+		return err
+	}
+
+	for k := range desired {
+		link := filepath.Join(dir, k)
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		}
+		if err := os.Symlink(filepath.Join(dataDir, k), link); err != nil {
+			return err
+		}
+	}
+	for k := range current {
+		if _, ok := desired[k]; ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, k)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// Remove older versions, including any left behind by failed writes.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "..") || e.Name() == filepath.Base(vdir) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile writes the supplied data to a new file that is only readable by
+// its owner.
+func writeFile(name string, data []byte) error {
+	f, err := os.OpenFile(filepath.Clean(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, fileMode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// equal returns true if the supplied key values are exactly equal.
+func equal(a, b store.KeyValues) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		bv, ok := b[k]
+		if !ok || !bytes.Equal(v, bv) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameMetadata returns true if the supplied current metadata encodes to the
+// supplied desired, encoded metadata.
+func sameMetadata(current *v1.ConnectionSecretMetadata, desired []byte) bool {
+	if current == nil {
+		return false
+	}
+	b, err := json.Marshal(current)
+	return err == nil && bytes.Equal(b, desired)
+}
+
+func validName(n string) bool {
+	return n != "" && n != "." && n != ".." && !strings.ContainsAny(n, `/\`)
+}
+
+func validKey(k string) bool {
+	return validName(k) && !strings.HasPrefix(k, "..")
+}
@@ -0,0 +1,403 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/connection/store"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+var (
+	errBoom = errors.New("boom")
+
+	fakeSecretName  = "fake"
+	fakeSecretScope = "fake-namespace"
+	fakeOwnerID     = "00000000-0000-0000-0000-000000000000"
+)
+
+func fakeKV() store.KeyValues {
+	return store.KeyValues{
+		"key1": []byte("value1"),
+		"key2": []byte("value2"),
+	}
+}
+
+func fakeMetadata() *v1.ConnectionSecretMetadata {
+	md := &v1.ConnectionSecretMetadata{Labels: map[string]string{"environment": "unit-test"}}
+	md.SetOwnerUID(types.UID(fakeOwnerID))
+	return md
+}
+
+func fakeSecret(data store.KeyValues) *store.Secret {
+	return &store.Secret{
+		ScopedName: store.ScopedName{Name: fakeSecretName, Scope: fakeSecretScope},
+		Metadata:   fakeMetadata(),
+		Data:       data,
+	}
+}
+
+// newStore returns a SecretStore rooted at a temporary directory, and writes
+// the supplied secret to it, if any.
+func newStore(t *testing.T, s *store.Secret) *SecretStore {
+	t.Helper()
+	ss, err := NewSecretStore(context.Background(), nil, nil, v1.SecretStoreConfig{
+		DefaultScope: "crossplane-system",
+		Filesystem:   &v1.FilesystemSecretStoreConfig{Path: t.TempDir()},
+	})
+	if err != nil {
+		t.Fatalf("NewSecretStore(...): %s", err)
+	}
+	if s != nil {
+		if _, err := ss.WriteKeyValues(context.Background(), s); err != nil {
+			t.Fatalf("ss.WriteKeyValues(...): %s", err)
+		}
+	}
+	return ss
+}
+
+func TestSecretStoreReadKeyValues(t *testing.T) {
+	type want struct {
+		result *store.Secret
+		err    error
+	}
+
+	cases := map[string]struct {
+		reason   string
+		existing *store.Secret
+		name     store.ScopedName
+		want     want
+	}{
+		"SecretNotFound": {
+			reason: "Should return nil as an error if secret is not found",
+			name:   store.ScopedName{Name: fakeSecretName, Scope: fakeSecretScope},
+			want:   want{result: &store.Secret{}},
+		},
+		"InvalidName": {
+			reason: "Should return an error if the secret's name is not a single path element",
+			name:   store.ScopedName{Name: "../etc", Scope: fakeSecretScope},
+			want: want{
+				result: &store.Secret{},
+				err:    errors.Errorf(errFmtInvalidName, "../etc"),
+			},
+		},
+		"Success": {
+			reason:   "Should return all key values and metadata after a success read",
+			existing: fakeSecret(fakeKV()),
+			name:     store.ScopedName{Name: fakeSecretName, Scope: fakeSecretScope},
+			want: want{
+				result: &store.Secret{Metadata: fakeMetadata(), Data: fakeKV()},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ss := newStore(t, tc.existing)
+			s := &store.Secret{}
+			err := ss.ReadKeyValues(context.Background(), tc.name, s)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nss.ReadKeyValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.result, s, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nss.ReadKeyValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSecretStoreWriteKeyValues(t *testing.T) {
+	type args struct {
+		secret *store.Secret
+		wo     []store.WriteOption
+	}
+	type want struct {
+		changed  bool
+		data     store.KeyValues
+		metadata *v1.ConnectionSecretMetadata
+		err      error
+	}
+
+	cases := map[string]struct {
+		reason   string
+		existing *store.Secret
+		args     args
+		want     want
+	}{
+		"SecretCreated": {
+			reason: "Should create a secret with all key values.",
+			args:   args{secret: fakeSecret(fakeKV())},
+			want:   want{changed: true, data: fakeKV()},
+		},
+		"InvalidKey": {
+			reason: "Should return an error if a key could collide with the secret's metadata.",
+			args:   args{secret: fakeSecret(store.KeyValues{metadataFile: []byte("{}")})},
+			want:   want{err: errors.Errorf(errFmtInvalidKey, metadataFile)},
+		},
+		"WriteOptionFails": {
+			reason:   "Should return a proper error if supplied write option fails.",
+			existing: fakeSecret(fakeKV()),
+			args: args{
+				secret: fakeSecret(store.KeyValues{"key1": []byte("new")}),
+				wo: []store.WriteOption{func(_ context.Context, _, _ *store.Secret) error {
+					return errBoom
+				}},
+			},
+			want: want{err: errBoom, data: fakeKV()},
+		},
+		"OwnerChecked": {
+			reason:   "Write options should be passed the current secret, including its owner.",
+			existing: fakeSecret(fakeKV()),
+			args: args{
+				secret: fakeSecret(store.KeyValues{"key1": []byte("new")}),
+				wo: []store.WriteOption{func(_ context.Context, current, _ *store.Secret) error {
+					if current.GetOwner() != fakeOwnerID {
+						return errBoom
+					}
+					return nil
+				}},
+			},
+			want: want{changed: true, data: store.KeyValues{"key1": []byte("new")}},
+		},
+		"AlreadyUpToDate": {
+			reason:   "Should not change secret if already up to date.",
+			existing: fakeSecret(fakeKV()),
+			args:     args{secret: fakeSecret(fakeKV())},
+			want:     want{changed: false, data: fakeKV()},
+		},
+		"NewKeyAdded": {
+			reason:   "Should replace the existing secret's data if a new key added.",
+			existing: fakeSecret(fakeKV()),
+			args:     args{secret: fakeSecret(store.KeyValues{"key3": []byte("value3")})},
+			want:     want{changed: true, data: store.KeyValues{"key3": []byte("value3")}},
+		},
+		"KeyRemoved": {
+			reason:   "Should remove keys that exist but aren't supplied.",
+			existing: fakeSecret(fakeKV()),
+			args:     args{secret: fakeSecret(store.KeyValues{"key1": []byte("value1")})},
+			want:     want{changed: true, data: store.KeyValues{"key1": []byte("value1")}},
+		},
+		"MetadataChanged": {
+			reason:   "Should update the secret's metadata if only its metadata changed.",
+			existing: fakeSecret(fakeKV()),
+			args: args{secret: &store.Secret{
+				ScopedName: store.ScopedName{Name: fakeSecretName, Scope: fakeSecretScope},
+				Metadata:   &v1.ConnectionSecretMetadata{Labels: map[string]string{"environment": "prod"}},
+				Data:       fakeKV(),
+			}},
+			want: want{changed: true, data: fakeKV(), metadata: &v1.ConnectionSecretMetadata{Labels: map[string]string{"environment": "prod"}}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ss := newStore(t, tc.existing)
+			changed, err := ss.WriteKeyValues(context.Background(), tc.args.secret, tc.args.wo...)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nss.WriteKeyValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.changed, changed); diff != "" {
+				t.Errorf("\n%s\nss.WriteKeyValues(...): -want changed, +got changed:\n%s", tc.reason, diff)
+			}
+			got := &store.Secret{}
+			if err := ss.ReadKeyValues(context.Background(), tc.args.secret.ScopedName, got); err != nil {
+				t.Fatalf("ss.ReadKeyValues(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.want.data, got.Data, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nss.WriteKeyValues(...): -want data, +got data:\n%s", tc.reason, diff)
+			}
+			if tc.want.metadata != nil {
+				if diff := cmp.Diff(tc.want.metadata, got.Metadata); diff != "" {
+					t.Errorf("\n%s\nss.WriteKeyValues(...): -want metadata, +got metadata:\n%s", tc.reason, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretStoreWritePermissions(t *testing.T) {
+	ss := newStore(t, fakeSecret(fakeKV()))
+	dir := filepath.Join(ss.root, fakeSecretScope, fakeSecretName)
+
+	for _, d := range []string{dir, filepath.Join(dir, dataDir)} {
+		fi, err := os.Stat(d)
+		if err != nil {
+			t.Fatalf("os.Stat(...): %s", err)
+		}
+		if diff := cmp.Diff(os.FileMode(dirMode), fi.Mode().Perm()); diff != "" {
+			t.Errorf("%s mode: -want, +got:\n%s", d, diff)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, dataDir))
+	if err != nil {
+		t.Fatalf("os.ReadDir(...): %s", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+		fi, err := e.Info()
+		if err != nil {
+			t.Fatalf("e.Info(): %s", err)
+		}
+		if diff := cmp.Diff(os.FileMode(fileMode), fi.Mode().Perm()); diff != "" {
+			t.Errorf("%s mode: -want, +got:\n%s", e.Name(), diff)
+		}
+	}
+	if diff := cmp.Diff([]string{metadataFile, "key1", "key2"}, names); diff != "" {
+		t.Errorf("files: -want, +got:\n%s", diff)
+	}
+}
+
+func TestSecretStoreWriteAtomic(t *testing.T) {
+	ss := newStore(t, fakeSecret(fakeKV()))
+	dir := filepath.Join(ss.root, fakeSecretScope, fakeSecretName)
+
+	if _, err := ss.WriteKeyValues(context.Background(), fakeSecret(store.KeyValues{"key1": []byte("new")})); err != nil {
+		t.Fatalf("ss.WriteKeyValues(...): %s", err)
+	}
+
+	// Keys should be symlinks into the current version, which should be the
+	// only version left.
+	version, err := os.Readlink(filepath.Join(dir, dataDir))
+	if err != nil {
+		t.Fatalf("os.Readlink(...): %s", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("os.ReadDir(...): %s", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if diff := cmp.Diff([]string{version, dataDir, "key1"}, names, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("files: -want, +got:\n%s", diff)
+	}
+
+	link, err := os.Readlink(filepath.Join(dir, "key1"))
+	if err != nil {
+		t.Fatalf("os.Readlink(...): %s", err)
+	}
+	if diff := cmp.Diff(filepath.Join(dataDir, "key1"), link); diff != "" {
+		t.Errorf("key1 link: -want, +got:\n%s", diff)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "key1"))
+	if err != nil {
+		t.Fatalf("os.ReadFile(...): %s", err)
+	}
+	if diff := cmp.Diff("new", string(got)); diff != "" {
+		t.Errorf("key1: -want, +got:\n%s", diff)
+	}
+}
+
+func TestSecretStoreDeleteKeyValues(t *testing.T) {
+	type args struct {
+		secret *store.Secret
+		do     []store.DeleteOption
+	}
+	type want struct {
+		data store.KeyValues
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason   string
+		existing *store.Secret
+		args     args
+		want     want
+	}{
+		"AlreadyDeleted": {
+			reason: "Should not return error if secret already deleted.",
+			args:   args{secret: fakeSecret(nil)},
+		},
+		"DeleteOptionFails": {
+			reason:   "Should return a proper error if provided delete option fails.",
+			existing: fakeSecret(fakeKV()),
+			args: args{
+				secret: fakeSecret(nil),
+				do: []store.DeleteOption{func(_ context.Context, _ *store.Secret) error {
+					return errBoom
+				}},
+			},
+			want: want{err: errBoom, data: fakeKV()},
+		},
+		"OwnerChecked": {
+			reason:   "Delete options should be passed the current secret, including its owner.",
+			existing: fakeSecret(fakeKV()),
+			args: args{
+				secret: &store.Secret{ScopedName: store.ScopedName{Name: fakeSecretName, Scope: fakeSecretScope}},
+				do: []store.DeleteOption{func(_ context.Context, current *store.Secret) error {
+					if current.GetOwner() != fakeOwnerID {
+						return errBoom
+					}
+					return nil
+				}},
+			},
+		},
+		"KeysDeleted": {
+			reason:   "Should remove supplied keys from secret and keep the remaining.",
+			existing: fakeSecret(fakeKV()),
+			args:     args{secret: fakeSecret(store.KeyValues{"key1": nil})},
+			want:     want{data: store.KeyValues{"key2": []byte("value2")}},
+		},
+		"AllKeysDeleted": {
+			reason:   "Should delete the whole secret if no keys are left.",
+			existing: fakeSecret(fakeKV()),
+			args:     args{secret: fakeSecret(store.KeyValues{"key1": nil, "key2": nil})},
+		},
+		"SecretDeleted": {
+			reason:   "Should delete the whole secret if no kv supplied as parameter.",
+			existing: fakeSecret(fakeKV()),
+			args:     args{secret: fakeSecret(nil)},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ss := newStore(t, tc.existing)
+			err := ss.DeleteKeyValues(context.Background(), tc.args.secret, tc.args.do...)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nss.DeleteKeyValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			got := &store.Secret{}
+			if err := ss.ReadKeyValues(context.Background(), tc.args.secret.ScopedName, got); err != nil {
+				t.Fatalf("ss.ReadKeyValues(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.want.data, got.Data, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nss.DeleteKeyValues(...): -want data, +got data:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestNewSecretStore(t *testing.T) {
+	_, err := NewSecretStore(context.Background(), nil, nil, v1.SecretStoreConfig{})
+	if diff := cmp.Diff(errors.New(errNoPath), err, test.EquateErrors()); diff != "" {
+		t.Errorf("NewSecretStore(...): -want error, +got error:\n%s", diff)
+	}
+}
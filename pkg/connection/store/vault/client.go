@@ -0,0 +1,271 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Error strings.
+const (
+	errEncodeRequest  = "cannot encode request body"
+	errBuildRequest   = "cannot build request"
+	errSendRequest    = "cannot send request"
+	errDecodeResponse = "cannot decode response body"
+	errLogin          = "cannot log in to vault"
+	errReadJWT        = "cannot read service account token"
+	errNoClientToken  = "login response did not include a client token"
+
+	errFmtRequestFailed = "%s %s failed with status %d: %s"
+)
+
+const (
+	headerToken     = "X-Vault-Token"
+	headerNamespace = "X-Vault-Namespace"
+)
+
+// A kvSecret is a secret stored in a KV v2 secrets engine.
+type kvSecret struct {
+	Data           map[string]string
+	CustomMetadata map[string]string
+}
+
+// A loginFn logs in to Vault using the supplied client, and returns a client
+// token.
+type loginFn func(ctx context.Context, c *kvClient) (string, error)
+
+// A kvClient is a minimal client for the HTTP API of a Vault KV v2 secrets
+// engine.
+type kvClient struct {
+	http      *http.Client
+	server    string
+	mount     string
+	namespace string
+
+	// login is used to get a client token when the client has none, or its
+	// token is rejected. Clients using token auth have no login function.
+	login loginFn
+
+	mx    sync.Mutex
+	token string
+}
+
+// read the secret at the supplied path. It returns nil if the secret does not
+// exist, or its latest version was deleted.
+func (c *kvClient) read(ctx context.Context, path string) (*kvSecret, error) {
+	resp := &struct {
+		Data struct {
+			Data     map[string]string `json:"data"`
+			Metadata struct {
+				CustomMetadata map[string]string `json:"custom_metadata"`
+			} `json:"metadata"`
+		} `json:"data"`
+	}{}
+	found, err := c.do(ctx, http.MethodGet, c.mount+"/data/"+path, nil, resp)
+	if err != nil || !found || resp.Data.Data == nil {
+		return nil, err
+	}
+	return &kvSecret{Data: resp.Data.Data, CustomMetadata: resp.Data.Metadata.CustomMetadata}, nil
+}
+
+// readMetadata reads the custom metadata of the secret at the supplied path.
+// Unlike its data, a secret's metadata exists until the secret is destroyed,
+// even if its latest version was deleted. It returns false if the secret does
+// not exist.
+func (c *kvClient) readMetadata(ctx context.Context, path string) (map[string]string, bool, error) {
+	resp := &struct {
+		Data struct {
+			CustomMetadata map[string]string `json:"custom_metadata"`
+		} `json:"data"`
+	}{}
+	found, err := c.do(ctx, http.MethodGet, c.mount+"/metadata/"+path, nil, resp)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	return resp.Data.CustomMetadata, true, nil
+}
+
+// write a new version of the secret at the supplied path.
+func (c *kvClient) write(ctx context.Context, path string, data map[string]string) error {
+	_, err := c.do(ctx, http.MethodPost, c.mount+"/data/"+path, map[string]any{"data": data}, nil)
+	return err
+}
+
+// writeMetadata replaces the custom metadata of the secret at the supplied
+// path.
+func (c *kvClient) writeMetadata(ctx context.Context, path string, custom map[string]string) error {
+	if custom == nil {
+		// Vault leaves custom metadata unchanged if it's omitted or null.
+		custom = map[string]string{}
+	}
+	_, err := c.do(ctx, http.MethodPost, c.mount+"/metadata/"+path, map[string]any{"custom_metadata": custom}, nil)
+	return err
+}
+
+// destroy the secret at the supplied path, including its metadata and all of
+// its versions.
+func (c *kvClient) destroy(ctx context.Context, path string) error {
+	_, err := c.do(ctx, http.MethodDelete, c.mount+"/metadata/"+path, nil, nil)
+	return err
+}
+
+// do an authenticated request. If the client's token is rejected and the
+// client can log in, it logs in and retries the request once. It returns false
+// if the requested path was not found.
+func (c *kvClient) do(ctx context.Context, method, path string, in, out any) (bool, error) {
+	token, err := c.getToken(ctx, false)
+	if err != nil {
+		return false, err
+	}
+	status, err := c.send(ctx, method, path, token, in, out)
+	if (status == http.StatusForbidden || status == http.StatusUnauthorized) && c.login != nil {
+		if token, err = c.getToken(ctx, true); err != nil {
+			return false, err
+		}
+		status, err = c.send(ctx, method, path, token, in, out)
+	}
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// getToken returns the client's token, logging in to get a new one if the
+// client has none or if forced to.
+func (c *kvClient) getToken(ctx context.Context, force bool) (string, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.login == nil || (c.token != "" && !force) {
+		return c.token, nil
+	}
+	t, err := c.login(ctx, c)
+	if err != nil {
+		return "", errors.Wrap(err, errLogin)
+	}
+	c.token = t
+	return t, nil
+}
+
+// send a request to the supplied path, relative to the server's /v1 API. The
+// supplied input is encoded as the request body, and the response body is
+// decoded into the supplied output, if they're not nil. It returns the
+// response status, if any.
+func (c *kvClient) send(ctx context.Context, method, path, token string, in, out any) (int, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return 0, errors.Wrap(err, errEncodeRequest)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	url := strings.TrimSuffix(c.server, "/") + "/v1/" + path
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return 0, errors.Wrap(err, errBuildRequest)
+	}
+	if token != "" {
+		req.Header.Set(headerToken, token)
+	}
+	if c.namespace != "" {
+		req.Header.Set(headerNamespace, c.namespace)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, errSendRequest)
+	}
+	defer resp.Body.Close() //nolint:errcheck // Nothing useful to do with this error.
+
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.StatusCode, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.Errorf(errFmtRequestFailed, method, path, resp.StatusCode, responseErrors(resp.Body))
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, errors.Wrap(json.NewDecoder(resp.Body).Decode(out), errDecodeResponse)
+}
+
+// responseErrors returns the errors in the supplied Vault error response body.
+func responseErrors(body io.Reader) string {
+	r := &struct {
+		Errors []string `json:"errors"`
+	}{}
+	if err := json.NewDecoder(body).Decode(r); err != nil || len(r.Errors) == 0 {
+		return "unknown error"
+	}
+	return strings.Join(r.Errors, ", ")
+}
+
+// loginWith returns a loginFn that logs in by writing the supplied request
+// body to the login endpoint of the auth method at the supplied mount path.
+func loginWith(mount string, body func() (map[string]string, error)) loginFn {
+	return func(ctx context.Context, c *kvClient) (string, error) {
+		in, err := body()
+		if err != nil {
+			return "", err
+		}
+		out := &struct {
+			Auth struct {
+				ClientToken string `json:"client_token"`
+			} `json:"auth"`
+		}{}
+		if _, err := c.send(ctx, http.MethodPost, "auth/"+mount+"/login", "", in, out); err != nil {
+			return "", err
+		}
+		if out.Auth.ClientToken == "" {
+			return "", errors.New(errNoClientToken)
+		}
+		return out.Auth.ClientToken, nil
+	}
+}
+
+// appRoleLogin logs in using the AppRole auth method.
+func appRoleLogin(mount, roleID, secretID string) loginFn {
+	return loginWith(mount, func() (map[string]string, error) {
+		return map[string]string{"role_id": roleID, "secret_id": secretID}, nil
+	})
+}
+
+// kubernetesLogin logs in using the Kubernetes auth method. The service
+// account token is read each time the client logs in, because Kubernetes
+// rotates it.
+func kubernetesLogin(mount, role, tokenPath string) loginFn {
+	return loginWith(mount, func() (map[string]string, error) {
+		jwt, err := os.ReadFile(tokenPath) //nolint:gosec // Reading the configured token is intended.
+		if err != nil {
+			return nil, errors.Wrap(err, errReadJWT)
+		}
+		return map[string]string{"role": role, "jwt": strings.TrimSpace(string(jwt))}, nil
+	})
+}
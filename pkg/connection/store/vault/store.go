@@ -0,0 +1,293 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vault implements a secret store backed by the KV v2 secrets engine
+// of HashiCorp Vault.
+package vault
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/connection/store"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// Error strings.
+const (
+	errNoConfig        = "vault secret store config is required"
+	errExtractCABundle = "cannot extract CA bundle"
+	errAppendCABundle  = "cannot append CA bundle"
+	errExtractToken    = "cannot extract token"
+	errExtractSecretID = "cannot extract AppRole secret ID"
+	errRead            = "cannot read secret"
+	errWrite           = "cannot write secret"
+	errReadMetadata    = "cannot read secret metadata"
+	errWriteMetadata   = "cannot write secret metadata"
+	errDelete          = "cannot delete secret"
+
+	errFmtNoAuthConfig      = "auth config for method %q is required"
+	errFmtUnknownAuthMethod = "unknown auth method: %q"
+)
+
+// Auth method defaults.
+const (
+	DefaultAppRoleMountPath    = "approle"
+	DefaultKubernetesMountPath = "kubernetes"
+	DefaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token" //nolint:gosec // This is a path, not a credential.
+)
+
+// SecretStore is a Vault Secret Store. Secrets are stored in a KV v2 secrets
+// engine at <scope>/<name>. Their metadata labels, including the label that
+// records their owner, are stored as the secret's custom metadata.
+type SecretStore struct {
+	client *kvClient
+
+	defaultScope string
+}
+
+// NewSecretStore returns a new Vault SecretStore.
+func NewSecretStore(ctx context.Context, kube client.Client, _ *tls.Config, cfg v1.SecretStoreConfig) (*SecretStore, error) {
+	if cfg.Vault == nil {
+		return nil, errors.New(errNoConfig)
+	}
+	c := &kvClient{
+		http:   &http.Client{},
+		server: cfg.Vault.Server,
+		mount:  strings.Trim(cfg.Vault.MountPath, "/"),
+	}
+	if cfg.Vault.Namespace != nil {
+		c.namespace = *cfg.Vault.Namespace
+	}
+
+	if ca := cfg.Vault.CABundle; ca != nil {
+		pem, err := resource.CommonCredentialExtractor(ctx, ca.Source, kube, ca.CommonCredentialSelectors)
+		if err != nil {
+			return nil, errors.Wrap(err, errExtractCABundle)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New(errAppendCABundle)
+		}
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		c.http.Transport = t
+	}
+
+	if err := configureAuth(ctx, kube, c, cfg.Vault.Auth); err != nil {
+		return nil, err
+	}
+
+	return &SecretStore{client: c, defaultScope: cfg.DefaultScope}, nil
+}
+
+func configureAuth(ctx context.Context, kube client.Client, c *kvClient, cfg v1.VaultAuthConfig) error {
+	switch cfg.Method {
+	case v1.VaultAuthToken:
+		if cfg.Token == nil {
+			return errors.Errorf(errFmtNoAuthConfig, cfg.Method)
+		}
+		t, err := resource.CommonCredentialExtractor(ctx, cfg.Token.Source, kube, cfg.Token.CommonCredentialSelectors)
+		if err != nil {
+			return errors.Wrap(err, errExtractToken)
+		}
+		c.token = strings.TrimSpace(string(t))
+	case v1.VaultAuthAppRole:
+		ar := cfg.AppRole
+		if ar == nil {
+			return errors.Errorf(errFmtNoAuthConfig, cfg.Method)
+		}
+		id, err := resource.CommonCredentialExtractor(ctx, ar.Source, kube, ar.CommonCredentialSelectors)
+		if err != nil {
+			return errors.Wrap(err, errExtractSecretID)
+		}
+		c.login = appRoleLogin(valueOr(ar.MountPath, DefaultAppRoleMountPath), ar.RoleID, strings.TrimSpace(string(id)))
+	case v1.VaultAuthKubernetes:
+		k := cfg.Kubernetes
+		if k == nil {
+			return errors.Errorf(errFmtNoAuthConfig, cfg.Method)
+		}
+		c.login = kubernetesLogin(valueOr(k.MountPath, DefaultKubernetesMountPath), k.Role, valueOr(k.TokenPath, DefaultKubernetesTokenPath))
+	default:
+		return errors.Errorf(errFmtUnknownAuthMethod, cfg.Method)
+	}
+	return nil
+}
+
+// ReadKeyValues reads and returns key value pairs for a given Vault Secret.
+func (ss *SecretStore) ReadKeyValues(ctx context.Context, n store.ScopedName, s *store.Secret) error {
+	kv, err := ss.client.read(ctx, ss.pathForSecret(n))
+	if err != nil {
+		return errors.Wrap(err, errRead)
+	}
+	if kv == nil {
+		return nil
+	}
+	cs := toSecret(n, kv)
+	s.Data = cs.Data
+	s.Metadata = cs.Metadata
+	return nil
+}
+
+// WriteKeyValues writes key value pairs to a given Vault Secret. Like the
+// Kubernetes secret store it replaces all of the secret's data; keys that
+// exist but aren't supplied are removed. The secret's custom metadata is
+// replaced with the supplied metadata labels.
+func (ss *SecretStore) WriteKeyValues(ctx context.Context, s *store.Secret, wo ...store.WriteOption) (bool, error) {
+	p := ss.pathForSecret(s.ScopedName)
+	cs, kv, err := ss.current(ctx, s.ScopedName)
+	if err != nil {
+		return false, err
+	}
+
+	ds := &store.Secret{ScopedName: s.ScopedName, Metadata: &v1.ConnectionSecretMetadata{}, Data: s.Data}
+	if s.Metadata != nil {
+		ds.Metadata = s.Metadata.DeepCopy()
+	}
+
+	if cs != nil {
+		for _, o := range wo {
+			if err := o(ctx, cs, ds); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	data := make(map[string]string, len(ds.Data))
+	for k, v := range ds.Data {
+		data[k] = string(v)
+	}
+
+	dataChanged := kv == nil || !equal(kv.Data, data)
+	metadataChanged := kv == nil || !equal(kv.CustomMetadata, ds.Metadata.Labels)
+	if !dataChanged && !metadataChanged {
+		return false, nil
+	}
+
+	// We write data first, because writing data creates a secret's metadata
+	// but writing metadata doesn't create its data. If we can't write the
+	// data we don't leave metadata behind for a secret that doesn't exist.
+	if dataChanged {
+		if err := ss.client.write(ctx, p, data); err != nil {
+			return false, errors.Wrap(err, errWrite)
+		}
+	}
+	if metadataChanged {
+		if err := ss.client.writeMetadata(ctx, p, ds.Metadata.Labels); err != nil {
+			return false, errors.Wrap(err, errWriteMetadata)
+		}
+	}
+	return true, nil
+}
+
+// DeleteKeyValues delete key value pairs from a given Vault Secret. If no keys
+// are supplied the whole secret, including all of its versions and metadata,
+// is deleted. If keys are supplied only those keys are deleted, and the
+// secret is deleted only if no keys are left.
+func (ss *SecretStore) DeleteKeyValues(ctx context.Context, s *store.Secret, do ...store.DeleteOption) error {
+	p := ss.pathForSecret(s.ScopedName)
+	cs, kv, err := ss.current(ctx, s.ScopedName)
+	if err != nil {
+		return err
+	}
+	if cs == nil {
+		// Secret already deleted, nothing to do.
+		return nil
+	}
+
+	for _, o := range do {
+		if err := o(ctx, cs); err != nil {
+			return err
+		}
+	}
+
+	for k := range s.Data {
+		delete(kv.Data, k)
+	}
+	if len(s.Data) == 0 || len(kv.Data) == 0 {
+		return errors.Wrap(ss.client.destroy(ctx, p), errDelete)
+	}
+	return errors.Wrap(ss.client.write(ctx, p, kv.Data), errWrite)
+}
+
+// current returns the secret with the supplied name, and its raw KV form. It
+// returns a nil secret if the secret does not exist. The secret's metadata is
+// read from its metadata endpoint, so that the owner of a secret whose latest
+// version was deleted is still known. The data of such a secret is empty.
+func (ss *SecretStore) current(ctx context.Context, n store.ScopedName) (*store.Secret, *kvSecret, error) {
+	p := ss.pathForSecret(n)
+	md, exists, err := ss.client.readMetadata(ctx, p)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errReadMetadata)
+	}
+	if !exists {
+		return nil, nil, nil
+	}
+	kv, err := ss.client.read(ctx, p)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errRead)
+	}
+	if kv == nil {
+		kv = &kvSecret{}
+	}
+	kv.CustomMetadata = md
+	return toSecret(n, kv), kv, nil
+}
+
+func (ss *SecretStore) pathForSecret(n store.ScopedName) string {
+	if n.Scope == "" {
+		n.Scope = ss.defaultScope
+	}
+	return path.Join(n.Scope, n.Name)
+}
+
+func toSecret(n store.ScopedName, kv *kvSecret) *store.Secret {
+	s := &store.Secret{ScopedName: n, Data: make(store.KeyValues, len(kv.Data))}
+	for k, v := range kv.Data {
+		s.Data[k] = []byte(v)
+	}
+	if len(kv.CustomMetadata) > 0 {
+		s.Metadata = &v1.ConnectionSecretMetadata{Labels: kv.CustomMetadata}
+	}
+	return s
+}
+
+func equal(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func valueOr(v, fallback string) string {
+	if v == "" {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,648 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/connection/store"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+var (
+	errBoom = errors.New("boom")
+
+	fakeSecretName  = "fake"
+	fakeSecretScope = "fake-namespace"
+	fakeOwnerID     = "00000000-0000-0000-0000-000000000000"
+	fakeRoleID      = "cool-role-id"
+	fakeSecretID    = "cool-secret-id"
+	fakeRole        = "cool-role"
+	fakeJWT         = "cool-jwt"
+)
+
+// fakeVault is a stand-in for a Vault server with a KV v2 secrets engine
+// mounted at "secret", and the AppRole and Kubernetes auth methods enabled.
+// A secret with nil data is one whose latest version was deleted.
+type fakeVault struct {
+	mx      sync.Mutex
+	token   string
+	denied  int
+	secrets map[string]*kvSecret
+	logins  int
+}
+
+func newFakeVault(token string) *fakeVault {
+	return &fakeVault{token: token, denied: http.StatusForbidden, secrets: map[string]*kvSecret{}}
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mx.Lock()
+	defer v.mx.Unlock()
+
+	body := map[string]any{}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	switch r.URL.Path {
+	case "/v1/auth/approle/login":
+		if body["role_id"] != fakeRoleID || body["secret_id"] != fakeSecretID {
+			respond(w, http.StatusBadRequest, map[string]any{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		v.logins++
+		respond(w, http.StatusOK, map[string]any{"auth": map[string]any{"client_token": v.token}})
+		return
+	case "/v1/auth/kubernetes/login":
+		if body["role"] != fakeRole || body["jwt"] != fakeJWT {
+			respond(w, http.StatusBadRequest, map[string]any{"errors": []string{"invalid role or jwt"}})
+			return
+		}
+		v.logins++
+		respond(w, http.StatusOK, map[string]any{"auth": map[string]any{"client_token": v.token}})
+		return
+	}
+
+	if r.Header.Get(headerToken) != v.token {
+		respond(w, v.denied, map[string]any{"errors": []string{"permission denied"}})
+		return
+	}
+
+	if p, ok := strings.CutPrefix(r.URL.Path, "/v1/secret/data/"); ok {
+		v.serveData(w, r, p, body)
+		return
+	}
+	if p, ok := strings.CutPrefix(r.URL.Path, "/v1/secret/metadata/"); ok {
+		v.serveMetadata(w, r, p, body)
+		return
+	}
+	respond(w, http.StatusNotFound, map[string]any{"errors": []string{}})
+}
+
+func (v *fakeVault) serveData(w http.ResponseWriter, r *http.Request, p string, body map[string]any) {
+	switch r.Method {
+	case http.MethodGet:
+		s, ok := v.secrets[p]
+		if !ok || s.Data == nil {
+			respond(w, http.StatusNotFound, map[string]any{"errors": []string{}})
+			return
+		}
+		respond(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"data":     s.Data,
+			"metadata": map[string]any{"custom_metadata": s.CustomMetadata},
+		}})
+	case http.MethodPost:
+		s, ok := v.secrets[p]
+		if !ok {
+			s = &kvSecret{}
+			v.secrets[p] = s
+		}
+		s.Data = stringMap(body["data"])
+		respond(w, http.StatusOK, map[string]any{"data": map[string]any{"version": 1}})
+	}
+}
+
+func (v *fakeVault) serveMetadata(w http.ResponseWriter, r *http.Request, p string, body map[string]any) {
+	switch r.Method {
+	case http.MethodGet:
+		s, ok := v.secrets[p]
+		if !ok {
+			respond(w, http.StatusNotFound, map[string]any{"errors": []string{}})
+			return
+		}
+		respond(w, http.StatusOK, map[string]any{"data": map[string]any{"custom_metadata": s.CustomMetadata}})
+	case http.MethodPost:
+		s, ok := v.secrets[p]
+		if !ok {
+			s = &kvSecret{}
+			v.secrets[p] = s
+		}
+		s.CustomMetadata = stringMap(body["custom_metadata"])
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		delete(v.secrets, p)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func respond(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func stringMap(in any) map[string]string {
+	m, _ := in.(map[string]any)
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k], _ = v.(string)
+	}
+	return out
+}
+
+func fakeKV() store.KeyValues {
+	return store.KeyValues{
+		"key1": []byte("value1"),
+		"key2": []byte("value2"),
+	}
+}
+
+func fakeMetadata() *v1.ConnectionSecretMetadata {
+	md := &v1.ConnectionSecretMetadata{Labels: map[string]string{"environment": "unit-test"}}
+	md.SetOwnerUID(types.UID(fakeOwnerID))
+	return md
+}
+
+func fakeSecret(data store.KeyValues) *store.Secret {
+	return &store.Secret{
+		ScopedName: store.ScopedName{Name: fakeSecretName, Scope: fakeSecretScope},
+		Metadata:   fakeMetadata(),
+		Data:       data,
+	}
+}
+
+func fakeConfig(server string, auth v1.VaultAuthConfig) v1.SecretStoreConfig {
+	return v1.SecretStoreConfig{
+		DefaultScope: "crossplane-system",
+		Vault:        &v1.VaultSecretStoreConfig{Server: server, MountPath: "secret/", Auth: auth},
+	}
+}
+
+func tokenAuth(t *testing.T, token string) v1.VaultAuthConfig {
+	t.Helper()
+	t.Setenv("VAULT_TOKEN", token)
+	return v1.VaultAuthConfig{
+		Method: v1.VaultAuthToken,
+		Token: &v1.VaultAuthTokenConfig{
+			Source:                    v1.CredentialsSourceEnvironment,
+			CommonCredentialSelectors: v1.CommonCredentialSelectors{Env: &v1.EnvSelector{Name: "VAULT_TOKEN"}},
+		},
+	}
+}
+
+func TestNewSecretStore(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		cfg    v1.SecretStoreConfig
+		want   error
+	}{
+		"NoConfig": {
+			reason: "Should return an error if no Vault config is supplied.",
+			cfg:    v1.SecretStoreConfig{},
+			want:   errors.New(errNoConfig),
+		},
+		"NoAuthConfig": {
+			reason: "Should return an error if the selected auth method is not configured.",
+			cfg:    fakeConfig("http://vault", v1.VaultAuthConfig{Method: v1.VaultAuthAppRole}),
+			want:   errors.Errorf(errFmtNoAuthConfig, v1.VaultAuthAppRole),
+		},
+		"UnknownAuthMethod": {
+			reason: "Should return an error if the auth method is unknown.",
+			cfg:    fakeConfig("http://vault", v1.VaultAuthConfig{Method: "Cool"}),
+			want:   errors.Errorf(errFmtUnknownAuthMethod, "Cool"),
+		},
+		"InvalidCABundle": {
+			reason: "Should return an error if the CA bundle contains no certificates.",
+			cfg: func() v1.SecretStoreConfig {
+				cfg := fakeConfig("https://vault", tokenAuth(t, "cool-token"))
+				t.Setenv("VAULT_CA", "not a certificate")
+				cfg.Vault.CABundle = &v1.VaultCABundleConfig{
+					Source:                    v1.CredentialsSourceEnvironment,
+					CommonCredentialSelectors: v1.CommonCredentialSelectors{Env: &v1.EnvSelector{Name: "VAULT_CA"}},
+				}
+				return cfg
+			}(),
+			want: errors.New(errAppendCABundle),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewSecretStore(context.Background(), nil, nil, tc.cfg)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nNewSecretStore(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSecretStoreAuth(t *testing.T) {
+	jwt := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(jwt, []byte(fakeJWT+"\n"), 0o600); err != nil {
+		t.Fatalf("os.WriteFile(...): %s", err)
+	}
+	t.Setenv("VAULT_SECRET_ID", fakeSecretID)
+
+	cases := map[string]struct {
+		reason string
+		auth   v1.VaultAuthConfig
+		denied int
+		logins int
+	}{
+		"Token": {
+			reason: "Should authenticate using the supplied token.",
+			auth:   tokenAuth(t, "cool-token"),
+		},
+		"AppRole": {
+			reason: "Should log in using the AppRole auth method, and log in again when its token is rejected.",
+			auth: v1.VaultAuthConfig{
+				Method: v1.VaultAuthAppRole,
+				AppRole: &v1.VaultAuthAppRoleConfig{
+					RoleID:                    fakeRoleID,
+					Source:                    v1.CredentialsSourceEnvironment,
+					CommonCredentialSelectors: v1.CommonCredentialSelectors{Env: &v1.EnvSelector{Name: "VAULT_SECRET_ID"}},
+				},
+			},
+			logins: 2,
+		},
+		"Kubernetes": {
+			reason: "Should log in using the Kubernetes auth method, and log in again when its token is rejected.",
+			auth: v1.VaultAuthConfig{
+				Method:     v1.VaultAuthKubernetes,
+				Kubernetes: &v1.VaultAuthKubernetesConfig{Role: fakeRole, TokenPath: jwt},
+			},
+			logins: 2,
+		},
+		"Unauthorized": {
+			reason: "Should log in again when its token is rejected as unauthorized.",
+			auth: v1.VaultAuthConfig{
+				Method:     v1.VaultAuthKubernetes,
+				Kubernetes: &v1.VaultAuthKubernetesConfig{Role: fakeRole, TokenPath: jwt},
+			},
+			denied: http.StatusUnauthorized,
+			logins: 2,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v := newFakeVault("cool-token")
+			if tc.denied != 0 {
+				v.denied = tc.denied
+			}
+			srv := httptest.NewServer(v)
+			defer srv.Close()
+
+			ss, err := NewSecretStore(context.Background(), nil, nil, fakeConfig(srv.URL, tc.auth))
+			if err != nil {
+				t.Fatalf("NewSecretStore(...): %s", err)
+			}
+			if _, err := ss.WriteKeyValues(context.Background(), fakeSecret(fakeKV())); err != nil {
+				t.Errorf("\n%s\nss.WriteKeyValues(...): %s", tc.reason, err)
+			}
+
+			// Expire the token. Only stores that can log in will recover.
+			if tc.logins > 0 {
+				v.mx.Lock()
+				v.token = "new-token"
+				v.mx.Unlock()
+			}
+
+			got := &store.Secret{}
+			if err := ss.ReadKeyValues(context.Background(), fakeSecret(nil).ScopedName, got); err != nil {
+				t.Errorf("\n%s\nss.ReadKeyValues(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(fakeKV(), got.Data); diff != "" {
+				t.Errorf("\n%s\nss.ReadKeyValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.logins, v.logins); diff != "" {
+				t.Errorf("\n%s\nlogins: -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSecretStoreReadKeyValues(t *testing.T) {
+	type want struct {
+		result *store.Secret
+		err    error
+	}
+
+	cases := map[string]struct {
+		reason   string
+		existing *store.Secret
+		token    string
+		want     want
+	}{
+		"SecretNotFound": {
+			reason: "Should return nil as an error if secret is not found.",
+			token:  "cool-token",
+			want:   want{result: &store.Secret{}},
+		},
+		"ReadFails": {
+			reason: "Should return a proper error if the secret cannot be read.",
+			token:  "wrong-token",
+			want: want{
+				result: &store.Secret{},
+				err:    errors.Wrap(errors.Errorf(errFmtRequestFailed, http.MethodGet, "secret/data/fake-namespace/fake", http.StatusForbidden, "permission denied"), errRead),
+			},
+		},
+		"Success": {
+			reason:   "Should return all key values, and custom metadata as labels.",
+			existing: fakeSecret(fakeKV()),
+			token:    "cool-token",
+			want: want{
+				result: &store.Secret{Metadata: fakeMetadata(), Data: fakeKV()},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v := newFakeVault("cool-token")
+			if tc.existing != nil {
+				v.secrets["fake-namespace/fake"] = &kvSecret{Data: map[string]string{"key1": "value1", "key2": "value2"}, CustomMetadata: tc.existing.Metadata.Labels}
+			}
+			srv := httptest.NewServer(v)
+			defer srv.Close()
+
+			ss, err := NewSecretStore(context.Background(), nil, nil, fakeConfig(srv.URL, tokenAuth(t, tc.token)))
+			if err != nil {
+				t.Fatalf("NewSecretStore(...): %s", err)
+			}
+			s := &store.Secret{}
+			err = ss.ReadKeyValues(context.Background(), fakeSecret(nil).ScopedName, s)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nss.ReadKeyValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.result, s); diff != "" {
+				t.Errorf("\n%s\nss.ReadKeyValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSecretStoreWriteKeyValues(t *testing.T) {
+	type args struct {
+		secret *store.Secret
+		wo     []store.WriteOption
+	}
+	type want struct {
+		changed bool
+		secret  *kvSecret
+		err     error
+	}
+
+	existing := func() *kvSecret {
+		return &kvSecret{Data: map[string]string{"key1": "value1", "key2": "value2"}, CustomMetadata: fakeMetadata().Labels}
+	}
+
+	cases := map[string]struct {
+		reason   string
+		existing *kvSecret
+		args     args
+		want     want
+	}{
+		"SecretCreated": {
+			reason: "Should create a secret with all key values and labels as custom metadata.",
+			args:   args{secret: fakeSecret(fakeKV())},
+			want:   want{changed: true, secret: existing()},
+		},
+		"WriteOptionFails": {
+			reason:   "Should return a proper error if supplied write option fails.",
+			existing: existing(),
+			args: args{
+				secret: fakeSecret(store.KeyValues{"key1": []byte("new")}),
+				wo: []store.WriteOption{func(_ context.Context, _, _ *store.Secret) error {
+					return errBoom
+				}},
+			},
+			want: want{err: errBoom, secret: existing()},
+		},
+		"OwnerChecked": {
+			reason:   "Write options should be passed the current secret, including its owner.",
+			existing: existing(),
+			args: args{
+				secret: fakeSecret(store.KeyValues{"key1": []byte("new")}),
+				wo: []store.WriteOption{func(_ context.Context, current, _ *store.Secret) error {
+					if current.GetOwner() != fakeOwnerID {
+						return errBoom
+					}
+					return nil
+				}},
+			},
+			want: want{changed: true, secret: &kvSecret{Data: map[string]string{"key1": "new"}, CustomMetadata: fakeMetadata().Labels}},
+		},
+		"DeletedVersionOwnerChecked": {
+			reason:   "Write options should be passed the owner of a secret whose latest version was deleted.",
+			existing: &kvSecret{CustomMetadata: map[string]string{v1.LabelKeyOwnerUID: "someone-else"}},
+			args: args{
+				secret: fakeSecret(fakeKV()),
+				wo: []store.WriteOption{func(_ context.Context, current, _ *store.Secret) error {
+					if current.GetOwner() != fakeOwnerID {
+						return errBoom
+					}
+					return nil
+				}},
+			},
+			want: want{err: errBoom, secret: &kvSecret{CustomMetadata: map[string]string{v1.LabelKeyOwnerUID: "someone-else"}}},
+		},
+		"DeletedVersionRewritten": {
+			reason:   "Should write a new version of a secret whose latest version was deleted.",
+			existing: &kvSecret{CustomMetadata: fakeMetadata().Labels},
+			args:     args{secret: fakeSecret(fakeKV())},
+			want:     want{changed: true, secret: existing()},
+		},
+		"AlreadyUpToDate": {
+			reason:   "Should not change secret if already up to date.",
+			existing: existing(),
+			args:     args{secret: fakeSecret(fakeKV())},
+			want:     want{changed: false, secret: existing()},
+		},
+		"LabelsUpdated": {
+			reason:   "Should update custom metadata if labels changed.",
+			existing: existing(),
+			args: args{secret: func() *store.Secret {
+				s := fakeSecret(fakeKV())
+				s.Metadata.Labels["environment"] = "production"
+				return s
+			}()},
+			want: want{changed: true, secret: &kvSecret{
+				Data:           map[string]string{"key1": "value1", "key2": "value2"},
+				CustomMetadata: map[string]string{"environment": "production", v1.LabelKeyOwnerUID: fakeOwnerID},
+			}},
+		},
+		"NewKeyAdded": {
+			reason:   "Should replace the existing secret's data if a new key added.",
+			existing: existing(),
+			args:     args{secret: fakeSecret(store.KeyValues{"key3": []byte("value3")})},
+			want: want{changed: true, secret: &kvSecret{
+				Data:           map[string]string{"key3": "value3"},
+				CustomMetadata: fakeMetadata().Labels,
+			}},
+		},
+		"KeyRemoved": {
+			reason:   "Should remove keys that exist but aren't supplied.",
+			existing: existing(),
+			args:     args{secret: fakeSecret(store.KeyValues{"key1": []byte("value1")})},
+			want: want{changed: true, secret: &kvSecret{
+				Data:           map[string]string{"key1": "value1"},
+				CustomMetadata: fakeMetadata().Labels,
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v := newFakeVault("cool-token")
+			if tc.existing != nil {
+				v.secrets["fake-namespace/fake"] = tc.existing
+			}
+			srv := httptest.NewServer(v)
+			defer srv.Close()
+
+			ss, err := NewSecretStore(context.Background(), nil, nil, fakeConfig(srv.URL, tokenAuth(t, "cool-token")))
+			if err != nil {
+				t.Fatalf("NewSecretStore(...): %s", err)
+			}
+			changed, err := ss.WriteKeyValues(context.Background(), tc.args.secret, tc.args.wo...)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nss.WriteKeyValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.changed, changed); diff != "" {
+				t.Errorf("\n%s\nss.WriteKeyValues(...): -want changed, +got changed:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.secret, v.secrets["fake-namespace/fake"]); diff != "" {
+				t.Errorf("\n%s\nss.WriteKeyValues(...): -want secret, +got secret:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSecretStoreDeleteKeyValues(t *testing.T) {
+	type args struct {
+		secret *store.Secret
+		do     []store.DeleteOption
+	}
+	type want struct {
+		secret *kvSecret
+		err    error
+	}
+
+	existing := func() *kvSecret {
+		return &kvSecret{Data: map[string]string{"key1": "value1", "key2": "value2"}, CustomMetadata: fakeMetadata().Labels}
+	}
+
+	cases := map[string]struct {
+		reason   string
+		existing *kvSecret
+		args     args
+		want     want
+	}{
+		"AlreadyDeleted": {
+			reason: "Should not return error if secret already deleted.",
+			args:   args{secret: fakeSecret(nil)},
+		},
+		"DeleteOptionFails": {
+			reason:   "Should return a proper error if provided delete option fails.",
+			existing: existing(),
+			args: args{
+				secret: fakeSecret(nil),
+				do: []store.DeleteOption{func(_ context.Context, _ *store.Secret) error {
+					return errBoom
+				}},
+			},
+			want: want{err: errBoom, secret: existing()},
+		},
+		"OwnerChecked": {
+			reason:   "Delete options should be passed the current secret, including its owner.",
+			existing: existing(),
+			args: args{
+				secret: &store.Secret{ScopedName: store.ScopedName{Name: fakeSecretName, Scope: fakeSecretScope}},
+				do: []store.DeleteOption{func(_ context.Context, current *store.Secret) error {
+					if current.GetOwner() != fakeOwnerID {
+						return errBoom
+					}
+					return nil
+				}},
+			},
+		},
+		"DeletedVersionOwnerChecked": {
+			reason:   "Delete options should be passed the owner of a secret whose latest version was deleted.",
+			existing: &kvSecret{CustomMetadata: map[string]string{v1.LabelKeyOwnerUID: "someone-else"}},
+			args: args{
+				secret: fakeSecret(nil),
+				do: []store.DeleteOption{func(_ context.Context, current *store.Secret) error {
+					if current.GetOwner() != fakeOwnerID {
+						return errBoom
+					}
+					return nil
+				}},
+			},
+			want: want{err: errBoom, secret: &kvSecret{CustomMetadata: map[string]string{v1.LabelKeyOwnerUID: "someone-else"}}},
+		},
+		"DeletedVersionDestroyed": {
+			reason:   "Should destroy a secret whose latest version was deleted.",
+			existing: &kvSecret{CustomMetadata: fakeMetadata().Labels},
+			args:     args{secret: fakeSecret(nil)},
+		},
+		"KeysDeleted": {
+			reason:   "Should remove supplied keys from secret and keep the remaining.",
+			existing: existing(),
+			args:     args{secret: fakeSecret(store.KeyValues{"key1": nil})},
+			want:     want{secret: &kvSecret{Data: map[string]string{"key2": "value2"}, CustomMetadata: fakeMetadata().Labels}},
+		},
+		"AllKeysDeleted": {
+			reason:   "Should delete the whole secret if no keys are left.",
+			existing: existing(),
+			args:     args{secret: fakeSecret(store.KeyValues{"key1": nil, "key2": nil})},
+		},
+		"SecretDeleted": {
+			reason:   "Should delete the whole secret if no kv supplied as parameter.",
+			existing: existing(),
+			args:     args{secret: fakeSecret(nil)},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v := newFakeVault("cool-token")
+			if tc.existing != nil {
+				v.secrets["fake-namespace/fake"] = tc.existing
+			}
+			srv := httptest.NewServer(v)
+			defer srv.Close()
+
+			ss, err := NewSecretStore(context.Background(), nil, nil, fakeConfig(srv.URL, tokenAuth(t, "cool-token")))
+			if err != nil {
+				t.Fatalf("NewSecretStore(...): %s", err)
+			}
+			err = ss.DeleteKeyValues(context.Background(), tc.args.secret, tc.args.do...)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nss.DeleteKeyValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.secret, v.secrets["fake-namespace/fake"], cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nss.DeleteKeyValues(...): -want secret, +got secret:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
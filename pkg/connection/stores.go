@@ -23,8 +23,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/connection/store/filesystem"
 	"github.com/crossplane/crossplane-runtime/pkg/connection/store/kubernetes"
 	"github.com/crossplane/crossplane-runtime/pkg/connection/store/plugin"
+	"github.com/crossplane/crossplane-runtime/pkg/connection/store/vault"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 )
 
@@ -42,6 +44,10 @@ func RuntimeStoreBuilder(ctx context.Context, local client.Client, tcfg *tls.Con
 		return kubernetes.NewSecretStore(ctx, local, nil, cfg)
 	case v1.SecretStorePlugin:
 		return plugin.NewSecretStore(ctx, local, tcfg, cfg)
+	case v1.SecretStoreFilesystem:
+		return filesystem.NewSecretStore(ctx, local, nil, cfg)
+	case v1.SecretStoreVault:
+		return vault.NewSecretStore(ctx, local, nil, cfg)
 	}
 	return nil, errors.Errorf(errFmtUnknownSecretStore, *cfg.Type)
 }
@@ -21,6 +21,7 @@ import (
 	"strings"
 	"sync"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -31,23 +32,61 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 )
 
+// Error strings.
+const (
+	errFmtNoUncachedReader = "%s is only cached as metadata, and there is no reader for full objects"
+	errFmtMetadataInformer = "%s is only cached as metadata: cannot get an informer or index for full objects"
+)
+
 // GVKRoutedCache is a cache that routes requests by GVK to other caches.
 type GVKRoutedCache struct {
 	scheme *runtime.Scheme
 
 	fallback cache.Cache
+	uncached client.Reader
 
 	lock      sync.RWMutex
 	delegates map[schema.GroupVersionKind]cache.Cache
+	metadata  map[schema.GroupVersionKind]bool
+
+	name    string
+	metrics *CacheMetrics
+}
+
+// A GVKRoutedCacheOption configures a GVKRoutedCache.
+type GVKRoutedCacheOption func(*GVKRoutedCache)
+
+// WithRoutedCacheMetrics configures the GVKRoutedCache to record metrics,
+// identifying itself using the supplied name. No metrics are recorded by
+// default.
+func WithRoutedCacheMetrics(m *CacheMetrics, name string) GVKRoutedCacheOption {
+	return func(c *GVKRoutedCache) {
+		c.metrics = m
+		c.name = name
+	}
+}
+
+// WithUncachedReader configures the reader a GVKRoutedCache uses to read full
+// objects of kinds that are only cached as metadata, typically the manager's
+// API reader. Such reads return an error by default.
+func WithUncachedReader(r client.Reader) GVKRoutedCacheOption {
+	return func(c *GVKRoutedCache) {
+		c.uncached = r
+	}
 }
 
 // NewGVKRoutedCache returns a new routed cache.
-func NewGVKRoutedCache(scheme *runtime.Scheme, fallback cache.Cache) *GVKRoutedCache {
-	return &GVKRoutedCache{
+func NewGVKRoutedCache(scheme *runtime.Scheme, fallback cache.Cache, o ...GVKRoutedCacheOption) *GVKRoutedCache {
+	c := &GVKRoutedCache{
 		scheme:    scheme,
 		fallback:  fallback,
 		delegates: make(map[schema.GroupVersionKind]cache.Cache),
+		metadata:  make(map[schema.GroupVersionKind]bool),
+	}
+	for _, fn := range o {
+		fn(c)
 	}
+	return c
 }
 
 var _ cache.Cache = &GVKRoutedCache{}
@@ -58,6 +97,23 @@ func (c *GVKRoutedCache) AddDelegate(gvk schema.GroupVersionKind, delegate cache
 	defer c.lock.Unlock()
 
 	c.delegates[gvk] = delegate
+	delete(c.metadata, gvk)
+	c.metrics.setDelegates(c.name, len(c.delegates))
+}
+
+// AddMetadataDelegate adds a delegated cache for a given GVK that caches only
+// object metadata. Only reads of metav1.PartialObjectMetadata objects and
+// lists are routed to the delegate. Reads of full objects are never routed to
+// the fallback, which would start an informer for full objects. They're served
+// by the uncached reader configured using WithUncachedReader, or return an
+// error if there is none. Informers and indexes of full objects can't be got.
+func (c *GVKRoutedCache) AddMetadataDelegate(gvk schema.GroupVersionKind, delegate cache.Cache) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.delegates[gvk] = delegate
+	c.metadata[gvk] = true
+	c.metrics.setDelegates(c.name, len(c.delegates))
 }
 
 // RemoveDelegate removes a delegated cache for a given GVK.
@@ -66,6 +122,41 @@ func (c *GVKRoutedCache) RemoveDelegate(gvk schema.GroupVersionKind) {
 	defer c.lock.Unlock()
 
 	delete(c.delegates, gvk)
+	delete(c.metadata, gvk)
+	c.metrics.setDelegates(c.name, len(c.delegates))
+}
+
+// delegate returns the delegated cache that should serve a read of the
+// supplied object or list of the supplied GVK, if any.
+func (c *GVKRoutedCache) delegate(gvk schema.GroupVersionKind, obj runtime.Object) (cache.Cache, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	delegate, ok := c.delegates[gvk]
+	if !ok || !c.metadata[gvk] {
+		return delegate, ok
+	}
+	switch obj.(type) {
+	case *metav1.PartialObjectMetadata, *metav1.PartialObjectMetadataList:
+		return delegate, true
+	}
+	return nil, false
+}
+
+// isMetadataOnly returns true if the supplied GVK is only cached as metadata.
+func (c *GVKRoutedCache) isMetadataOnly(gvk schema.GroupVersionKind) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.metadata[gvk]
+}
+
+// uncachedReader returns the reader that should serve reads of full objects
+// of the supplied GVK, which is only cached as metadata.
+func uncachedReader(r client.Reader, gvk schema.GroupVersionKind) (client.Reader, error) {
+	if r == nil {
+		return nil, errors.Errorf(errFmtNoUncachedReader, gvk)
+	}
+	return r, nil
 }
 
 // Get retrieves an object for a given ObjectKey backed by a cache.
@@ -75,13 +166,18 @@ func (c *GVKRoutedCache) Get(ctx context.Context, key client.ObjectKey, obj clie
 		return errors.Errorf("failed to get GVK for type %T: %w", obj, err)
 	}
 
-	c.lock.RLock()
-	delegate, ok := c.delegates[gvk]
-	c.lock.RUnlock()
-
+	delegate, ok := c.delegate(gvk, obj)
+	c.metrics.routed(c.name, gvk, verbGet, ok)
 	if ok {
 		return delegate.Get(ctx, key, obj, opts...)
 	}
+	if c.isMetadataOnly(gvk) {
+		r, err := uncachedReader(c.uncached, gvk)
+		if err != nil {
+			return err
+		}
+		return r.Get(ctx, key, obj, opts...)
+	}
 
 	return c.fallback.Get(ctx, key, obj, opts...)
 }
@@ -100,13 +196,18 @@ func (c *GVKRoutedCache) List(ctx context.Context, list client.ObjectList, opts
 	}
 	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
 
-	c.lock.RLock()
-	delegate, ok := c.delegates[gvk]
-	c.lock.RUnlock()
-
+	delegate, ok := c.delegate(gvk, list)
+	c.metrics.routed(c.name, gvk, verbList, ok)
 	if ok {
 		return delegate.List(ctx, list, opts...)
 	}
+	if c.isMetadataOnly(gvk) {
+		r, err := uncachedReader(c.uncached, gvk)
+		if err != nil {
+			return err
+		}
+		return r.List(ctx, list, opts...)
+	}
 
 	return c.fallback.List(ctx, list, opts...)
 }
@@ -118,26 +219,24 @@ func (c *GVKRoutedCache) GetInformer(ctx context.Context, obj client.Object, opt
 		return nil, errors.Errorf("failed to get GVK for type %T: %w", obj, err)
 	}
 
-	c.lock.RLock()
-	delegate, ok := c.delegates[gvk]
-	c.lock.RUnlock()
-
-	if ok {
+	if delegate, ok := c.delegate(gvk, obj); ok {
 		return delegate.GetInformer(ctx, obj, opts...)
 	}
+	if c.isMetadataOnly(gvk) {
+		return nil, errors.Errorf(errFmtMetadataInformer, gvk)
+	}
 
 	return c.fallback.GetInformer(ctx, obj, opts...)
 }
 
 // GetInformerForKind returns an informer for the given GVK.
 func (c *GVKRoutedCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind, opts ...cache.InformerGetOption) (cache.Informer, error) {
-	c.lock.RLock()
-	delegate, ok := c.delegates[gvk]
-	c.lock.RUnlock()
-
-	if ok {
+	if delegate, ok := c.delegate(gvk, nil); ok {
 		return delegate.GetInformerForKind(ctx, gvk, opts...)
 	}
+	if c.isMetadataOnly(gvk) {
+		return nil, errors.Errorf(errFmtMetadataInformer, gvk)
+	}
 
 	return c.fallback.GetInformerForKind(ctx, gvk, opts...)
 }
@@ -197,23 +296,25 @@ func (c *GVKRoutedCache) IndexField(ctx context.Context, obj client.Object, fiel
 		return errors.Errorf("failed to get GVK for type %T: %w", obj, err)
 	}
 
-	c.lock.RLock()
-	delegate, ok := c.delegates[gvk]
-	c.lock.RUnlock()
-
-	if ok {
+	if delegate, ok := c.delegate(gvk, obj); ok {
 		return delegate.IndexField(ctx, obj, field, extractValue)
 	}
+	if c.isMetadataOnly(gvk) {
+		return errors.Errorf(errFmtMetadataInformer, gvk)
+	}
 
 	return c.fallback.IndexField(ctx, obj, field, extractValue)
 }
 
 // cachedRoutedClient wraps a client and routes read requests by GVK to a cache.
+// Reads of full objects of kinds the cache only caches as metadata are served
+// by the uncached reader, if any.
 type cachedRoutedClient struct {
 	client.Client
 
-	scheme *runtime.Scheme
-	cache  *GVKRoutedCache
+	scheme   *runtime.Scheme
+	cache    *GVKRoutedCache
+	uncached client.Reader
 }
 
 func (c *cachedRoutedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
@@ -222,13 +323,18 @@ func (c *cachedRoutedClient) Get(ctx context.Context, key client.ObjectKey, obj
 		return errors.Errorf("failed to get GVK for type %T: %w", obj, err)
 	}
 
-	c.cache.lock.RLock()
-	delegate, ok := c.cache.delegates[gvk]
-	c.cache.lock.RUnlock()
-
+	delegate, ok := c.cache.delegate(gvk, obj)
+	c.cache.metrics.routed(c.cache.name, gvk, verbGet, ok)
 	if ok {
 		return delegate.Get(ctx, key, obj, opts...)
 	}
+	if c.cache.isMetadataOnly(gvk) {
+		r, err := uncachedReader(c.uncached, gvk)
+		if err != nil {
+			return err
+		}
+		return r.Get(ctx, key, obj, opts...)
+	}
 
 	return c.Client.Get(ctx, key, obj, opts...)
 }
@@ -246,26 +352,34 @@ func (c *cachedRoutedClient) List(ctx context.Context, list client.ObjectList, o
 	}
 	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
 
-	c.cache.lock.RLock()
-	delegate, ok := c.cache.delegates[gvk]
-	c.cache.lock.RUnlock()
-
+	delegate, ok := c.cache.delegate(gvk, list)
+	c.cache.metrics.routed(c.cache.name, gvk, verbList, ok)
 	if ok {
 		return delegate.List(ctx, list, opts...)
 	}
+	if c.cache.isMetadataOnly(gvk) {
+		r, err := uncachedReader(c.uncached, gvk)
+		if err != nil {
+			return err
+		}
+		return r.List(ctx, list, opts...)
+	}
 
 	return c.Client.List(ctx, list, opts...)
 }
 
 // WithGVKRoutedCache returns a manager backed by a GVKRoutedCache. The client
-// returned by the manager will route read requests to cached GVKs.
+// returned by the manager will route read requests to cached GVKs. It reads
+// full objects of kinds that are only cached as metadata using the manager's
+// API reader.
 func WithGVKRoutedCache(c *GVKRoutedCache, mgr controllerruntime.Manager) controllerruntime.Manager {
 	return &routedManager{
 		Manager: mgr,
 		client: &cachedRoutedClient{
-			Client: mgr.GetClient(),
-			scheme: mgr.GetScheme(),
-			cache:  c,
+			Client:   mgr.GetClient(),
+			scheme:   mgr.GetScheme(),
+			cache:    c,
+			uncached: mgr.GetAPIReader(),
 		},
 		cache: c,
 	}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// AnnotationKeyLastApplied is the annotation kubectl uses to record the last
+// applied configuration of an object.
+const AnnotationKeyLastApplied = "kubectl.kubernetes.io/last-applied-configuration"
+
+// CacheOptions configure the caches of a controller started by an Engine.
+type CacheOptions struct {
+	// MetadataOnly kinds of object are watched and cached as
+	// metav1.PartialObjectMetadata. Event handlers receive, and reads are
+	// served from the cache for, only metadata. Reads of full objects of
+	// these kinds by the controller's client and cache are served by the
+	// manager's API reader, and are never cached. The controller can't get
+	// informers or add indexes for full objects of these kinds.
+	MetadataOnly []schema.GroupVersionKind
+
+	// StripManagedFields strips managed fields and the last applied
+	// configuration annotation from objects before they're cached.
+	StripManagedFields bool
+
+	// LabelSelector restricts the cache to objects with matching labels.
+	LabelSelector labels.Selector
+
+	// Namespaces restricts the cache to objects in these namespaces. All
+	// namespaces are cached if none are specified.
+	Namespaces []string
+}
+
+// A CacheOptionsFn returns the cache options of the named controller.
+type CacheOptionsFn func(name string) CacheOptions
+
+// WithCacheOptionsFn configures the caches of the controllers the Engine
+// starts. Controllers are started with default cache options by default.
+func WithCacheOptionsFn(fn CacheOptionsFn) EngineOption {
+	return func(e *Engine) {
+		e.cacheOptions = fn
+	}
+}
+
+// isMetadataOnly returns true if the supplied GVK should be cached as metadata.
+func (o CacheOptions) isMetadataOnly(gvk schema.GroupVersionKind) bool {
+	return contains(o.MetadataOnly, gvk)
+}
+
+// forCache returns options for a new cache.
+func (o CacheOptions) forCache(mgr manager.Manager) cache.Options {
+	co := cache.Options{
+		Scheme:               mgr.GetScheme(),
+		Mapper:               mgr.GetRESTMapper(),
+		DefaultLabelSelector: o.LabelSelector,
+	}
+	if len(o.Namespaces) > 0 {
+		co.DefaultNamespaces = make(map[string]cache.Config, len(o.Namespaces))
+		for _, ns := range o.Namespaces {
+			co.DefaultNamespaces[ns] = cache.Config{}
+		}
+	}
+	if o.StripManagedFields {
+		co.DefaultTransform = TransformStripManagedFields()
+	}
+	return co
+}
+
+// kind returns the kind of object to watch for the supplied GVK.
+func (o CacheOptions) kind(gvk schema.GroupVersionKind, kind client.Object) client.Object {
+	if !o.isMetadataOnly(gvk) {
+		return kind
+	}
+	pom := &metav1.PartialObjectMetadata{}
+	pom.SetGroupVersionKind(gvk)
+	return pom
+}
+
+// TransformStripManagedFields returns a cache transform that strips managed
+// fields and the last applied configuration annotation from objects. Neither
+// is typically read by controllers, but both can be large.
+func TransformStripManagedFields() toolscache.TransformFunc {
+	return func(in any) (any, error) {
+		// Deleted objects may be wrapped in a tombstone, which we leave alone.
+		o, err := meta.Accessor(in)
+		if err != nil {
+			return in, nil //nolint:nilerr // Not an object, so nothing to strip.
+		}
+		o.SetManagedFields(nil)
+		if a := o.GetAnnotations(); a != nil {
+			if _, ok := a[AnnotationKeyLastApplied]; ok {
+				delete(a, AnnotationKeyLastApplied)
+				o.SetAnnotations(a)
+			}
+		}
+		return in, nil
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestTransformStripManagedFields(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		in     any
+		want   any
+	}{
+		"NotAnObject": {
+			reason: "Things that aren't objects should be returned unchanged.",
+			in:     "cool",
+			want:   "cool",
+		},
+		"Object": {
+			reason: "Managed fields and the last applied annotation should be stripped.",
+			in: &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+				ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+				Annotations: map[string]string{
+					AnnotationKeyLastApplied: "{}",
+					"cool":                   "very",
+				},
+			}},
+			want: &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{"cool": "very"},
+			}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := TransformStripManagedFields()(tc.in)
+			if err != nil {
+				t.Fatalf("\n%s\nTransformStripManagedFields(): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nTransformStripManagedFields(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestEngineCacheOptions(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+	sel := labels.SelectorFromSet(labels.Set{"cool": "very"})
+
+	var got cache.Options
+	var watched []client.Object
+	ca := &MockCache{MockGetInformer: func(_ context.Context, obj client.Object, _ ...cache.InformerGetOption) (cache.Informer, error) {
+		watched = append(watched, obj)
+		return &MockInformer{}, nil
+	}}
+
+	e := NewEngine(&fake.Manager{Scheme: runtime.NewScheme(), Cache: &MockCache{}},
+		WithCacheOptionsFn(func(string) CacheOptions {
+			return CacheOptions{
+				MetadataOnly:       []schema.GroupVersionKind{gvk},
+				StripManagedFields: true,
+				LabelSelector:      sel,
+				Namespaces:         []string{"default"},
+			}
+		}),
+		WithNewCacheFn(func(_ *rest.Config, o cache.Options) (cache.Cache, error) {
+			got = o
+			return ca, nil
+		}),
+		WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+			return &MockController{MockWatch: func(s source.Source, h handler.EventHandler, p ...predicate.Predicate) error {
+				return s.Start(context.Background(), h, nil, p...)
+			}}, nil
+		}),
+	)
+
+	thing := &unstructured.Unstructured{}
+	thing.SetGroupVersionKind(gvk)
+	if _, err := e.Create("coolcontroller", controller.Options{}, For(thing, nil)); err != nil {
+		t.Fatalf("e.Create(...): %s", err)
+	}
+
+	if diff := cmp.Diff(sel, got.DefaultLabelSelector); diff != "" {
+		t.Errorf("e.Create(...): -want label selector, +got label selector:\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]cache.Config{"default": {}}, got.DefaultNamespaces); diff != "" {
+		t.Errorf("e.Create(...): -want namespaces, +got namespaces:\n%s", diff)
+	}
+	if got.DefaultTransform == nil {
+		t.Errorf("e.Create(...): want a transform that strips managed fields")
+	}
+
+	pom := &metav1.PartialObjectMetadata{}
+	pom.SetGroupVersionKind(gvk)
+	if diff := cmp.Diff([]client.Object{pom}, watched); diff != "" {
+		t.Errorf("e.Create(...): Metadata only kinds should be watched as metadata: -want, +got:\n%s", diff)
+	}
+}
+
+func TestGVKRoutedCacheMetadataDelegate(t *testing.T) {
+	errDelegate := errors.New("delegate")
+	errFallback := errors.New("fallback")
+	errUncached := errors.New("uncached")
+
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+
+	pom := &metav1.PartialObjectMetadata{}
+	pom.SetGroupVersionKind(gvk)
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+
+	cases := map[string]struct {
+		reason   string
+		uncached client.Reader
+		obj      client.Object
+		want     error
+	}{
+		"Metadata": {
+			reason:   "Reads of metadata should be routed to the delegate.",
+			uncached: &MockReader{err: errUncached},
+			obj:      pom,
+			want:     errDelegate,
+		},
+		"FullObject": {
+			reason:   "Reads of full objects should be routed to the uncached reader, not the fallback.",
+			uncached: &MockReader{err: errUncached},
+			obj:      u,
+			want:     errUncached,
+		},
+		"FullObjectNoUncachedReader": {
+			reason: "Reads of full objects should return an error if there is no uncached reader.",
+			obj:    u,
+			want:   errors.Errorf(errFmtNoUncachedReader, gvk),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := NewGVKRoutedCache(runtime.NewScheme(), &MockReader{err: errFallback}, WithUncachedReader(tc.uncached))
+			c.AddMetadataDelegate(gvk, &MockReader{err: errDelegate})
+
+			err := c.Get(context.Background(), client.ObjectKey{Name: "cool"}, tc.obj)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nc.Get(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestEngineMetadataOnlyFullObjectReads(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+
+	// Informers got from the manager's cache, which is the fallback of each
+	// controller's GVKRoutedCache.
+	informers := 0
+	fallback := &MockCache{MockGetInformer: func(context.Context, client.Object, ...cache.InformerGetOption) (cache.Informer, error) {
+		informers++
+		return &MockInformer{}, nil
+	}}
+	ar := &MockReader{}
+
+	var mgr manager.Manager
+	e := NewEngine(&fake.Manager{Scheme: runtime.NewScheme(), Cache: fallback, APIReader: ar},
+		WithCacheOptionsFn(func(string) CacheOptions {
+			return CacheOptions{MetadataOnly: []schema.GroupVersionKind{gvk}}
+		}),
+		WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return &MockCache{MockGetInformer: func(context.Context, client.Object, ...cache.InformerGetOption) (cache.Informer, error) {
+				return &MockInformer{}, nil
+			}}, nil
+		}),
+		WithNewControllerFn(func(_ string, m manager.Manager, _ controller.Options) (controller.Controller, error) {
+			mgr = m
+			return &MockController{MockWatch: func(s source.Source, h handler.EventHandler, p ...predicate.Predicate) error {
+				return s.Start(context.Background(), h, nil, p...)
+			}}, nil
+		}),
+	)
+
+	thing := &unstructured.Unstructured{}
+	thing.SetGroupVersionKind(gvk)
+	if _, err := e.Create("coolcontroller", controller.Options{}, For(thing, nil)); err != nil {
+		t.Fatalf("e.Create(...): %s", err)
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	if err := mgr.GetClient().Get(context.Background(), client.ObjectKey{Name: "cool"}, u); err != nil {
+		t.Errorf("mgr.GetClient().Get(...): %s", err)
+	}
+	if err := mgr.GetCache().Get(context.Background(), client.ObjectKey{Name: "cool"}, u); err != nil {
+		t.Errorf("mgr.GetCache().Get(...): %s", err)
+	}
+	_, err := mgr.GetCache().GetInformer(context.Background(), u)
+	if diff := cmp.Diff(errors.Errorf(errFmtMetadataInformer, gvk), err, test.EquateErrors()); diff != "" {
+		t.Errorf("mgr.GetCache().GetInformer(...): -want error, +got error:\n%s", diff)
+	}
+
+	if diff := cmp.Diff(2, ar.gets); diff != "" {
+		t.Errorf("Full object reads of metadata only kinds should be served by the API reader: -want gets, +got gets:\n%s", diff)
+	}
+	if diff := cmp.Diff(0, informers); diff != "" {
+		t.Errorf("Full object reads of metadata only kinds should not start an informer in the manager's cache: -want informers, +got informers:\n%s", diff)
+	}
+}
+
+type MockReader struct {
+	cache.Cache
+
+	err  error
+	gets int
+}
+
+func (r *MockReader) Get(context.Context, client.ObjectKey, client.Object, ...client.GetOption) error {
+	r.gets++
+	return r.err
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Error strings.
+const (
+	errGetCRDInformer = "cannot get CustomResourceDefinition informer"
+	errAddCRDHandler  = "cannot add CustomResourceDefinition event handler"
+
+	errFmtNotServed = "stopped because %s is no longer served"
+)
+
+// WatchCRDs watches CustomResourceDefinitions using the supplied informers,
+// which are typically the manager's cache. The apiextensions/v1 types must be
+// registered with the informers' scheme. When a CustomResourceDefinition is
+// deleted, or stops serving a version, the Engine stops every controller that
+// watches a kind it no longer serves. The controller's Err explains why it was
+// stopped. Stopped controllers are not restarted.
+func (e *Engine) WatchCRDs(ctx context.Context, i cache.Informers) error {
+	inf, err := i.GetInformer(ctx, &extv1.CustomResourceDefinition{}, cache.BlockUntilSynced(false))
+	if err != nil {
+		return errors.Wrap(err, errGetCRDInformer)
+	}
+	_, err = inf.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj any) {
+			o, ok := oldObj.(*extv1.CustomResourceDefinition)
+			if !ok {
+				return
+			}
+			n, ok := newObj.(*extv1.CustomResourceDefinition)
+			if !ok {
+				return
+			}
+			e.notServed(removed(served(o), served(n)))
+		},
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			crd, ok := obj.(*extv1.CustomResourceDefinition)
+			if !ok {
+				return
+			}
+			e.notServed(served(crd))
+		},
+	})
+	return errors.Wrap(err, errAddCRDHandler)
+}
+
+// notServed stops every controller that watches any of the supplied GVKs,
+// removing them from the controller's GVKRoutedCache, and from the manager's if
+// it has one. Custom sources that watch the GVKs are stopped, but don't stop
+// their controller.
+func (e *Engine) notServed(gvks []schema.GroupVersionKind) {
+	if len(gvks) == 0 {
+		return
+	}
+
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	// The manager's cache is a GVKRoutedCache if it was wrapped using
+	// WithGVKRoutedCache. Its delegate caches aren't ours to stop, but reads
+	// of a GVK that is no longer served shouldn't be routed to them.
+	if rc, ok := e.mgr.GetCache().(*GVKRoutedCache); ok {
+		for _, gvk := range gvks {
+			rc.RemoveDelegate(gvk)
+		}
+	}
+
+	for name, r := range e.runs {
+		if r.stopped {
+			continue
+		}
+		c := r.ctrl
+		for _, gvk := range gvks {
+			if _, ok := c.custom[gvk]; ok {
+				c.stopCustom(gvk)
+				c.w = c.withoutWatches(gvk)
+			}
+		}
+		for _, gvk := range gvks {
+			if !contains(c.gvks, gvk) {
+				continue
+			}
+			// Stop watching the GVK before we stop the controller, so
+			// that its cache doesn't try to list it.
+			for _, src := range c.sources[gvk] {
+				_ = src.Stop()
+			}
+			c.removeCache(gvk)
+
+			e.stop(name)
+			e.errors[name] = errors.Errorf(errFmtNotServed, gvk)
+			break
+		}
+	}
+}
+
+// served returns the GVKs served by the supplied CustomResourceDefinition.
+func served(crd *extv1.CustomResourceDefinition) []schema.GroupVersionKind {
+	out := make([]schema.GroupVersionKind, 0, len(crd.Spec.Versions))
+	for _, v := range crd.Spec.Versions {
+		if !v.Served {
+			continue
+		}
+		out = append(out, schema.GroupVersionKind{Group: crd.Spec.Group, Version: v.Name, Kind: crd.Spec.Names.Kind})
+	}
+	return out
+}
+
+// removed returns the GVKs in a that are not in b.
+func removed(a, b []schema.GroupVersionKind) []schema.GroupVersionKind {
+	out := make([]schema.GroupVersionKind, 0)
+	for _, gvk := range a {
+		if !contains(b, gvk) {
+			out = append(out, gvk)
+		}
+	}
+	return out
+}
+
+func contains(gvks []schema.GroupVersionKind, gvk schema.GroupVersionKind) bool {
+	for _, g := range gvks {
+		if g == gvk {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestEngineWatchCRDs(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	v1 := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+	v2 := schema.GroupVersionKind{Group: "example.org", Version: "v2", Kind: "Thing"}
+	other := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Other"}
+
+	crd := func(served ...string) *extv1.CustomResourceDefinition {
+		c := &extv1.CustomResourceDefinition{Spec: extv1.CustomResourceDefinitionSpec{
+			Group: "example.org",
+			Names: extv1.CustomResourceDefinitionNames{Kind: "Thing"},
+		}}
+		for _, v := range served {
+			c.Spec.Versions = append(c.Spec.Versions, extv1.CustomResourceDefinitionVersion{Name: v, Served: true})
+		}
+		return c
+	}
+
+	t.Run("GetInformerError", func(t *testing.T) {
+		e := NewEngine(&fake.Manager{})
+		i := &MockCache{MockGetInformer: func(context.Context, client.Object, ...cache.InformerGetOption) (cache.Informer, error) {
+			return nil, errBoom
+		}}
+		err := e.WatchCRDs(context.Background(), i)
+		if diff := cmp.Diff(errors.Wrap(errBoom, errGetCRDInformer), err, test.EquateErrors()); diff != "" {
+			t.Errorf("e.WatchCRDs(...): -want error, +got error:\n%s", diff)
+		}
+	})
+
+	// The manager's cache may be a caller-supplied GVKRoutedCache.
+	mrc := NewGVKRoutedCache(runtime.NewScheme(), &MockCache{})
+	mrc.AddDelegate(v2, &MockCache{})
+
+	// Record the sources each controller watches.
+	watched := map[string][]source.Source{}
+
+	inf := &MockInformer{}
+	e := NewEngine(&fake.Manager{Scheme: runtime.NewScheme(), Cache: mrc},
+		WithRestartPolicy(RestartOnFailure()),
+		WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return &MockCache{
+				MockStart: func(ctx context.Context) error {
+					<-ctx.Done()
+					return nil
+				},
+				MockGetInformer: func(context.Context, client.Object, ...cache.InformerGetOption) (cache.Informer, error) {
+					return &MockInformer{}, nil
+				},
+			}, nil
+		}),
+		WithNewControllerFn(func(name string, _ manager.Manager, _ controller.Options) (controller.Controller, error) {
+			return &MockController{
+				MockStart: func(ctx context.Context) error {
+					<-ctx.Done()
+					return nil
+				},
+				MockWatch: func(src source.Source, _ handler.EventHandler, _ ...predicate.Predicate) error {
+					watched[name] = append(watched[name], src)
+					return nil
+				},
+			}, nil
+		}),
+	)
+
+	i := &MockCache{MockGetInformer: func(context.Context, client.Object, ...cache.InformerGetOption) (cache.Informer, error) {
+		return inf, nil
+	}}
+	if err := e.WatchCRDs(context.Background(), i); err != nil {
+		t.Fatalf("e.WatchCRDs(...): %s", err)
+	}
+
+	for name, gvk := range map[string]schema.GroupVersionKind{"v1": v1, "v2": v2, "other": other} {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		if err := e.Start(name, controller.Options{}, For(u, nil)); err != nil {
+			t.Fatalf("e.Start(%q, ...): %s", name, err)
+		}
+	}
+
+	// This controller watches v2 using a custom source.
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(v2)
+	if err := e.Start("custom", controller.Options{}, TriggeredBy(source.Kind(&MockCache{}, u), nil)); err != nil {
+		t.Fatalf("e.Start(%q, ...): %s", "custom", err)
+	}
+
+	h := inf.handler.(toolscache.ResourceEventHandlerFuncs)
+
+	// Stop serving v2. Only the controller that watches v2 should stop.
+	h.OnUpdate(crd("v1", "v2"), crd("v1"))
+	for name, running := range map[string]bool{"v1": true, "v2": false, "other": true, "custom": true} {
+		if diff := cmp.Diff(running, e.IsRunning(name)); diff != "" {
+			t.Errorf("e.IsRunning(%q): -want, +got:\n%s", name, diff)
+		}
+	}
+	if diff := cmp.Diff(errors.Errorf(errFmtNotServed, v2), e.Err("v2"), test.EquateErrors()); diff != "" {
+		t.Errorf("e.Err(...): -want error, +got error:\n%s", diff)
+	}
+
+	// Reads of v2 shouldn't be routed to the manager's stale delegate.
+	if _, ok := mrc.delegate(v2, u); ok {
+		t.Errorf("mrc.delegate(...): want the route for a GVK that is no longer served to be removed")
+	}
+
+	// The custom source that watches v2 should be stopped.
+	if len(watched["custom"]) != 1 {
+		t.Fatalf("MockWatch(...): want the custom controller to watch one source, got %d", len(watched["custom"]))
+	}
+	if src, ok := watched["custom"][0].(*customSource); !ok || !src.isStopped() {
+		t.Errorf("e.notServed(...): want the custom source that watches v2 to be stopped")
+	}
+
+	// Delete the CRD. The controller that watches v1 should stop too.
+	h.OnDelete(toolscache.DeletedFinalStateUnknown{Obj: crd("v1")})
+	for name, running := range map[string]bool{"v1": false, "v2": false, "other": true} {
+		if diff := cmp.Diff(running, e.IsRunning(name)); diff != "" {
+			t.Errorf("e.IsRunning(%q): -want, +got:\n%s", name, diff)
+		}
+	}
+	if diff := cmp.Diff(errors.Errorf(errFmtNotServed, v1), e.Err("v1"), test.EquateErrors()); diff != "" {
+		t.Errorf("e.Err(...): -want error, +got error:\n%s", diff)
+	}
+
+	// Controllers stopped because their CRD was removed aren't crashed.
+	if err := e.HealthzCheck(nil); err != nil {
+		t.Errorf("e.HealthzCheck(...): %s", err)
+	}
+
+	e.Stop("other")
+	e.Stop("custom")
+}
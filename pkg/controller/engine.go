@@ -19,8 +19,12 @@ package controller
 
 import (
 	"context"
+	"strings"
 	"sync"
+	"time"
 
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -32,15 +36,22 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
 )
 
 // Error strings
 const (
 	errCreateCache      = "cannot create new cache"
 	errCreateController = "cannot create new controller"
+	errIndexField       = "cannot add index to new cache"
 	errCrashCache       = "cache error"
 	errCrashController  = "controller error"
 	errWatch            = "cannot setup watch"
+	errStopWatch        = "cannot stop watch"
+
+	errFmtGVK        = "failed to get GVK for type %T"
+	errFmtNotRunning = "controller %q is not running"
+	errFmtUnknown    = "controller %q has not been started"
 )
 
 // A NewCacheFn creates a new controller-runtime cache.
@@ -61,12 +72,45 @@ var (
 type Engine struct {
 	mgr manager.Manager
 
-	started map[string]context.CancelFunc
-	errors  map[string]error
-	mx      sync.RWMutex
+	started  map[string]*run
+	runs     map[string]*run
+	errors   map[string]error
+	restarts map[string]*restart
+	mx       sync.RWMutex
+
+	newCache     NewCacheFn
+	newCtrl      NewControllerFn
+	cacheOptions CacheOptionsFn
+
+	policy  RestartPolicy
+	onCrash CrashHandlerFn
+
+	metrics      *CacheMetrics
+	limitMetrics *ratelimiter.Metrics
+}
+
+// A run of a controller, from when it is started until it is stopped or
+// crashes.
+type run struct {
+	ctx     context.Context
+	stop    context.CancelFunc
+	started time.Time
+	ctrl    *namedController
+
+	// synced is true once the run's cache has synced.
+	synced bool
 
-	newCache NewCacheFn
-	newCtrl  NewControllerFn
+	// attempts is the number of consecutive times the controller crashed
+	// before this run.
+	attempts int
+
+	// stopped is true if the run was stopped explicitly.
+	stopped bool
+}
+
+// A restart of a controller that is waiting for its backoff to elapse.
+type restart struct {
+	timer *time.Timer
 }
 
 // An EngineOption configures an Engine.
@@ -88,16 +132,37 @@ func WithNewControllerFn(fn NewControllerFn) EngineOption {
 	}
 }
 
+// WithCacheMetrics configures the Engine to record metrics about the caches of
+// the controllers it starts. No metrics are recorded by default.
+func WithCacheMetrics(m *CacheMetrics) EngineOption {
+	return func(e *Engine) {
+		e.metrics = m
+	}
+}
+
+// WithRateLimiterMetrics configures the Engine to forget the rate limiter
+// metrics recorded under a controller's name when it stops the controller, for
+// example those of its rate limiting Reconciler. Metrics are recorded by the
+// rate limiters and Reconcilers themselves, not the Engine.
+func WithRateLimiterMetrics(m *ratelimiter.Metrics) EngineOption {
+	return func(e *Engine) {
+		e.limitMetrics = m
+	}
+}
+
 // NewEngine produces a new Engine.
 func NewEngine(mgr manager.Manager, o ...EngineOption) *Engine {
 	e := &Engine{
 		mgr: mgr,
 
-		started: make(map[string]context.CancelFunc),
-		errors:  make(map[string]error),
+		started:  make(map[string]*run),
+		runs:     make(map[string]*run),
+		errors:   make(map[string]error),
+		restarts: make(map[string]*restart),
 
-		newCache: DefaultNewCacheFn,
-		newCtrl:  DefaultNewControllerFn,
+		newCache:     DefaultNewCacheFn,
+		newCtrl:      DefaultNewControllerFn,
+		cacheOptions: func(string) CacheOptions { return CacheOptions{} },
 	}
 
 	for _, eo := range o {
@@ -126,26 +191,145 @@ func (e *Engine) Err(name string) error {
 	return e.errors[name]
 }
 
-// Stop the named controller.
+// Stop the named controller. A controller that crashed and is waiting to be
+// restarted won't be.
 func (e *Engine) Stop(name string) {
-	e.done(name, nil)
-}
-
-func (e *Engine) done(name string, err error) {
 	e.mx.Lock()
 	defer e.mx.Unlock()
+	e.stop(name)
+}
 
-	stop, ok := e.started[name]
-	if ok {
-		stop()
+// stop the named controller. The Engine's lock must be held.
+func (e *Engine) stop(name string) {
+	if rs, ok := e.restarts[name]; ok {
+		rs.timer.Stop()
+		delete(e.restarts, name)
+	}
+
+	if r, ok := e.runs[name]; ok {
+		r.stopped = true
+	}
+	if r, ok := e.started[name]; ok {
+		r.stop()
 		delete(e.started, name)
+		e.metrics.forget(name)
+	}
+	e.limitMetrics.Forget(name)
+}
+
+// done is called when the cache or controller of the supplied run returns. It
+// stops the run, records the first error it returned, and restarts the
+// controller if it crashed and its RestartPolicy says to.
+func (e *Engine) done(c *namedController, r *run, err error) {
+	e.mx.Lock()
+
+	// The controller was restarted. We don't care about old runs.
+	if cur, ok := e.started[c.name]; ok && cur != r {
+		e.mx.Unlock()
+		return
+	}
+
+	if _, ok := e.started[c.name]; ok {
+		r.stop()
+		delete(e.started, c.name)
+		e.metrics.forget(c.name)
 	}
 
 	// Don't overwrite the first error if done is called multiple times.
-	if e.errors[name] != nil {
+	if err == nil || e.errors[c.name] != nil {
+		e.mx.Unlock()
+		return
+	}
+	e.errors[c.name] = err
+
+	// Errors returned after a controller was stopped explicitly aren't crashes.
+	if r.stopped {
+		e.mx.Unlock()
+		return
+	}
+
+	crash := e.crashed(c, r, err)
+	e.mx.Unlock()
+
+	if e.onCrash != nil {
+		e.onCrash(crash)
+	}
+}
+
+// crashed schedules a restart of the supplied controller if its RestartPolicy
+// says to. The Engine's lock must be held.
+func (e *Engine) crashed(c *namedController, r *run, err error) Crash {
+	crash := Crash{Name: c.name, Err: err, Attempts: r.attempts + 1}
+	if _, maxBackoff := c.policy.limits(); time.Since(r.started) > maxBackoff {
+		crash.Attempts = 1
+	}
+	crash.Backoff, crash.Restart = c.policy.backoff(crash.Attempts)
+	if !crash.Restart {
+		return crash
+	}
+
+	rs := &restart{}
+	rs.timer = time.AfterFunc(crash.Backoff, func() { e.restartController(c, rs, crash.Attempts) })
+	e.restarts[c.name] = rs
+	return crash
+}
+
+// restartController creates and starts the supplied controller again, unless
+// the supplied restart was cancelled.
+func (e *Engine) restartController(c *namedController, rs *restart, attempts int) {
+	e.mx.RLock()
+	cancelled := e.restarts[c.name] != rs
+	e.mx.RUnlock()
+	if cancelled || c.ctx.Err() != nil {
+		return
+	}
+
+	// Build the controller again without holding the Engine's lock, then
+	// swap it into the existing controller, so that the NamedController
+	// returned by Create keeps working.
+	e.mx.RLock()
+	nc := &namedController{
+		name:    c.name,
+		e:       e,
+		co:      c.co,
+		o:       c.o,
+		policy:  c.policy,
+		cache:   c.cache,
+		indexes: append([]index{}, c.indexes...),
+	}
+	w := append([]Watch{}, c.w...)
+	e.mx.RUnlock()
+	err := nc.build(w...)
+
+	// The restart remains pending until the controller is swapped, so that
+	// the controller isn't reported as crashed while it's being created.
+	e.mx.Lock()
+	if e.restarts[c.name] != rs {
+		// The controller was stopped or started again while we were
+		// creating it.
+		e.mx.Unlock()
+		return
+	}
+	delete(e.restarts, c.name)
+	if _, running := e.started[c.name]; running {
+		e.mx.Unlock()
+		return
+	}
+	if err != nil {
+		// Treat failing to create the controller as a crash, so that we
+		// try again.
+		r := &run{ctx: c.ctx, stop: func() {}, started: time.Now(), ctrl: c, attempts: attempts}
+		e.started[c.name] = r
+		e.runs[c.name] = r
+		e.errors[c.name] = nil
+		e.mx.Unlock()
+		e.done(c, r, err)
 		return
 	}
-	e.errors[name] = err
+	c.ca, c.rc, c.ctrl = nc.ca, nc.rc, nc.ctrl
+	c.gvks, c.caches, c.stops, c.sources, c.custom, c.w = nc.gvks, nc.caches, nc.stops, nc.sources, nc.custom, nc.w
+	c.start(c.ctx, attempts)
+	e.mx.Unlock()
 }
 
 // Watch an object.
@@ -177,7 +361,14 @@ func TriggeredBy(source source.Source, h handler.EventHandler, p ...predicate.Pr
 // the supplied options, and configured with the supplied watches. Start does
 // not block.
 func (e *Engine) Start(name string, o controller.Options, w ...Watch) error {
-	c, err := e.Create(name, o, w...)
+	return e.StartWithPolicy(name, o, e.policy, w...)
+}
+
+// StartWithPolicy starts the named controller like Start, but restarts it
+// according to the supplied RestartPolicy rather than the Engine's if it
+// crashes.
+func (e *Engine) StartWithPolicy(name string, o controller.Options, p RestartPolicy, w ...Watch) error {
+	c, err := e.CreateWithPolicy(name, o, p, w...)
 	if err != nil {
 		return err
 	}
@@ -195,7 +386,42 @@ type namedController struct {
 	name string
 	e    *Engine
 	ca   cache.Cache
+	rc   *GVKRoutedCache
+	co   CacheOptions
 	ctrl controller.Controller
+
+	// The GVKs the controller watches using its own caches, the caches, and
+	// the sources that watch them. Each GVK gets a cache of its own, which is
+	// started with the controller and stopped when the GVK is no longer
+	// watched, so that its informers are removed.
+	gvks    []schema.GroupVersionKind
+	caches  map[schema.GroupVersionKind]cache.Cache
+	stops   map[schema.GroupVersionKind]context.CancelFunc
+	sources map[schema.GroupVersionKind][]*stoppableSource
+
+	// The custom sources the controller watches, by the GVK they watch, if
+	// known.
+	custom map[schema.GroupVersionKind][]*customSource
+
+	// The options, watches, and restart policy the controller was created
+	// with, and the context it was started with, used to restart it.
+	o      controller.Options
+	w      []Watch
+	policy RestartPolicy
+	ctx    context.Context
+
+	// The cache returned by GetCache, and the indexes added to it. Indexes
+	// are added to the new caches each time the controller is restarted, and
+	// to the cache of each GVK the controller starts watching.
+	cache   *controllerCache
+	indexes []index
+}
+
+// An index added to a controller's cache.
+type index struct {
+	obj     client.Object
+	field   string
+	extract client.IndexerFunc
 }
 
 // Create the named controller. Each controller gets its own cache
@@ -203,85 +429,512 @@ type namedController struct {
 // the supplied options, and configured with the supplied watches. It is not
 // started yet.
 func (e *Engine) Create(name string, o controller.Options, w ...Watch) (NamedController, error) {
-	// Each controller gets its own cache for the GVKs it owns. This cache is
-	// wrapped by a GVKRoutedCache that routes requests to other GVKs to the
-	// manager's cache. This way we can share informers for composed resources
-	// (that's where this is primarily used) with other controllers, but get
-	// control about the lifecycle of the owned GVKs' informers.
-	ca, err := e.newCache(e.mgr.GetConfig(), cache.Options{Scheme: e.mgr.GetScheme(), Mapper: e.mgr.GetRESTMapper()})
+	return e.CreateWithPolicy(name, o, e.policy, w...)
+}
+
+// CreateWithPolicy creates the named controller like Create, but restarts it
+// according to the supplied RestartPolicy rather than the Engine's if it
+// crashes.
+func (e *Engine) CreateWithPolicy(name string, o controller.Options, p RestartPolicy, w ...Watch) (NamedController, error) {
+	return e.create(name, o, p, w...)
+}
+
+func (e *Engine) create(name string, o controller.Options, p RestartPolicy, w ...Watch) (*namedController, error) {
+	c := &namedController{name: name, e: e, co: e.cacheOptions(name), o: o, policy: p}
+	c.cache = &controllerCache{c: c}
+	if err := c.build(w...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// build the controller's cache and controller-runtime controller, and
+// configure the controller with the supplied watches. The controller's indexes
+// are added to the new cache.
+func (c *namedController) build(w ...Watch) error {
+	e := c.e
+
+	// Each controller gets its own cache for each GVK it owns. These caches
+	// are wrapped by a GVKRoutedCache that routes requests to other GVKs to
+	// the manager's cache. This way we can share informers for composed
+	// resources (that's where this is primarily used) with other controllers,
+	// but get control about the lifecycle of the owned GVKs' informers. The
+	// controller's cache is used for kinds it doesn't watch, for example by
+	// its GetCache.
+	ca, err := e.newCache(e.mgr.GetConfig(), c.co.forCache(e.mgr))
 	if err != nil {
-		return nil, errors.Wrap(err, errCreateCache)
+		return errors.Wrap(err, errCreateCache)
 	}
 
 	// Wrap the existing manager to use our cache for the GVKs of this controller.
-	rc := NewGVKRoutedCache(e.mgr.GetScheme(), e.mgr.GetCache())
+	// Full objects of kinds that are only cached as metadata are read from
+	// the API server, not the manager's cache, which would start an informer
+	// for full objects.
+	ar := e.mgr.GetAPIReader()
+	rc := NewGVKRoutedCache(e.mgr.GetScheme(), e.mgr.GetCache(), WithRoutedCacheMetrics(e.metrics, c.name), WithUncachedReader(ar))
 	rm := &routedManager{
 		Manager: e.mgr,
 		client: &cachedRoutedClient{
-			Client: e.mgr.GetClient(),
-			scheme: e.mgr.GetScheme(),
-			cache:  rc,
+			Client:   e.mgr.GetClient(),
+			scheme:   e.mgr.GetScheme(),
+			cache:    rc,
+			uncached: ar,
 		},
 		cache: rc,
 	}
 
-	ctrl, err := e.newCtrl(name, rm, o)
+	ctrl, err := e.newCtrl(c.name, rm, c.o)
 	if err != nil {
-		return nil, errors.Wrap(err, errCreateController)
+		return errors.Wrap(err, errCreateController)
 	}
 
+	c.ca = ca
+	c.rc = rc
+	c.ctrl = ctrl
+	c.gvks = nil
+	c.caches = make(map[schema.GroupVersionKind]cache.Cache)
+	c.stops = make(map[schema.GroupVersionKind]context.CancelFunc)
+	c.sources = make(map[schema.GroupVersionKind][]*stoppableSource)
+	c.custom = make(map[schema.GroupVersionKind][]*customSource)
+	c.w = nil
+
 	for _, wt := range w {
 		if wt.customSource != nil {
-			if err := ctrl.Watch(wt.customSource, wt.handler, wt.predicates...); err != nil {
-				return nil, errors.Wrap(err, errWatch)
+			if err := ctrl.Watch(c.customSource(wt), wt.handler, wt.predicates...); err != nil {
+				return errors.Wrap(err, errWatch)
 			}
+			c.w = append(c.w, wt)
 			continue
 		}
 
 		// route cache and client (read) requests to our cache for this GVK.
 		gvk, err := apiutil.GVKForObject(wt.kind, e.mgr.GetScheme())
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to get GVK for type %T", wt.kind)
+			return errors.Wrapf(err, errFmtGVK, wt.kind)
 		}
-		rc.AddDelegate(gvk, ca)
+		if _, ok := c.caches[gvk]; !ok {
+			if err := c.addCache(gvk); err != nil {
+				return err
+			}
+		}
+
+		if err := c.watch(gvk, wt); err != nil {
+			return err
+		}
+	}
 
-		if err := ctrl.Watch(source.Kind(ca, wt.kind), wt.handler, wt.predicates...); err != nil {
-			return nil, errors.Wrap(err, errWatch)
+	// Indexes of kinds the controller doesn't watch are added to its cache.
+	for _, ix := range c.indexes {
+		if _, ok := c.caches[c.indexed(ix)]; ok {
+			continue
+		}
+		if err := ca.IndexField(context.Background(), ix.obj, ix.field, ix.extract); err != nil {
+			return errors.Wrap(err, errIndexField)
 		}
 	}
 
-	return &namedController{name: name, e: e, ca: ca, ctrl: ctrl}, nil
+	return nil
+}
+
+// watch the supplied kind of object using the controller's cache for its GVK.
+func (c *namedController) watch(gvk schema.GroupVersionKind, wt Watch) error {
+	if err := c.ctrl.Watch(c.source(gvk, wt), wt.handler, wt.predicates...); err != nil {
+		return errors.Wrap(err, errWatch)
+	}
+	c.w = append(c.w, wt)
+	return nil
+}
+
+// source returns a new source that watches the supplied kind of object using
+// the controller's cache for its GVK, so that StopWatches can stop it. The
+// Engine's lock must be held if the controller is running.
+func (c *namedController) source(gvk schema.GroupVersionKind, wt Watch) *stoppableSource {
+	src := newStoppableSource(c.caches[gvk], c.co.kind(gvk, wt.kind))
+	if len(c.sources[gvk]) == 0 {
+		// Only the first source of each GVK counts its objects.
+		src.objects = c.e.metrics.objectsOf(c.name, gvk)
+	}
+	c.sources[gvk] = append(c.sources[gvk], src)
+	return src
+}
+
+// customSource returns a new source that wraps the supplied watch's custom
+// source, so that it can be stopped if the kind of object it watches is known.
+// The Engine's lock must be held if the controller is running.
+func (c *namedController) customSource(wt Watch) *customSource {
+	src := &customSource{Source: wt.customSource}
+	if gvk, ok := c.watched(wt); ok {
+		c.custom[gvk] = append(c.custom[gvk], src)
+	}
+	return src
+}
+
+// watched returns the GVK the supplied watch watches, if known.
+func (c *namedController) watched(wt Watch) (schema.GroupVersionKind, bool) {
+	kind := wt.kind
+	if wt.customSource != nil {
+		kind = kindOf(wt.customSource)
+	}
+	if kind == nil {
+		return schema.GroupVersionKind{}, false
+	}
+	gvk, err := apiutil.GVKForObject(kind, c.e.mgr.GetScheme())
+	return gvk, err == nil
+}
+
+// stopCustom stops the controller's custom sources that watch the supplied
+// GVK. The Engine's lock must be held.
+func (c *namedController) stopCustom(gvk schema.GroupVersionKind) {
+	for _, src := range c.custom[gvk] {
+		src.Stop()
+	}
+	delete(c.custom, gvk)
+}
+
+// StartWatches starts the supplied watches on the named controller, which must
+// be running. A kind of object the controller doesn't already watch is watched
+// using a new cache, which is stopped when StopWatches stops all watches of
+// that kind. Watches of custom sources can only be stopped if the kind of
+// object they watch is known. If any watch can't be started none are; the
+// caches and sources that were added for the others are removed.
+func (e *Engine) StartWatches(name string, w ...Watch) error {
+	e.mx.Lock()
+	r, ok := e.started[name]
+	if !ok {
+		e.mx.Unlock()
+		return errors.Errorf(errFmtNotRunning, name)
+	}
+	c := r.ctrl
+
+	srcs := make([]source.Source, 0, len(w))
+	added := make([]schema.GroupVersionKind, 0)
+	for _, wt := range w {
+		if wt.customSource != nil {
+			srcs = append(srcs, c.customSource(wt))
+			continue
+		}
+
+		gvk, err := apiutil.GVKForObject(wt.kind, e.mgr.GetScheme())
+		if err != nil {
+			c.rollback(srcs, added)
+			e.mx.Unlock()
+			return errors.Wrapf(err, errFmtGVK, wt.kind)
+		}
+		if _, ok := c.caches[gvk]; !ok {
+			if err := c.addCache(gvk); err != nil {
+				c.rollback(srcs, added)
+				e.mx.Unlock()
+				return err
+			}
+			c.startCache(r, gvk)
+			added = append(added, gvk)
+		}
+		srcs = append(srcs, c.source(gvk, wt))
+	}
+	e.mx.Unlock()
+
+	// A controller holds its own lock while it waits for its sources to sync,
+	// and Watch takes that lock. We don't hold the Engine's lock while we
+	// call Watch, so that the Engine isn't blocked while the controller syncs.
+	for i, wt := range w {
+		if err := c.ctrl.Watch(srcs[i], wt.handler, wt.predicates...); err != nil {
+			e.mx.Lock()
+			c.rollback(srcs, added)
+			e.mx.Unlock()
+			return errors.Wrap(err, errWatch)
+		}
+	}
+
+	e.mx.Lock()
+	c.w = append(c.w, w...)
+	e.mx.Unlock()
+	return nil
+}
+
+// rollback stops the supplied sources, and the caches of the supplied GVKs,
+// when StartWatches fails. The Engine's lock must be held.
+func (c *namedController) rollback(srcs []source.Source, added []schema.GroupVersionKind) {
+	for _, src := range srcs {
+		switch s := src.(type) {
+		case *stoppableSource:
+			// Stopping a source can only fail to remove an event
+			// handler from an informer, which we're about to stop.
+			_ = s.Stop()
+			for gvk, ss := range c.sources {
+				c.sources[gvk] = withoutSource(ss, s)
+			}
+		case *customSource:
+			s.Stop()
+			for gvk, cs := range c.custom {
+				c.custom[gvk] = withoutSource(cs, s)
+			}
+		}
+	}
+	for _, gvk := range added {
+		c.removeCache(gvk)
+		delete(c.sources, gvk)
+		c.e.metrics.forgetObjects(c.name, gvk)
+	}
+}
+
+func withoutSource[T comparable](srcs []T, src T) []T {
+	out := make([]T, 0, len(srcs))
+	for _, s := range srcs {
+		if s != src {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// addCache adds a new cache for the supplied GVK, adds the controller's
+// indexes of that GVK to it, and routes the controller's reads of that GVK to
+// it. The Engine's lock must be held if the controller is running.
+func (c *namedController) addCache(gvk schema.GroupVersionKind) error {
+	ca, err := c.e.newCache(c.e.mgr.GetConfig(), c.co.forCache(c.e.mgr))
+	if err != nil {
+		return errors.Wrap(err, errCreateCache)
+	}
+	for _, ix := range c.indexes {
+		if c.indexed(ix) != gvk {
+			continue
+		}
+		if err := ca.IndexField(context.Background(), ix.obj, ix.field, ix.extract); err != nil {
+			return errors.Wrap(err, errIndexField)
+		}
+	}
+
+	if c.co.isMetadataOnly(gvk) {
+		c.rc.AddMetadataDelegate(gvk, ca)
+	} else {
+		c.rc.AddDelegate(gvk, ca)
+	}
+	c.caches[gvk] = ca
+	c.gvks = append(c.gvks, gvk)
+	return nil
+}
+
+// startCache starts the controller's cache for the supplied GVK. The cache is
+// stopped when the supplied run is, or when the GVK is no longer watched. The
+// Engine's lock must be held.
+func (c *namedController) startCache(r *run, gvk schema.GroupVersionKind) {
+	ca := c.caches[gvk]
+	ctx, stop := context.WithCancel(r.ctx)
+	go func() {
+		<-c.e.mgr.Elected()
+		// Errors returned after the watches were stopped aren't crashes.
+		if err := ca.Start(ctx); err != nil && ctx.Err() == nil {
+			c.e.done(c, r, errors.Wrap(err, errCrashCache))
+		}
+	}()
+	c.stops[gvk] = stop
+}
+
+// removeCache stops the controller's cache for the supplied GVK, if it was
+// started, and stops routing the controller's reads of that GVK to it. The
+// Engine's lock must be held.
+func (c *namedController) removeCache(gvk schema.GroupVersionKind) {
+	if stop, ok := c.stops[gvk]; ok {
+		stop()
+		delete(c.stops, gvk)
+	}
+	delete(c.caches, gvk)
+	c.rc.RemoveDelegate(gvk)
+	c.gvks = without(c.gvks, gvk)
+}
+
+// indexed returns the GVK of the supplied index's kind of object.
+func (c *namedController) indexed(ix index) schema.GroupVersionKind {
+	gvk, _ := apiutil.GVKForObject(ix.obj, c.e.mgr.GetScheme())
+	return gvk
+}
+
+// StopWatches stops all of the named controller's watches of the supplied
+// kinds of object, including watches of custom sources whose kind of object is
+// known. The cache used to watch each kind is stopped, so that its informers
+// are removed.
+func (e *Engine) StopWatches(name string, gvks ...schema.GroupVersionKind) error {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	r, ok := e.runs[name]
+	if !ok {
+		return errors.Errorf(errFmtUnknown, name)
+	}
+	c := r.ctrl
+
+	for _, gvk := range gvks {
+		for _, src := range c.sources[gvk] {
+			if err := src.Stop(); err != nil {
+				return errors.Wrap(err, errStopWatch)
+			}
+		}
+		delete(c.sources, gvk)
+		c.stopCustom(gvk)
+		c.removeCache(gvk)
+
+		e.metrics.forgetObjects(name, gvk)
+		c.w = c.withoutWatches(gvk)
+	}
+	return nil
+}
+
+// withoutWatches returns the controller's watches, omitting those of the
+// supplied kind, so that they're not started again if it's restarted.
+func (c *namedController) withoutWatches(gvk schema.GroupVersionKind) []Watch {
+	w := make([]Watch, 0, len(c.w))
+	for _, wt := range c.w {
+		if wgvk, ok := c.watched(wt); ok && wgvk == gvk {
+			continue
+		}
+		w = append(w, wt)
+	}
+	return w
+}
+
+func without(gvks []schema.GroupVersionKind, gvk schema.GroupVersionKind) []schema.GroupVersionKind {
+	out := make([]schema.GroupVersionKind, 0, len(gvks))
+	for _, g := range gvks {
+		if g != gvk {
+			out = append(out, g)
+		}
+	}
+	return out
 }
 
 // Start the named controller. Start does not block.
 func (c *namedController) Start(ctx context.Context) error {
-	if c.e.IsRunning(c.name) {
+	c.e.mx.Lock()
+	defer c.e.mx.Unlock()
+
+	if _, running := c.e.started[c.name]; running {
 		return nil
 	}
 
-	ctx, stop := context.WithCancel(ctx)
-	c.e.mx.Lock()
-	c.e.started[c.name] = stop
+	// Starting the controller supersedes any pending restart.
+	if rs, ok := c.e.restarts[c.name]; ok {
+		rs.timer.Stop()
+		delete(c.e.restarts, c.name)
+	}
+
+	c.start(ctx, 0)
+	return nil
+}
+
+// start a run of the controller. The Engine's lock must be held.
+func (c *namedController) start(ctx context.Context, attempts int) {
+	c.ctx = ctx
+	rctx, stop := context.WithCancel(ctx)
+	r := &run{ctx: rctx, stop: stop, started: time.Now(), ctrl: c, attempts: attempts}
+	c.e.started[c.name] = r
+	c.e.runs[c.name] = r
 	c.e.errors[c.name] = nil
-	c.e.mx.Unlock()
 
+	for gvk := range c.caches {
+		c.startCache(r, gvk)
+	}
 	go func() {
 		<-c.e.mgr.Elected()
-		c.e.done(c.name, errors.Wrap(c.ca.Start(ctx), errCrashCache))
+		c.e.done(c, r, errors.Wrap(c.ca.Start(rctx), errCrashCache))
 	}()
 	go func() {
 		<-c.e.mgr.Elected()
-		if synced := c.ca.WaitForCacheSync(ctx); !synced {
-			c.e.done(c.name, errors.New(errCrashCache))
+		t := time.Now()
+		if synced := c.ca.WaitForCacheSync(rctx); !synced {
+			c.e.done(c, r, errors.New(errCrashCache))
 			return
 		}
-		c.e.done(c.name, errors.Wrap(c.ctrl.Start(ctx), errCrashController))
+		c.e.metrics.synced(c.name, time.Since(t))
+		c.e.mx.Lock()
+		r.synced = true
+		c.e.mx.Unlock()
+		c.e.done(c, r, errors.Wrap(c.ctrl.Start(rctx), errCrashController))
 	}()
-
-	return nil
 }
 
-// GetCache returns the cache used by the named controller.
+// GetCache returns the cache used by the named controller. The returned cache
+// remains valid if the controller is restarted.
 func (c *namedController) GetCache() cache.Cache {
-	return c.ca
+	return c.cache
+}
+
+// A controllerCache is the cache of a controller created by an Engine. It
+// delegates to the controller's cache for the kind of object, or to the
+// controller's cache for kinds it doesn't watch. These caches are replaced each
+// time the controller is restarted. The controllerCache records the indexes
+// added to it so that they can be added to the replacements.
+type controllerCache struct {
+	c *namedController
+}
+
+func (cc *controllerCache) current() cache.Cache {
+	cc.c.e.mx.RLock()
+	defer cc.c.e.mx.RUnlock()
+	return cc.c.ca
+}
+
+// forKind returns the cache of the supplied GVK.
+func (cc *controllerCache) forKind(gvk schema.GroupVersionKind) cache.Cache {
+	cc.c.e.mx.RLock()
+	defer cc.c.e.mx.RUnlock()
+	if ca, ok := cc.c.caches[gvk]; ok {
+		return ca
+	}
+	return cc.c.ca
+}
+
+// forObject returns the cache of the supplied kind of object.
+func (cc *controllerCache) forObject(obj runtime.Object) cache.Cache {
+	gvk, err := apiutil.GVKForObject(obj, cc.c.e.mgr.GetScheme())
+	if err != nil {
+		return cc.current()
+	}
+	if _, ok := obj.(client.ObjectList); ok {
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	}
+	return cc.forKind(gvk)
+}
+
+// Get the supplied object from the controller's cache.
+func (cc *controllerCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return cc.forObject(obj).Get(ctx, key, obj, opts...)
+}
+
+// List the supplied objects from the controller's cache.
+func (cc *controllerCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return cc.forObject(list).List(ctx, list, opts...)
+}
+
+// GetInformer returns an informer for the supplied object.
+func (cc *controllerCache) GetInformer(ctx context.Context, obj client.Object, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	return cc.forObject(obj).GetInformer(ctx, obj, opts...)
+}
+
+// GetInformerForKind returns an informer for the supplied GVK.
+func (cc *controllerCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	return cc.forKind(gvk).GetInformerForKind(ctx, gvk, opts...)
+}
+
+// Start the controller's cache. The Engine starts the cache when it starts
+// the controller, so this is rarely necessary.
+func (cc *controllerCache) Start(ctx context.Context) error {
+	return cc.current().Start(ctx)
+}
+
+// WaitForCacheSync waits for the controller's cache to sync.
+func (cc *controllerCache) WaitForCacheSync(ctx context.Context) bool {
+	return cc.current().WaitForCacheSync(ctx)
+}
+
+// IndexField adds an index to the controller's cache. The index is added
+// again each time the controller is restarted, and each time it starts
+// watching the kind of object.
+func (cc *controllerCache) IndexField(ctx context.Context, obj client.Object, field string, extract client.IndexerFunc) error {
+	if err := cc.forObject(obj).IndexField(ctx, obj, field, extract); err != nil {
+		return err
+	}
+	cc.c.e.mx.Lock()
+	defer cc.c.e.mx.Unlock()
+	cc.c.indexes = append(cc.c.indexes, index{obj: obj, field: field, extract: extract})
+	return nil
 }
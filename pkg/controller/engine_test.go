@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -40,13 +41,18 @@ import (
 type MockCache struct {
 	cache.Cache
 
-	MockStart func(stop context.Context) error
+	MockStart       func(stop context.Context) error
+	MockGetInformer func(ctx context.Context, obj client.Object, opts ...cache.InformerGetOption) (cache.Informer, error)
 }
 
 func (c *MockCache) Start(stop context.Context) error {
 	return c.MockStart(stop)
 }
 
+func (c *MockCache) GetInformer(ctx context.Context, obj client.Object, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	return c.MockGetInformer(ctx, obj, opts...)
+}
+
 func (c *MockCache) WaitForCacheSync(_ context.Context) bool {
 	return true
 }
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Values of the route label of the requests metric.
+const (
+	routeDelegate = "delegate"
+	routeFallback = "fallback"
+)
+
+// Values of the verb label of the requests metric.
+const (
+	verbGet  = "get"
+	verbList = "list"
+)
+
+// CacheMetrics are Prometheus metrics for GVKRoutedCaches, and the caches of
+// the controllers started by an Engine. Register them with a Prometheus
+// registry, e.g. controller-runtime's metrics.Registry.
+type CacheMetrics struct {
+	delegates   *prometheus.GaugeVec
+	objects     *prometheus.GaugeVec
+	syncLatency *prometheus.HistogramVec
+	requests    *prometheus.CounterVec
+}
+
+// NewCacheMetrics returns new cache metrics.
+func NewCacheMetrics() *CacheMetrics {
+	return &CacheMetrics{
+		delegates: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Subsystem: "engine",
+			Name:      "cache_delegates",
+			Help:      "The number of GVKs a GVKRoutedCache routes to a delegate cache.",
+		}, []string{"cache"}),
+		objects: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Subsystem: "engine",
+			Name:      "cache_objects",
+			Help:      "The number of objects of a GVK in a controller's cache.",
+		}, []string{"cache", "gvk"}),
+		syncLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Subsystem: "engine",
+			Name:      "cache_sync_seconds",
+			Help:      "How long a controller's cache took to sync after it was started.",
+			Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300},
+		}, []string{"cache"}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Subsystem: "engine",
+			Name:      "cache_requests_total",
+			Help:      "The number of get and list requests a GVKRoutedCache routed to a delegate or the fallback.",
+		}, []string{"cache", "gvk", "verb", "route"}),
+	}
+}
+
+// Describe sends the descriptors of the metrics to the supplied channel.
+func (m *CacheMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.delegates.Describe(ch)
+	m.objects.Describe(ch)
+	m.syncLatency.Describe(ch)
+	m.requests.Describe(ch)
+}
+
+// Collect sends the metrics to the supplied channel.
+func (m *CacheMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.delegates.Collect(ch)
+	m.objects.Collect(ch)
+	m.syncLatency.Collect(ch)
+	m.requests.Collect(ch)
+}
+
+var _ prometheus.Collector = &CacheMetrics{}
+
+// All of the below methods may be called on nil CacheMetrics, which record
+// nothing.
+
+func (m *CacheMetrics) setDelegates(cache string, n int) {
+	if m == nil {
+		return
+	}
+	m.delegates.WithLabelValues(cache).Set(float64(n))
+}
+
+func (m *CacheMetrics) routed(cache string, gvk schema.GroupVersionKind, verb string, delegated bool) {
+	if m == nil {
+		return
+	}
+	route := routeFallback
+	if delegated {
+		route = routeDelegate
+	}
+	m.requests.WithLabelValues(cache, gvk.String(), verb, route).Inc()
+}
+
+func (m *CacheMetrics) synced(cache string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.syncLatency.WithLabelValues(cache).Observe(d.Seconds())
+}
+
+// objectsOf returns a gauge of the number of objects of the supplied GVK in the
+// supplied cache, reset to zero.
+func (m *CacheMetrics) objectsOf(cache string, gvk schema.GroupVersionKind) prometheus.Gauge {
+	if m == nil {
+		return nil
+	}
+	g := m.objects.WithLabelValues(cache, gvk.String())
+	g.Set(0)
+	return g
+}
+
+// forgetObjects of the supplied GVK in the supplied cache.
+func (m *CacheMetrics) forgetObjects(cache string, gvk schema.GroupVersionKind) {
+	if m == nil {
+		return
+	}
+	m.objects.DeleteLabelValues(cache, gvk.String())
+}
+
+// forget the current state of the supplied cache, which was stopped.
+func (m *CacheMetrics) forget(cache string) {
+	if m == nil {
+		return
+	}
+	m.delegates.DeleteLabelValues(cache)
+	m.objects.DeletePartialMatch(prometheus.Labels{"cache": cache})
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+func TestGVKRoutedCacheMetrics(t *testing.T) {
+	m := NewCacheMetrics()
+	thing := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+	other := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Other"}
+
+	c := NewGVKRoutedCache(runtime.NewScheme(), &MockReader{}, WithRoutedCacheMetrics(m, "cool"))
+	c.AddDelegate(thing, &MockReader{})
+	c.AddDelegate(other, &MockReader{})
+	c.RemoveDelegate(other)
+
+	for _, gvk := range []schema.GroupVersionKind{thing, thing, other} {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		_ = c.Get(context.Background(), client.ObjectKey{Name: "cool"}, u)
+	}
+
+	if diff := cmp.Diff(1.0, testutil.ToFloat64(m.delegates.WithLabelValues("cool"))); diff != "" {
+		t.Errorf("delegates: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(2.0, testutil.ToFloat64(m.requests.WithLabelValues("cool", thing.String(), verbGet, routeDelegate))); diff != "" {
+		t.Errorf("requests routed to delegate: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(1.0, testutil.ToFloat64(m.requests.WithLabelValues("cool", other.String(), verbGet, routeFallback))); diff != "" {
+		t.Errorf("requests routed to fallback: -want, +got:\n%s", diff)
+	}
+}
+
+func TestEngineMetrics(t *testing.T) {
+	m := NewCacheMetrics()
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+	inf := &MockInformer{}
+
+	e := NewEngine(&fake.Manager{Scheme: runtime.NewScheme(), Cache: &MockCache{}},
+		WithCacheMetrics(m),
+		WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return &MockCache{
+				MockStart: func(ctx context.Context) error {
+					<-ctx.Done()
+					return nil
+				},
+				MockGetInformer: func(context.Context, client.Object, ...cache.InformerGetOption) (cache.Informer, error) {
+					return inf, nil
+				},
+			}, nil
+		}),
+		WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+			return &MockController{
+				MockStart: func(ctx context.Context) error {
+					<-ctx.Done()
+					return nil
+				},
+				MockWatch: func(s source.Source, h handler.EventHandler, p ...predicate.Predicate) error {
+					return s.Start(context.Background(), h, nil, p...)
+				},
+			}, nil
+		}),
+	)
+
+	thing := &unstructured.Unstructured{}
+	thing.SetGroupVersionKind(gvk)
+	reject := predicate.NewPredicateFuncs(func(client.Object) bool { return false })
+	if err := e.Start("cool", controller.Options{}, For(thing, nil, reject)); err != nil {
+		t.Fatalf("e.Start(...): %s", err)
+	}
+
+	// Objects should be counted even if predicates filter their events.
+	h := inf.handler.(toolscache.ResourceEventHandlerFuncs)
+	h.OnAdd(&unstructured.Unstructured{}, false)
+	h.OnAdd(&unstructured.Unstructured{}, false)
+	h.OnDelete(&unstructured.Unstructured{})
+
+	if diff := cmp.Diff(1.0, testutil.ToFloat64(m.objects.WithLabelValues("cool", gvk.String()))); diff != "" {
+		t.Errorf("objects: -want, +got:\n%s", diff)
+	}
+
+	waitFor(t, func() bool { return testutil.CollectAndCount(m.syncLatency) == 1 })
+
+	// Stopping the controller should forget its delegates and objects.
+	e.Stop("cool")
+	if diff := cmp.Diff(0, testutil.CollectAndCount(m.objects)+testutil.CollectAndCount(m.delegates)); diff != "" {
+		t.Errorf("e.Stop(...): -want metrics, +got metrics:\n%s", diff)
+	}
+}
+
+func TestEngineRateLimiterMetrics(t *testing.T) {
+	m := ratelimiter.NewMetrics()
+	_ = ratelimiter.NewReconciler("cool", nil, ratelimiter.NewGlobal(1), ratelimiter.WithMetrics(m))
+
+	e := NewEngine(&fake.Manager{},
+		WithRateLimiterMetrics(m),
+		WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return &MockCache{MockStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}}, nil
+		}),
+		WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+			return &MockController{MockStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}}, nil
+		}),
+	)
+	if err := e.Start("cool", controller.Options{}); err != nil {
+		t.Fatalf("e.Start(...): %s", err)
+	}
+	if diff := cmp.Diff(1, testutil.CollectAndCount(m)); diff != "" {
+		t.Errorf("e.Start(...): -want metrics, +got metrics:\n%s", diff)
+	}
+
+	// Stopping the controller should forget its rate limiter metrics.
+	e.Stop("cool")
+	if diff := cmp.Diff(0, testutil.CollectAndCount(m)); diff != "" {
+		t.Errorf("e.Stop(...): -want metrics, +got metrics:\n%s", diff)
+	}
+}
+
+func TestNilCacheMetrics(t *testing.T) {
+	var m *CacheMetrics
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("Nil CacheMetrics should record nothing, but panicked: %v", r)
+		}
+	}()
+	m.setDelegates("cool", 1)
+	m.routed("cool", schema.GroupVersionKind{}, verbGet, true)
+	m.forget("cool")
+	if g := m.objectsOf("cool", schema.GroupVersionKind{}); g != nil {
+		t.Errorf("m.objectsOf(...): want nil gauge")
+	}
+}
@@ -0,0 +1,352 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	kmeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+)
+
+// ExternalNameIndex is the name of the field index that NotificationSource
+// uses to map an external name to the managed resources that have it. The
+// index must be registered with IndexExternalName.
+const ExternalNameIndex = "metadata.annotations.externalName"
+
+// Error strings.
+const (
+	errNotStarted        = "notification source is not started"
+	errReadNotification  = "cannot read notification"
+	errParseNotification = "cannot parse notification"
+	errNoExternalName    = "notification does not specify an external name"
+	errListByExtName     = "cannot list resources by external name"
+	errExtractList       = "cannot extract items from list"
+	errNotifyFailed      = "cannot process notification"
+	errNoSignature       = "notification is not signed"
+	errBadSignature      = "notification signature is invalid"
+	errBadSecret         = "notification secret is invalid"
+)
+
+// Headers and content types used by CloudEvents over HTTP. See
+// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/bindings/http-protocol-binding.md
+const (
+	headerCloudEventSpecVersion = "Ce-Specversion"
+	headerCloudEventSubject     = "Ce-Subject"
+	contentTypeCloudEvents      = "application/cloudevents+json"
+)
+
+// maxNotificationBytes is the largest notification body we're willing to read.
+const maxNotificationBytes = 1 << 20
+
+// IndexExternalName is a client.IndexerFunc that indexes objects by their
+// external name annotation. Register it under ExternalNameIndex for any kind
+// a NotificationSource should map notifications to.
+func IndexExternalName(o client.Object) []string {
+	if en := meta.GetExternalName(o); en != "" {
+		return []string{en}
+	}
+	return nil
+}
+
+// An ExternalChange notifies a controller that an external resource changed.
+type ExternalChange struct {
+	// ExternalName of the external resource that changed.
+	ExternalName string `json:"externalName"`
+}
+
+// A cloudEvent is a CloudEvent in structured content mode. We only decode the
+// fields we need to produce an ExternalChange.
+type cloudEvent struct {
+	SpecVersion string          `json:"specversion"`
+	Subject     string          `json:"subject,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+}
+
+// A NotificationVerifier verifies that a notification was sent by a trusted
+// sender. It's passed the request and its body, and returns an error if the
+// notification should be rejected.
+type NotificationVerifier func(r *http.Request, body []byte) error
+
+// VerifySharedSecret returns a NotificationVerifier that requires the supplied
+// header to contain the supplied shared secret.
+func VerifySharedSecret(header string, secret []byte) NotificationVerifier {
+	return func(r *http.Request, _ []byte) error {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(header)), secret) != 1 {
+			return errors.New(errBadSecret)
+		}
+		return nil
+	}
+}
+
+// VerifyHMACSHA256 returns a NotificationVerifier that requires the supplied
+// header to contain the hex encoded HMAC-SHA256 of the notification body,
+// keyed with the supplied secret. The hex encoded HMAC may be prefixed with
+// "sha256=", as sent by e.g. GitHub webhooks.
+func VerifyHMACSHA256(header string, secret []byte) NotificationVerifier {
+	return func(r *http.Request, body []byte) error {
+		sig := r.Header.Get(header)
+		if sig == "" {
+			return errors.New(errNoSignature)
+		}
+		got, err := hex.DecodeString(strings.TrimPrefix(sig, "sha256="))
+		if err != nil {
+			return errors.New(errBadSignature)
+		}
+		mac := hmac.New(sha256.New, secret)
+		_, _ = mac.Write(body)
+		if !hmac.Equal(got, mac.Sum(nil)) {
+			return errors.New(errBadSignature)
+		}
+		return nil
+	}
+}
+
+// A NotificationSourceOption configures a NotificationSource.
+type NotificationSourceOption func(s *NotificationSource)
+
+// WithNotificationVerifier configures a NotificationSource to reject
+// notifications that the supplied verifier doesn't accept. Any client that
+// can reach a NotificationSource without a verifier can cause resources to be
+// reconciled, so a verifier should be used unless the endpoint is otherwise
+// protected.
+func WithNotificationVerifier(v NotificationVerifier) NotificationSourceOption {
+	return func(s *NotificationSource) {
+		s.verify = v
+	}
+}
+
+// WithNotificationLogger configures the logger a NotificationSource uses to
+// log notifications it fails to process.
+func WithNotificationLogger(l logging.Logger) NotificationSourceOption {
+	return func(s *NotificationSource) {
+		s.log = l
+	}
+}
+
+// A NotificationSource is a source.Source that enqueues managed resources when
+// it is notified that their external resource changed. Notifications are
+// received over HTTP - a NotificationSource is an http.Handler. They may be
+// CloudEvents, in structured or binary content mode, or a simple JSON encoded
+// ExternalChange. CloudEvents must either contain an ExternalChange as their
+// data, or use the external name as their subject.
+//
+// Notifications are mapped to managed resources using ExternalNameIndex, so
+// the index must be registered with the cache the supplied client reads from.
+//
+// Notifications are not authenticated unless a NotificationVerifier is
+// configured using WithNotificationVerifier.
+type NotificationSource struct {
+	client client.Reader
+	list   client.ObjectList
+	verify NotificationVerifier
+	log    logging.Logger
+
+	mx   sync.RWMutex
+	regs []registration
+}
+
+type registration struct {
+	handler    handler.EventHandler
+	queue      workqueue.RateLimitingInterface
+	predicates []predicate.Predicate
+}
+
+var (
+	_ source.Source = &NotificationSource{}
+	_ http.Handler  = &NotificationSource{}
+)
+
+// NewNotificationSource returns a NotificationSource that maps notifications
+// to objects of the kind of the supplied list. For example passing a
+// *v1.BucketList will cause Bucket objects to be enqueued.
+func NewNotificationSource(c client.Reader, l client.ObjectList, o ...NotificationSourceOption) *NotificationSource {
+	s := &NotificationSource{client: c, list: l, log: logging.NewNopLogger()}
+	for _, fn := range o {
+		fn(s)
+	}
+	return s
+}
+
+// Start registers the supplied handler and queue with the NotificationSource.
+// Objects that notifications map to are handled as generic events. Start does
+// not block.
+func (s *NotificationSource) Start(_ context.Context, h handler.EventHandler, q workqueue.RateLimitingInterface, p ...predicate.Predicate) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.regs = append(s.regs, registration{handler: h, queue: q, predicates: p})
+	return nil
+}
+
+// ServeHTTP handles an external change notification. It responds with 202
+// Accepted once every matching object has been enqueued.
+func (s *NotificationSource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mx.RLock()
+	started := len(s.regs) > 0
+	s.mx.RUnlock()
+	if !started {
+		http.Error(w, errNotStarted, http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxNotificationBytes))
+	if err != nil {
+		http.Error(w, errors.Wrap(err, errReadNotification).Error(), http.StatusBadRequest)
+		return
+	}
+
+	if s.verify != nil {
+		if err := s.verify(r, body); err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	c, err := ParseExternalChange(r.Header, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.Notify(r.Context(), c); err != nil {
+		s.log.Info("Cannot process notification", "external-name", c.ExternalName, "error", err)
+		http.Error(w, errNotifyFailed, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Notify the NotificationSource that an external resource changed. Every
+// object with the changed external resource's external name is enqueued.
+func (s *NotificationSource) Notify(ctx context.Context, c ExternalChange) error {
+	l, ok := s.list.DeepCopyObject().(client.ObjectList)
+	if !ok {
+		return errors.Errorf("%T is not a client.ObjectList", s.list)
+	}
+	if err := s.client.List(ctx, l, client.MatchingFields{ExternalNameIndex: c.ExternalName}); err != nil {
+		return errors.Wrap(err, errListByExtName)
+	}
+	items, err := kmeta.ExtractList(l)
+	if err != nil {
+		return errors.Wrap(err, errExtractList)
+	}
+
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	for _, i := range items {
+		o, ok := i.(client.Object)
+		if !ok {
+			continue
+		}
+		for _, reg := range s.regs {
+			reg.generic(ctx, event.GenericEvent{Object: o})
+		}
+	}
+	return nil
+}
+
+func (r registration) generic(ctx context.Context, evt event.GenericEvent) {
+	for _, p := range r.predicates {
+		if !p.Generic(evt) {
+			return
+		}
+	}
+	r.handler.Generic(ctx, evt, r.queue)
+}
+
+// ParseExternalChange parses an ExternalChange from the supplied HTTP headers
+// and body. The body may be a CloudEvent in structured content mode, the data
+// of a CloudEvent in binary content mode, or a JSON encoded ExternalChange.
+// CloudEvents whose data is not a JSON encoded ExternalChange use their
+// subject as the external name.
+func ParseExternalChange(h http.Header, body []byte) (ExternalChange, error) {
+	c := ExternalChange{}
+	data := body
+	subject := ""
+
+	switch {
+	case h.Get(headerCloudEventSpecVersion) != "":
+		// Binary content mode. Event attributes are headers, and the body is
+		// the event data.
+		subject = h.Get(headerCloudEventSubject)
+	case isStructuredCloudEvent(h, body):
+		ce := cloudEvent{}
+		if err := json.Unmarshal(body, &ce); err != nil {
+			return ExternalChange{}, errors.Wrap(err, errParseNotification)
+		}
+		data = ce.Data
+		subject = ce.Subject
+	}
+
+	if len(data) > 0 {
+		// CloudEvent data needn't be JSON. We can still use the event if it
+		// has a subject.
+		if err := json.Unmarshal(data, &c); err != nil {
+			if subject == "" {
+				return ExternalChange{}, errors.Wrap(err, errParseNotification)
+			}
+			c = ExternalChange{}
+		}
+	}
+
+	if c.ExternalName == "" {
+		c.ExternalName = subject
+	}
+	if c.ExternalName == "" {
+		return ExternalChange{}, errors.New(errNoExternalName)
+	}
+	return c, nil
+}
+
+func isStructuredCloudEvent(h http.Header, body []byte) bool {
+	if mt, _, err := mime.ParseMediaType(h.Get("Content-Type")); err == nil && mt == contentTypeCloudEvents {
+		return true
+	}
+
+	// Some senders don't set the CloudEvents content type. Sniff for the
+	// specversion attribute, which is required.
+	ce := cloudEvent{}
+	return json.Unmarshal(body, &ce) == nil && ce.SpecVersion != ""
+}
@@ -0,0 +1,335 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestParseExternalChange(t *testing.T) {
+	type args struct {
+		h    http.Header
+		body string
+	}
+	type want struct {
+		c   ExternalChange
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"SimpleJSON": {
+			reason: "A JSON encoded ExternalChange should be parsed.",
+			args: args{
+				h:    http.Header{"Content-Type": []string{"application/json"}},
+				body: `{"externalName":"cool-bucket"}`,
+			},
+			want: want{
+				c: ExternalChange{ExternalName: "cool-bucket"},
+			},
+		},
+		"StructuredCloudEventData": {
+			reason: "A structured CloudEvent whose data is an ExternalChange should be parsed.",
+			args: args{
+				h:    http.Header{"Content-Type": []string{"application/cloudevents+json; charset=utf-8"}},
+				body: `{"specversion":"1.0","type":"audit","source":"cloud","id":"1","data":{"externalName":"cool-bucket"}}`,
+			},
+			want: want{
+				c: ExternalChange{ExternalName: "cool-bucket"},
+			},
+		},
+		"StructuredCloudEventSubject": {
+			reason: "A structured CloudEvent without an external name in its data should use its subject.",
+			args: args{
+				h:    http.Header{},
+				body: `{"specversion":"1.0","type":"audit","source":"cloud","id":"1","subject":"cool-bucket"}`,
+			},
+			want: want{
+				c: ExternalChange{ExternalName: "cool-bucket"},
+			},
+		},
+		"BinaryCloudEvent": {
+			reason: "A binary CloudEvent should use its subject header if its data has no external name.",
+			args: args{
+				h: http.Header{
+					"Ce-Specversion": []string{"1.0"},
+					"Ce-Subject":     []string{"cool-bucket"},
+				},
+				body: `{"somethingElse":"entirely"}`,
+			},
+			want: want{
+				c: ExternalChange{ExternalName: "cool-bucket"},
+			},
+		},
+		"BinaryCloudEventNonJSONData": {
+			reason: "A binary CloudEvent whose data is not JSON should use its subject header.",
+			args: args{
+				h: http.Header{
+					"Ce-Specversion": []string{"1.0"},
+					"Ce-Subject":     []string{"cool-bucket"},
+					"Content-Type":   []string{"application/xml"},
+				},
+				body: `<bucket name="cool-bucket"/>`,
+			},
+			want: want{
+				c: ExternalChange{ExternalName: "cool-bucket"},
+			},
+		},
+		"NoExternalName": {
+			reason: "An error should be returned if no external name can be determined.",
+			args: args{
+				h:    http.Header{},
+				body: `{}`,
+			},
+			want: want{
+				err: errors.New(errNoExternalName),
+			},
+		},
+		"InvalidJSON": {
+			reason: "An error should be returned if the notification is not valid JSON.",
+			args: args{
+				h:    http.Header{},
+				body: `{`,
+			},
+			want: want{
+				err: errors.Wrap(errors.New("unexpected end of JSON input"), errParseNotification),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, err := ParseExternalChange(tc.args.h, []byte(tc.args.body))
+			if diff := cmp.Diff(tc.want.c, c); diff != "" {
+				t.Errorf("\n%s\nParseExternalChange(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nParseExternalChange(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestNotificationSourceServeHTTP(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	withItems := func(names ...string) test.ObjectListFn {
+		return func(l client.ObjectList) error {
+			ul := l.(*unstructured.UnstructuredList)
+			for _, n := range names {
+				u := unstructured.Unstructured{}
+				u.SetName(n)
+				ul.Items = append(ul.Items, u)
+			}
+			return nil
+		}
+	}
+
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		_, _ = mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	type args struct {
+		method string
+		header http.Header
+		body   string
+	}
+	type want struct {
+		code int
+		body string
+		reqs []reconcile.Request
+	}
+
+	cases := map[string]struct {
+		reason string
+		c      client.Reader
+		o      []NotificationSourceOption
+		start  bool
+		args   args
+		want   want
+	}{
+		"MethodNotAllowed": {
+			reason: "Only POST requests should be accepted.",
+			start:  true,
+			args: args{
+				method: http.MethodGet,
+			},
+			want: want{
+				code: http.StatusMethodNotAllowed,
+			},
+		},
+		"NotStarted": {
+			reason: "Notifications should be refused until the source is started.",
+			args: args{
+				method: http.MethodPost,
+				body:   `{"externalName":"cool-bucket"}`,
+			},
+			want: want{
+				code: http.StatusServiceUnavailable,
+			},
+		},
+		"BadRequest": {
+			reason: "Notifications that cannot be parsed should be rejected.",
+			start:  true,
+			args: args{
+				method: http.MethodPost,
+				body:   `{}`,
+			},
+			want: want{
+				code: http.StatusBadRequest,
+			},
+		},
+		"ListError": {
+			reason: "Errors listing resources by external name should be returned.",
+			c:      &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+			start:  true,
+			args: args{
+				method: http.MethodPost,
+				body:   `{"externalName":"cool-bucket"}`,
+			},
+			want: want{
+				code: http.StatusInternalServerError,
+				body: errNotifyFailed + "\n",
+			},
+		},
+		"SharedSecretRejected": {
+			reason: "Notifications without the shared secret should be rejected.",
+			o:      []NotificationSourceOption{WithNotificationVerifier(VerifySharedSecret("X-Secret", []byte("secret")))},
+			start:  true,
+			args: args{
+				method: http.MethodPost,
+				header: http.Header{"X-Secret": []string{"wrong"}},
+				body:   `{"externalName":"cool-bucket"}`,
+			},
+			want: want{
+				code: http.StatusUnauthorized,
+			},
+		},
+		"SignatureRejected": {
+			reason: "Notifications with an invalid HMAC signature should be rejected.",
+			o:      []NotificationSourceOption{WithNotificationVerifier(VerifyHMACSHA256("X-Signature", []byte("secret")))},
+			start:  true,
+			args: args{
+				method: http.MethodPost,
+				header: http.Header{"X-Signature": []string{sign(`{"externalName":"other-bucket"}`)}},
+				body:   `{"externalName":"cool-bucket"}`,
+			},
+			want: want{
+				code: http.StatusUnauthorized,
+			},
+		},
+		"SignatureAccepted": {
+			reason: "Notifications with a valid HMAC signature should be accepted.",
+			c:      &test.MockClient{MockList: test.NewMockListFn(nil, withItems("a"))},
+			o:      []NotificationSourceOption{WithNotificationVerifier(VerifyHMACSHA256("X-Signature", []byte("secret")))},
+			start:  true,
+			args: args{
+				method: http.MethodPost,
+				header: http.Header{"X-Signature": []string{sign(`{"externalName":"cool-bucket"}`)}},
+				body:   `{"externalName":"cool-bucket"}`,
+			},
+			want: want{
+				code: http.StatusAccepted,
+				reqs: []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "a"}}},
+			},
+		},
+		"Enqueued": {
+			reason: "Every resource with the notified external name should be enqueued.",
+			c: &test.MockClient{MockList: func(_ context.Context, l client.ObjectList, opts ...client.ListOption) error {
+				lo := &client.ListOptions{}
+				lo.ApplyOptions(opts)
+				if got := lo.FieldSelector.String(); got != ExternalNameIndex+"=cool-bucket" {
+					return errors.Errorf("unexpected field selector %q", got)
+				}
+				return withItems("a", "b")(l)
+			}},
+			start: true,
+			args: args{
+				method: http.MethodPost,
+				body:   `{"externalName":"cool-bucket"}`,
+			},
+			want: want{
+				code: http.StatusAccepted,
+				reqs: []reconcile.Request{
+					{NamespacedName: types.NamespacedName{Name: "a"}},
+					{NamespacedName: types.NamespacedName{Name: "b"}},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+			defer q.ShutDown()
+
+			s := NewNotificationSource(tc.c, &unstructured.UnstructuredList{}, tc.o...)
+			if tc.start {
+				_ = s.Start(context.Background(), &handler.EnqueueRequestForObject{}, q)
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.args.method, "/", strings.NewReader(tc.args.body))
+			for k, v := range tc.args.header {
+				req.Header[k] = v
+			}
+			s.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tc.want.code, rec.Code); diff != "" {
+				t.Errorf("\n%s\ns.ServeHTTP(...): -want status, +got status:\n%s", tc.reason, diff)
+			}
+			if tc.want.body != "" {
+				if diff := cmp.Diff(tc.want.body, rec.Body.String()); diff != "" {
+					t.Errorf("\n%s\ns.ServeHTTP(...): -want body, +got body:\n%s", tc.reason, diff)
+				}
+			}
+
+			var got []reconcile.Request
+			for q.Len() > 0 {
+				i, _ := q.Get()
+				got = append(got, i.(reconcile.Request))
+				q.Done(i)
+			}
+			if diff := cmp.Diff(tc.want.reqs, got); diff != "" {
+				t.Errorf("\n%s\ns.ServeHTTP(...): -want requests, +got requests:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -22,7 +22,10 @@ import (
 
 	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/feature"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
@@ -56,6 +59,20 @@ type Options struct {
 	// MaxConcurrentReconciles for each controller.
 	MaxConcurrentReconciles int
 
+	// Timeout of each reconcile. Controllers use their own default timeout
+	// if it's zero.
+	Timeout time.Duration
+
+	// ItemRateLimiter rate limits requeues of each item a controller
+	// reconciles. ratelimiter.NewController is used if it's nil.
+	ItemRateLimiter workqueue.RateLimiter
+
+	// NewQueue creates the work queue of controllers created by
+	// NewController. Controllers use controller-runtime's default queue if
+	// it's nil. Use PriorityQueueFn to process changes made by users before
+	// polls.
+	NewQueue NewQueueFn
+
 	// Features that should be enabled.
 	Features *feature.Flags
 
@@ -67,13 +84,38 @@ type Options struct {
 func (o Options) ForControllerRuntime() controller.Options {
 	recoverPanic := true
 
+	rl := o.ItemRateLimiter
+	if rl == nil {
+		rl = ratelimiter.NewController()
+	}
+
 	return controller.Options{
 		MaxConcurrentReconciles: o.MaxConcurrentReconciles,
-		RateLimiter:             ratelimiter.NewController(),
+		RateLimiter:             rl,
 		RecoverPanic:            &recoverPanic,
 	}
 }
 
+// NewController creates a controller that uses the supplied reconciler, and
+// adds it to the supplied manager. The controller uses a queue created by
+// NewQueue if it's set.
+//
+// controller-runtime's builder (i.e. NewControllerManagedBy) can't configure a
+// controller's queue. Controllers that should use NewQueue must be created by
+// NewController, then watch their sources using its Watch method.
+func (o Options) NewController(name string, m manager.Manager, r reconcile.Reconciler) (controller.Controller, error) {
+	co := o.ForControllerRuntime()
+	co.Reconciler = r
+	if o.NewQueue == nil {
+		return controller.New(name, m, co)
+	}
+	c, err := NewQueueController(name, m, co, o.NewQueue)
+	if err != nil {
+		return nil, err
+	}
+	return c, errors.Wrap(m.Add(c), errAddController)
+}
+
 // ESSOptions for External Secret Stores.
 type ESSOptions struct {
 	TLSConfig     *tls.Config
@@ -0,0 +1,238 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
+)
+
+// DefaultOverridesKey is the ConfigMap data key overrides are loaded from by
+// default.
+const DefaultOverridesKey = "overrides.yaml"
+
+// Error strings.
+const (
+	errReadOverrides      = "cannot read options overrides file"
+	errGetOverridesCM     = "cannot get options overrides ConfigMap"
+	errParseOverrides     = "cannot parse options overrides"
+	errFmtNoOverridesKey  = "options overrides ConfigMap has no key %q"
+	errFmtNoKind          = "override %d does not specify a kind"
+	errFmtNonPositive     = "override %d for %s: %s must be positive"
+	errFmtDelayExceedsMax = "override %d for %s: rateLimit.baseDelay %s must not exceed rateLimit.maxDelay %s"
+)
+
+// Overrides of controller Options for particular kinds of resource.
+type Overrides struct {
+	// Overrides for each kind.
+	Overrides []KindOverride `json:"overrides"`
+}
+
+// A KindOverride overrides controller Options for a kind of resource. Fields
+// that aren't set don't override the base Options.
+type KindOverride struct {
+	// Group of the kind. Empty for the core API group.
+	Group string `json:"group,omitempty"`
+
+	// Version of the kind. The override applies to all versions if it's not
+	// set. Overrides for a particular version take precedence over overrides
+	// for all versions.
+	Version string `json:"version,omitempty"`
+
+	// Kind of resource.
+	Kind string `json:"kind"`
+
+	// PollInterval overrides Options.PollInterval.
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+
+	// MaxConcurrentReconciles overrides Options.MaxConcurrentReconciles.
+	MaxConcurrentReconciles *int `json:"maxConcurrentReconciles,omitempty"`
+
+	// Timeout overrides Options.Timeout.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// RateLimit overrides how reconciles of the kind are rate limited.
+	RateLimit *RateLimitOverride `json:"rateLimit,omitempty"`
+
+	// Features enables (true) or disables (false) feature flags.
+	Features map[feature.Flag]bool `json:"features,omitempty"`
+}
+
+// A RateLimitOverride overrides how reconciles of a kind are rate limited.
+type RateLimitOverride struct {
+	// ReconcilesPerSecond limits the average rate of requeues of the kind.
+	// The kind is rate limited separately from other kinds, rather than by
+	// Options.GlobalRateLimiter.
+	ReconcilesPerSecond *int `json:"reconcilesPerSecond,omitempty"`
+
+	// BaseDelay of the per-item exponential backoff.
+	BaseDelay *metav1.Duration `json:"baseDelay,omitempty"`
+
+	// MaxDelay of the per-item exponential backoff.
+	MaxDelay *metav1.Duration `json:"maxDelay,omitempty"`
+}
+
+// ParseOverrides parses YAML or JSON encoded Overrides.
+func ParseOverrides(data []byte) (*Overrides, error) {
+	o := &Overrides{}
+	if err := yaml.UnmarshalStrict(data, o); err != nil {
+		return nil, errors.Wrap(err, errParseOverrides)
+	}
+	return o, errors.Wrap(o.validate(), errParseOverrides)
+}
+
+// LoadOverridesFile loads Overrides from the YAML file at the supplied path.
+func LoadOverridesFile(path string) (*Overrides, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // Reading a file supplied by the operator is intended.
+	if err != nil {
+		return nil, errors.Wrap(err, errReadOverrides)
+	}
+	return ParseOverrides(data)
+}
+
+// LoadOverridesConfigMap loads Overrides from the supplied key of the supplied
+// ConfigMap.
+func LoadOverridesConfigMap(ctx context.Context, c client.Reader, nn types.NamespacedName, key string) (*Overrides, error) {
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, nn, cm); err != nil {
+		return nil, errors.Wrap(err, errGetOverridesCM)
+	}
+	data, ok := cm.Data[key]
+	if !ok {
+		return nil, errors.Errorf(errFmtNoOverridesKey, key)
+	}
+	return ParseOverrides([]byte(data))
+}
+
+func (o *Overrides) validate() error {
+	for i, ko := range o.Overrides {
+		if ko.Kind == "" {
+			return errors.Errorf(errFmtNoKind, i)
+		}
+		gvk := schema.GroupVersionKind{Group: ko.Group, Version: ko.Version, Kind: ko.Kind}
+		if ko.MaxConcurrentReconciles != nil && *ko.MaxConcurrentReconciles < 1 {
+			return errors.Errorf(errFmtNonPositive, i, gvk, "maxConcurrentReconciles")
+		}
+		for field, d := range map[string]*metav1.Duration{"pollInterval": ko.PollInterval, "timeout": ko.Timeout} {
+			if d != nil && d.Duration <= 0 {
+				return errors.Errorf(errFmtNonPositive, i, gvk, field)
+			}
+		}
+		if rl := ko.RateLimit; rl != nil {
+			if rl.ReconcilesPerSecond != nil && *rl.ReconcilesPerSecond < 1 {
+				return errors.Errorf(errFmtNonPositive, i, gvk, "rateLimit.reconcilesPerSecond")
+			}
+			for field, d := range map[string]*metav1.Duration{"rateLimit.baseDelay": rl.BaseDelay, "rateLimit.maxDelay": rl.MaxDelay} {
+				if d != nil && d.Duration <= 0 {
+					return errors.Errorf(errFmtNonPositive, i, gvk, field)
+				}
+			}
+			if base, maxDelay := rl.delays(); base > maxDelay {
+				return errors.Errorf(errFmtDelayExceedsMax, i, gvk, base, maxDelay)
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve returns the effective Options for the supplied kind, by applying
+// any overrides for the kind to the supplied base Options. Overrides for all
+// versions of the kind are applied before overrides for its version. The base
+// Options are not modified.
+//
+// A kind with a reconcilesPerSecond rate limit override gets a new
+// GlobalRateLimiter each time Resolve is called, so Resolve should be called
+// once per controller.
+func (o *Overrides) Resolve(base Options, gvk schema.GroupVersionKind) Options {
+	out := base
+	if o == nil {
+		return out
+	}
+
+	copied := false
+	for _, versioned := range []bool{false, true} {
+		for _, ko := range o.Overrides {
+			if ko.Group != gvk.Group || ko.Kind != gvk.Kind || (ko.Version != "") != versioned {
+				continue
+			}
+			if versioned && ko.Version != gvk.Version {
+				continue
+			}
+			if len(ko.Features) > 0 && !copied {
+				// Don't modify the base Options' flags, which are
+				// typically shared by all controllers.
+				out.Features = base.Features.Copy()
+				copied = true
+			}
+			ko.apply(&out)
+		}
+	}
+	return out
+}
+
+func (ko KindOverride) apply(o *Options) {
+	if ko.PollInterval != nil {
+		o.PollInterval = ko.PollInterval.Duration
+	}
+	if ko.MaxConcurrentReconciles != nil {
+		o.MaxConcurrentReconciles = *ko.MaxConcurrentReconciles
+	}
+	if ko.Timeout != nil {
+		o.Timeout = ko.Timeout.Duration
+	}
+	if rl := ko.RateLimit; rl != nil {
+		if rl.ReconcilesPerSecond != nil {
+			o.GlobalRateLimiter = ratelimiter.NewGlobal(*rl.ReconcilesPerSecond)
+		}
+		if rl.BaseDelay != nil || rl.MaxDelay != nil {
+			o.ItemRateLimiter = workqueue.NewItemExponentialFailureRateLimiter(rl.delays())
+		}
+	}
+	for f, enabled := range ko.Features {
+		if enabled {
+			o.Features.Enable(f)
+			continue
+		}
+		o.Features.Disable(f)
+	}
+}
+
+// delays returns the effective base and max delays of the rate limit override.
+// Delays that aren't overridden use the ratelimiter package's defaults.
+func (rl *RateLimitOverride) delays() (base, maxDelay time.Duration) {
+	base, maxDelay = ratelimiter.DefaultBaseDelay, ratelimiter.DefaultMaxDelay
+	if rl.BaseDelay != nil {
+		base = rl.BaseDelay.Duration
+	}
+	if rl.MaxDelay != nil {
+		maxDelay = rl.MaxDelay.Duration
+	}
+	return base, maxDelay
+}
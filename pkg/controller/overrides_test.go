@@ -0,0 +1,238 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+const overridesYAML = `
+overrides:
+- group: ec2.aws.crossplane.io
+  kind: Instance
+  pollInterval: 10m
+  maxConcurrentReconciles: 2
+  timeout: 5m
+  features:
+    EnableAlphaCool: true
+    EnableBetaLame: false
+- group: ec2.aws.crossplane.io
+  version: v1beta1
+  kind: Instance
+  pollInterval: 20m
+  rateLimit:
+    reconcilesPerSecond: 1
+    baseDelay: 5s
+`
+
+func TestParseOverrides(t *testing.T) {
+	type want struct {
+		o   *Overrides
+		err error
+	}
+	ten := 10
+	zero := 0
+
+	cases := map[string]struct {
+		reason string
+		data   string
+		want   want
+	}{
+		"Valid": {
+			reason: "Valid overrides should be parsed.",
+			data:   "overrides: [{group: iam.aws.crossplane.io, kind: Policy, maxConcurrentReconciles: 10, pollInterval: 1h}]",
+			want: want{o: &Overrides{Overrides: []KindOverride{{
+				Group:                   "iam.aws.crossplane.io",
+				Kind:                    "Policy",
+				MaxConcurrentReconciles: &ten,
+				PollInterval:            &metav1.Duration{Duration: time.Hour},
+			}}}},
+		},
+		"UnknownField": {
+			reason: "Unknown fields should be rejected, rather than silently ignored.",
+			data:   "overrides: [{kind: Policy, interval: 1h}]",
+			want:   want{err: errors.Wrap(errors.New(`error unmarshaling JSON: while decoding JSON: json: unknown field "interval"`), errParseOverrides)},
+		},
+		"NoKind": {
+			reason: "Overrides must specify a kind.",
+			data:   "overrides: [{group: iam.aws.crossplane.io}]",
+			want: want{
+				o:   &Overrides{Overrides: []KindOverride{{Group: "iam.aws.crossplane.io"}}},
+				err: errors.Wrap(errors.Errorf(errFmtNoKind, 0), errParseOverrides),
+			},
+		},
+		"NonPositive": {
+			reason: "Overrides must not specify non-positive concurrency.",
+			data:   "overrides: [{kind: Policy, maxConcurrentReconciles: 0}]",
+			want: want{
+				o:   &Overrides{Overrides: []KindOverride{{Kind: "Policy", MaxConcurrentReconciles: &zero}}},
+				err: errors.Wrap(errors.Errorf(errFmtNonPositive, 0, schema.GroupVersionKind{Kind: "Policy"}, "maxConcurrentReconciles"), errParseOverrides),
+			},
+		},
+		"BaseDelayExceedsMax": {
+			reason: "Overrides must not specify a base delay larger than the max delay.",
+			data:   "overrides: [{kind: Policy, rateLimit: {baseDelay: 1m, maxDelay: 10s}}]",
+			want: want{
+				o: &Overrides{Overrides: []KindOverride{{Kind: "Policy", RateLimit: &RateLimitOverride{
+					BaseDelay: &metav1.Duration{Duration: time.Minute},
+					MaxDelay:  &metav1.Duration{Duration: 10 * time.Second},
+				}}}},
+				err: errors.Wrap(errors.Errorf(errFmtDelayExceedsMax, 0, schema.GroupVersionKind{Kind: "Policy"}, time.Minute, 10*time.Second), errParseOverrides),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o, err := ParseOverrides([]byte(tc.data))
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nParseOverrides(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.o, o); diff != "" {
+				t.Errorf("\n%s\nParseOverrides(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestOverridesResolve(t *testing.T) {
+	o, err := ParseOverrides([]byte(overridesYAML))
+	if err != nil {
+		t.Fatalf("ParseOverrides(...): %s", err)
+	}
+
+	base := DefaultOptions()
+	base.Features.Enable("EnableBetaLame")
+
+	type want struct {
+		pollInterval time.Duration
+		concurrency  int
+		timeout      time.Duration
+		rateLimited  bool
+		cool         bool
+		lame         bool
+	}
+	cases := map[string]struct {
+		reason string
+		gvk    schema.GroupVersionKind
+		want   want
+	}{
+		"NoOverrides": {
+			reason: "Kinds without overrides should use the base options.",
+			gvk:    schema.GroupVersionKind{Group: "iam.aws.crossplane.io", Version: "v1beta1", Kind: "Policy"},
+			want:   want{pollInterval: base.PollInterval, concurrency: base.MaxConcurrentReconciles, lame: true},
+		},
+		"AllVersions": {
+			reason: "Overrides for all versions of a kind should apply to any version.",
+			gvk:    schema.GroupVersionKind{Group: "ec2.aws.crossplane.io", Version: "v1alpha1", Kind: "Instance"},
+			want:   want{pollInterval: 10 * time.Minute, concurrency: 2, timeout: 5 * time.Minute, cool: true},
+		},
+		"Version": {
+			reason: "Overrides for a version of a kind should take precedence over overrides for all versions.",
+			gvk:    schema.GroupVersionKind{Group: "ec2.aws.crossplane.io", Version: "v1beta1", Kind: "Instance"},
+			want:   want{pollInterval: 20 * time.Minute, concurrency: 2, timeout: 5 * time.Minute, rateLimited: true, cool: true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := o.Resolve(base, tc.gvk)
+			w := want{
+				pollInterval: got.PollInterval,
+				concurrency:  got.MaxConcurrentReconciles,
+				timeout:      got.Timeout,
+				rateLimited:  got.ItemRateLimiter != nil && got.GlobalRateLimiter != base.GlobalRateLimiter,
+				cool:         got.Features.Enabled("EnableAlphaCool"),
+				lame:         got.Features.Enabled("EnableBetaLame"),
+			}
+			if diff := cmp.Diff(tc.want, w, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\no.Resolve(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+
+	if !base.Features.Enabled("EnableBetaLame") || base.Features.Enabled("EnableAlphaCool") {
+		t.Errorf("o.Resolve(...): The base options' feature flags should not be modified")
+	}
+	if got := o.Resolve(base, schema.GroupVersionKind{Group: "ec2.aws.crossplane.io", Version: "v1beta1", Kind: "Instance"}); got.ItemRateLimiter.When("a") != 5*time.Second {
+		t.Errorf("o.Resolve(...): want per-item backoff to start at the overridden base delay")
+	}
+	if (*Overrides)(nil).Resolve(base, schema.GroupVersionKind{}).PollInterval != base.PollInterval {
+		t.Errorf("Resolving nil overrides should return the base options")
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	errBoom := errors.New("boom")
+	nn := types.NamespacedName{Namespace: "crossplane-system", Name: "overrides"}
+
+	t.Run("File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "overrides.yaml")
+		if err := os.WriteFile(path, []byte(overridesYAML), 0o600); err != nil {
+			t.Fatalf("os.WriteFile(...): %s", err)
+		}
+		o, err := LoadOverridesFile(path)
+		if err != nil {
+			t.Fatalf("LoadOverridesFile(...): %s", err)
+		}
+		if diff := cmp.Diff(2, len(o.Overrides)); diff != "" {
+			t.Errorf("LoadOverridesFile(...): -want overrides, +got overrides:\n%s", diff)
+		}
+	})
+
+	t.Run("ConfigMap", func(t *testing.T) {
+		c := &test.MockClient{MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+			obj.(*corev1.ConfigMap).Data = map[string]string{DefaultOverridesKey: overridesYAML}
+			return nil
+		})}
+		o, err := LoadOverridesConfigMap(context.Background(), c, nn, DefaultOverridesKey)
+		if err != nil {
+			t.Fatalf("LoadOverridesConfigMap(...): %s", err)
+		}
+		if diff := cmp.Diff(2, len(o.Overrides)); diff != "" {
+			t.Errorf("LoadOverridesConfigMap(...): -want overrides, +got overrides:\n%s", diff)
+		}
+	})
+
+	t.Run("ConfigMapMissingKey", func(t *testing.T) {
+		c := &test.MockClient{MockGet: test.NewMockGetFn(nil)}
+		_, err := LoadOverridesConfigMap(context.Background(), c, nn, DefaultOverridesKey)
+		if diff := cmp.Diff(errors.Errorf(errFmtNoOverridesKey, DefaultOverridesKey), err, test.EquateErrors()); diff != "" {
+			t.Errorf("LoadOverridesConfigMap(...): -want error, +got error:\n%s", diff)
+		}
+	})
+
+	t.Run("ConfigMapGetError", func(t *testing.T) {
+		c := &test.MockClient{MockGet: test.NewMockGetFn(errBoom)}
+		_, err := LoadOverridesConfigMap(context.Background(), c, nn, DefaultOverridesKey)
+		if diff := cmp.Diff(errors.Wrap(errBoom, errGetOverridesCM), err, test.EquateErrors()); diff != "" {
+			t.Errorf("LoadOverridesConfigMap(...): -want error, +got error:\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,430 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+// A Priority determines the order in which a PriorityQueue returns items.
+type Priority int
+
+// Priorities, from lowest to highest.
+const (
+	// PriorityLow items are typically polls and retries.
+	PriorityLow Priority = iota
+
+	// PriorityNormal items are added using Add.
+	PriorityNormal
+
+	// PriorityHigh items are typically changes made by users.
+	PriorityHigh
+
+	numPriorities = int(PriorityHigh) + 1
+)
+
+// DefaultStarvationLimit is the default number of higher priority items a
+// PriorityQueue returns in a row while a lower priority item waits.
+const DefaultStarvationLimit = 10
+
+// A PriorityAdder can add items to a queue with a priority.
+type PriorityAdder interface {
+	AddWithPriority(item any, p Priority)
+}
+
+// A PriorityQueue is a rate limited work queue that returns higher priority
+// items before lower priority items. Items of the same priority are returned
+// in the order they were added. Like other work queues an item is never queued
+// more than once. An item that is added with a higher priority than it was
+// queued with is promoted to the higher priority.
+//
+// Items added using Add have normal priority. Items added using AddAfter and
+// AddRateLimited have low priority, so that polls and retries don't delay
+// changes. AddRateLimited still respects the supplied rate limiter, so that
+// per-item backoff and global rate limiting keep working.
+//
+// Lower priority items age while they wait, so that a steady stream of higher
+// priority items can't starve them. Once a priority has been passed over for
+// its starvation limit of items in a row its oldest item is returned next.
+type PriorityQueue struct {
+	limiter workqueue.RateLimiter
+	limit   int
+
+	mx   sync.Mutex
+	cond *sync.Cond
+
+	// FIFO queues, by priority. A queue may contain stale entries for items
+	// that were promoted to a higher priority, or that were already returned.
+	queues [numPriorities][]any
+
+	// Items waiting to be returned, and their priority.
+	queued map[any]Priority
+
+	// The number of items waiting to be returned, and the number of times in
+	// a row the oldest of them was passed over, by priority.
+	counts  [numPriorities]int
+	skipped [numPriorities]int
+
+	// Items being processed, and items added while they were processed.
+	processing map[any]bool
+	dirty      map[any]Priority
+
+	// Items that will be added when their delay elapses.
+	waiting map[any]*delayed
+
+	shuttingDown bool
+}
+
+type delayed struct {
+	timer *time.Timer
+	at    time.Time
+	p     Priority
+}
+
+var (
+	_ workqueue.RateLimitingInterface = &PriorityQueue{}
+	_ PriorityAdder                   = &PriorityQueue{}
+)
+
+// A PriorityQueueOption configures a PriorityQueue.
+type PriorityQueueOption func(q *PriorityQueue)
+
+// WithStarvationLimit configures the number of higher priority items a
+// PriorityQueue returns in a row while a lower priority item waits. Limits
+// less than one disable aging, so that higher priority items are always
+// returned first.
+func WithStarvationLimit(n int) PriorityQueueOption {
+	return func(q *PriorityQueue) {
+		q.limit = n
+	}
+}
+
+// NewPriorityQueue returns a PriorityQueue that rate limits items using the
+// supplied rate limiter.
+func NewPriorityQueue(rl workqueue.RateLimiter, o ...PriorityQueueOption) *PriorityQueue {
+	q := &PriorityQueue{
+		limiter:    rl,
+		limit:      DefaultStarvationLimit,
+		queued:     make(map[any]Priority),
+		processing: make(map[any]bool),
+		dirty:      make(map[any]Priority),
+		waiting:    make(map[any]*delayed),
+	}
+	q.cond = sync.NewCond(&q.mx)
+	for _, fn := range o {
+		fn(q)
+	}
+	return q
+}
+
+// Add the supplied item with normal priority.
+func (q *PriorityQueue) Add(item any) {
+	q.AddWithPriority(item, PriorityNormal)
+}
+
+// AddWithPriority adds the supplied item with the supplied priority.
+func (q *PriorityQueue) AddWithPriority(item any, p Priority) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.add(item, p)
+}
+
+// add must be called with the lock held.
+func (q *PriorityQueue) add(item any, p Priority) {
+	if q.shuttingDown {
+		return
+	}
+
+	// Adding an item makes any delayed add of it redundant.
+	if d, ok := q.waiting[item]; ok && d.p <= p {
+		d.timer.Stop()
+		delete(q.waiting, item)
+	}
+
+	// The item will be queued again when it's done.
+	if q.processing[item] {
+		if cur, ok := q.dirty[item]; !ok || p > cur {
+			q.dirty[item] = p
+		}
+		return
+	}
+
+	cur, ok := q.queued[item]
+	if ok && cur >= p {
+		return
+	}
+	if ok {
+		q.counts[cur]--
+	}
+	q.counts[p]++
+	q.queued[item] = p
+	q.queues[p] = append(q.queues[p], item)
+	q.cond.Signal()
+}
+
+// Len returns the number of items waiting to be returned.
+func (q *PriorityQueue) Len() int {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return len(q.queued)
+}
+
+// Get blocks until it can return the highest priority item, or a lower
+// priority item that has reached its starvation limit. The item must be marked
+// done when it has been processed. Get returns shutdown true when the queue is
+// shutting down.
+func (q *PriorityQueue) Get() (any, bool) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	for len(q.queued) == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if len(q.queued) == 0 {
+		return nil, true
+	}
+
+	p := q.next()
+	for len(q.queues[p]) > 0 {
+		item := q.queues[p][0]
+		q.queues[p][0] = nil
+		q.queues[p] = q.queues[p][1:]
+
+		// Skip stale entries.
+		if cur, ok := q.queued[item]; !ok || cur != p {
+			continue
+		}
+		delete(q.queued, item)
+		q.counts[p]--
+		q.processing[item] = true
+		return item, false
+	}
+
+	// Unreachable as long as every queued item has an entry in its queue.
+	return nil, true
+}
+
+// next returns the priority of the next item to return. It's the highest
+// priority with a waiting item, unless a lower priority has reached its
+// starvation limit. next must be called with the lock held, and only when
+// there are items waiting.
+func (q *PriorityQueue) next() Priority {
+	next := Priority(-1)
+	for p := numPriorities - 1; p >= 0; p-- {
+		if q.counts[p] == 0 {
+			// An item only ages while it waits.
+			q.skipped[p] = 0
+			continue
+		}
+		if next < 0 || (q.limit > 0 && q.skipped[p] >= q.limit) {
+			next = Priority(p)
+		}
+	}
+
+	// The lower priorities that have waiting items are passed over.
+	q.skipped[next] = 0
+	for p := 0; p < int(next); p++ {
+		if q.counts[p] > 0 {
+			q.skipped[p]++
+		}
+	}
+	return next
+}
+
+// Done marks the supplied item as processed. It's queued again if it was added
+// while it was being processed.
+func (q *PriorityQueue) Done(item any) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	delete(q.processing, item)
+	if p, ok := q.dirty[item]; ok {
+		delete(q.dirty, item)
+		q.add(item, p)
+	}
+	if len(q.processing) == 0 {
+		// Wake ShutDownWithDrain.
+		q.cond.Broadcast()
+	}
+}
+
+// ShutDown the queue. Items that are added afterwards are ignored, and Get
+// returns shutdown true once the queue is empty.
+func (q *PriorityQueue) ShutDown() {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	q.shuttingDown = true
+	for item, d := range q.waiting {
+		d.timer.Stop()
+		delete(q.waiting, item)
+	}
+	q.cond.Broadcast()
+}
+
+// ShutDownWithDrain shuts down the queue, and blocks until every item that is
+// being processed is done.
+func (q *PriorityQueue) ShutDownWithDrain() {
+	q.ShutDown()
+
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	for len(q.processing) > 0 {
+		q.cond.Wait()
+	}
+}
+
+// ShuttingDown returns true if the queue is shutting down.
+func (q *PriorityQueue) ShuttingDown() bool {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return q.shuttingDown
+}
+
+// AddAfter adds the supplied item with low priority once the supplied delay
+// has elapsed.
+func (q *PriorityQueue) AddAfter(item any, d time.Duration) {
+	q.addAfter(item, d, PriorityLow)
+}
+
+// AddRateLimited adds the supplied item with low priority once the rate
+// limiter says it's ok.
+func (q *PriorityQueue) AddRateLimited(item any) {
+	q.addAfter(item, q.limiter.When(item), PriorityLow)
+}
+
+func (q *PriorityQueue) addAfter(item any, d time.Duration, p Priority) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	if q.shuttingDown {
+		return
+	}
+	if d <= 0 {
+		q.add(item, p)
+		return
+	}
+
+	// Keep the earliest delayed add of an item, like a delaying queue.
+	at := time.Now().Add(d)
+	if cur, ok := q.waiting[item]; ok {
+		if !at.Before(cur.at) {
+			return
+		}
+		cur.timer.Stop()
+	}
+
+	dl := &delayed{at: at, p: p}
+	dl.timer = time.AfterFunc(d, func() {
+		q.mx.Lock()
+		defer q.mx.Unlock()
+		if q.waiting[item] != dl {
+			return
+		}
+		delete(q.waiting, item)
+		q.add(item, p)
+	})
+	q.waiting[item] = dl
+}
+
+// Forget the supplied item's rate limiting history.
+func (q *PriorityQueue) Forget(item any) {
+	q.limiter.Forget(item)
+}
+
+// NumRequeues returns how many times the supplied item was rate limited.
+func (q *PriorityQueue) NumRequeues(item any) int {
+	return q.limiter.NumRequeues(item)
+}
+
+// PriorityQueueFn is a NewQueueFn that returns a PriorityQueue. Use it with
+// NewQueueController, or with Options.NewQueue.
+func PriorityQueueFn(rl workqueue.RateLimiter) workqueue.RateLimitingInterface {
+	return NewPriorityQueue(rl)
+}
+
+// PriorityHandler wraps the supplied EventHandler such that it adds requests to
+// a PriorityQueue with a priority that depends on the event:
+//
+//   - Deletes, and updates that change an object's generation or deletion
+//     timestamp (i.e. changes made by users) have high priority.
+//   - Updates that don't change an object's generation (e.g. status updates),
+//     and creates of objects that were created before the handler was (i.e.
+//     the initial list of objects when a controller starts) have low priority.
+//   - All other events have normal priority.
+//
+// Requests are added normally if the queue is not a PriorityAdder.
+func PriorityHandler(h handler.EventHandler) handler.EventHandler {
+	started := time.Now()
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.RateLimitingInterface) {
+			p := PriorityNormal
+			if e.Object != nil && e.Object.GetCreationTimestamp().Time.Before(started) {
+				p = PriorityLow
+			}
+			h.Create(ctx, e, withPriority(q, p))
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			h.Update(ctx, e, withPriority(q, updatePriority(e.ObjectOld, e.ObjectNew)))
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			h.Delete(ctx, e, withPriority(q, PriorityHigh))
+		},
+		GenericFunc: func(ctx context.Context, e event.GenericEvent, q workqueue.RateLimitingInterface) {
+			h.Generic(ctx, e, q)
+		},
+	}
+}
+
+func updatePriority(oldObj, newObj client.Object) Priority {
+	if oldObj == nil || newObj == nil {
+		return PriorityNormal
+	}
+	if oldObj.GetGeneration() != newObj.GetGeneration() {
+		return PriorityHigh
+	}
+	if !oldObj.GetDeletionTimestamp().Equal(newObj.GetDeletionTimestamp()) {
+		return PriorityHigh
+	}
+	return PriorityLow
+}
+
+// A priorityQueue adds items to a PriorityAdder with a fixed priority.
+type priorityQueue struct {
+	workqueue.RateLimitingInterface
+	adder PriorityAdder
+	p     Priority
+}
+
+func withPriority(q workqueue.RateLimitingInterface, p Priority) workqueue.RateLimitingInterface {
+	pa, ok := q.(PriorityAdder)
+	if !ok {
+		return q
+	}
+	return &priorityQueue{RateLimitingInterface: q, adder: pa, p: p}
+}
+
+func (q *priorityQueue) Add(item any) {
+	q.adder.AddWithPriority(item, q.p)
+}
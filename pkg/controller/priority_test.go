@@ -0,0 +1,215 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func drain(q workqueue.Interface) []any {
+	out := make([]any, 0, q.Len())
+	for q.Len() > 0 {
+		item, _ := q.Get()
+		q.Done(item)
+		out = append(out, item)
+	}
+	return out
+}
+
+func TestPriorityQueue(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		o      []PriorityQueueOption
+		add    func(q *PriorityQueue)
+		want   []any
+	}{
+		"Priority": {
+			reason: "Higher priority items should be returned first.",
+			add: func(q *PriorityQueue) {
+				q.AddAfter("poll", 0)
+				q.Add("normal")
+				q.AddWithPriority("change", PriorityHigh)
+			},
+			want: []any{"change", "normal", "poll"},
+		},
+		"FIFO": {
+			reason: "Items of the same priority should be returned in the order they were added.",
+			add: func(q *PriorityQueue) {
+				q.Add("a")
+				q.Add("b")
+				q.Add("c")
+			},
+			want: []any{"a", "b", "c"},
+		},
+		"Promote": {
+			reason: "An item added with a higher priority than it was queued with should be promoted, not queued twice.",
+			add: func(q *PriorityQueue) {
+				q.AddAfter("a", 0)
+				q.Add("b")
+				q.AddWithPriority("a", PriorityHigh)
+			},
+			want: []any{"a", "b"},
+		},
+		"NoDemote": {
+			reason: "An item added with a lower priority than it was queued with should keep its priority.",
+			add: func(q *PriorityQueue) {
+				q.Add("b")
+				q.AddWithPriority("a", PriorityHigh)
+				q.AddAfter("a", 0)
+			},
+			want: []any{"a", "b"},
+		},
+		"StarvationLimit": {
+			reason: "A lower priority item should be returned once it has been passed over for its starvation limit of items.",
+			o:      []PriorityQueueOption{WithStarvationLimit(2)},
+			add: func(q *PriorityQueue) {
+				q.AddAfter("poll", 0)
+				for _, item := range []string{"a", "b", "c", "d"} {
+					q.AddWithPriority(item, PriorityHigh)
+				}
+			},
+			want: []any{"a", "b", "poll", "c", "d"},
+		},
+		"StarvationLimitEachPriority": {
+			reason: "Each lower priority should age independently.",
+			o:      []PriorityQueueOption{WithStarvationLimit(2)},
+			add: func(q *PriorityQueue) {
+				q.AddAfter("poll", 0)
+				q.Add("normal")
+				for _, item := range []string{"a", "b", "c", "d"} {
+					q.AddWithPriority(item, PriorityHigh)
+				}
+			},
+			want: []any{"a", "b", "poll", "normal", "c", "d"},
+		},
+		"NoStarvationLimit": {
+			reason: "Higher priority items should always be returned first if aging is disabled.",
+			o:      []PriorityQueueOption{WithStarvationLimit(0)},
+			add: func(q *PriorityQueue) {
+				q.AddAfter("poll", 0)
+				for _, item := range []string{"a", "b", "c", "d"} {
+					q.AddWithPriority(item, PriorityHigh)
+				}
+			},
+			want: []any{"a", "b", "c", "d", "poll"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			q := NewPriorityQueue(workqueue.DefaultControllerRateLimiter(), tc.o...)
+			tc.add(q)
+			if diff := cmp.Diff(tc.want, drain(q)); diff != "" {
+				t.Errorf("\n%s\nGet(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPriorityQueueProcessing(t *testing.T) {
+	q := NewPriorityQueue(workqueue.DefaultControllerRateLimiter())
+
+	q.Add("a")
+	item, _ := q.Get()
+
+	// Adding an item while it's being processed should queue it again once
+	// it's done.
+	q.AddWithPriority("a", PriorityHigh)
+	if diff := cmp.Diff(0, q.Len()); diff != "" {
+		t.Errorf("Len(): -want, +got:\n%s", diff)
+	}
+	q.Done(item)
+	if diff := cmp.Diff(1, q.Len()); diff != "" {
+		t.Errorf("Len(): -want, +got:\n%s", diff)
+	}
+
+	// Delayed items should be added once their delay elapses.
+	q.AddAfter("b", 50*time.Millisecond)
+	q.AddRateLimited("c")
+	waitFor(t, func() bool { return q.Len() == 3 })
+	if diff := cmp.Diff(1, q.NumRequeues("c")); diff != "" {
+		t.Errorf("NumRequeues(): -want, +got:\n%s", diff)
+	}
+
+	q.ShutDown()
+	q.Add("d")
+	if diff := cmp.Diff([]any{"a", "c", "b"}, drain(q)); diff != "" {
+		t.Errorf("Items added after shutdown should be ignored: -want, +got:\n%s", diff)
+	}
+	if _, shutdown := q.Get(); !shutdown {
+		t.Errorf("Get(): want shutdown")
+	}
+}
+
+func TestPriorityQueueStarvation(t *testing.T) {
+	q := NewPriorityQueue(workqueue.DefaultControllerRateLimiter(), WithStarvationLimit(3))
+	q.AddAfter("poll", 0)
+
+	// A steady stream of changes shouldn't starve the poll.
+	for i := 0; i < 10; i++ {
+		q.AddWithPriority(i, PriorityHigh)
+		item, _ := q.Get()
+		q.Done(item)
+		if item == "poll" {
+			if diff := cmp.Diff(3, i); diff != "" {
+				t.Errorf("Get(): -want changes returned before the poll, +got:\n%s", diff)
+			}
+			return
+		}
+	}
+	t.Errorf("Get(): want the poll to be returned")
+}
+
+func TestPriorityHandler(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	cm := func(name string, gen int64, created time.Time) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              name,
+			Generation:        gen,
+			CreationTimestamp: metav1.NewTime(created),
+		}}
+	}
+	req := func(name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: name}}
+	}
+
+	q := NewPriorityQueue(workqueue.DefaultControllerRateLimiter())
+	h := PriorityHandler(&handler.EnqueueRequestForObject{})
+	ctx := context.Background()
+
+	h.Create(ctx, event.CreateEvent{Object: cm("existing", 1, old)}, q)
+	h.Update(ctx, event.UpdateEvent{ObjectOld: cm("status", 1, old), ObjectNew: cm("status", 1, old)}, q)
+	h.Create(ctx, event.CreateEvent{Object: cm("created", 1, time.Now().Add(time.Hour))}, q)
+	h.Update(ctx, event.UpdateEvent{ObjectOld: cm("spec", 1, old), ObjectNew: cm("spec", 2, old)}, q)
+	h.Delete(ctx, event.DeleteEvent{Object: cm("deleted", 1, old)}, q)
+
+	want := []any{req("spec"), req("deleted"), req("created"), req("existing"), req("status")}
+	if diff := cmp.Diff(want, drain(q)); diff != "" {
+		t.Errorf("Changes made by users should be processed first: -want, +got:\n%s", diff)
+	}
+}
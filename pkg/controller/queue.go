@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Error strings.
+const (
+	errNoQueue       = "controller must have a queue constructor"
+	errAddController = "cannot add controller to manager"
+	errFeedQueue     = "cannot feed controller queue"
+)
+
+// A NewQueueFn returns a work queue that rate limits items using the supplied
+// rate limiter.
+type NewQueueFn func(rl workqueue.RateLimiter) workqueue.RateLimitingInterface
+
+// NewQueueControllerFn returns a NewControllerFn that creates controllers
+// using NewQueueController. Pass it to WithNewControllerFn to configure the
+// queue of the controllers an Engine starts.
+func NewQueueControllerFn(fn NewQueueFn) NewControllerFn {
+	return func(name string, m manager.Manager, o controller.Options) (controller.Controller, error) {
+		return NewQueueController(name, m, o, fn)
+	}
+}
+
+// NewQueueController returns a new, unmanaged controller that processes
+// requests in the order they're returned by a queue made by the supplied
+// function. The controller is built by controller-runtime's
+// controller.NewUnmanaged, so it supports the same options and emits the same
+// metrics.
+//
+// controller-runtime's controller.Options can't configure a controller's
+// queue. Instead the controller's sources add requests to the supplied queue,
+// which passes each request to the controller-runtime controller's queue once
+// one of its workers is free. Requests that must be requeued are requeued
+// using the supplied queue, so errors are reported to controller-runtime as
+// terminal errors.
+func NewQueueController(name string, m manager.Manager, o controller.Options, fn NewQueueFn) (controller.Controller, error) {
+	if fn == nil {
+		return nil, errors.New(errNoQueue)
+	}
+
+	rl := o.RateLimiter
+	if rl == nil {
+		rl = workqueue.DefaultControllerRateLimiter()
+	}
+
+	// Default the number of workers like controller.NewUnmanaged does, so
+	// that we know how many requests it can process at once.
+	if o.MaxConcurrentReconciles <= 0 {
+		o.MaxConcurrentReconciles = m.GetControllerOptions().MaxConcurrentReconciles
+	}
+	if o.MaxConcurrentReconciles <= 0 {
+		o.MaxConcurrentReconciles = 1
+	}
+
+	c := &queueController{
+		queue:    fn(rl),
+		slots:    make(chan struct{}, o.MaxConcurrentReconciles),
+		inflight: make(map[any]bool),
+	}
+
+	// Let controller.NewUnmanaged return an error if there's no reconciler.
+	if o.Reconciler != nil {
+		c.do = o.Reconciler
+		o.Reconciler = reconcile.Func(c.reconcile)
+	}
+
+	// Our queue rate limits requeues. The controller-runtime controller only
+	// requeues requests when a reconcile panics.
+	o.RateLimiter = workqueue.DefaultItemBasedRateLimiter()
+
+	ctrl, err := controller.NewUnmanaged(name, m, o)
+	if err != nil {
+		return nil, err
+	}
+	c.Controller = ctrl
+
+	// Sources are started in the order they're watched, so this source
+	// starts feeding the controller's queue before any others add to ours.
+	if err := ctrl.Watch(source.Func(c.feed), nil); err != nil {
+		return nil, errors.Wrap(err, errFeedQueue)
+	}
+	return c, nil
+}
+
+// A queueController is a controller-runtime controller whose requests are
+// ordered by a different queue.
+type queueController struct {
+	controller.Controller
+
+	do    reconcile.Reconciler
+	queue workqueue.RateLimitingInterface
+
+	// slots has an entry for each request being passed to or processed by
+	// the controller-runtime controller.
+	slots chan struct{}
+
+	mx       sync.Mutex
+	inflight map[any]bool
+}
+
+// Watch the supplied source. The source adds requests to the controller's
+// queue.
+func (c *queueController) Watch(src source.Source, h handler.EventHandler, ps ...predicate.Predicate) error {
+	qs := queuedSource{Source: src, queue: c.queue}
+	if ss, ok := src.(source.SyncingSource); ok {
+		return c.Controller.Watch(queuedSyncingSource{queuedSource: qs, sync: ss}, h, ps...)
+	}
+	return c.Controller.Watch(qs, h, ps...)
+}
+
+// NeedLeaderElection returns true if the controller should only run when its
+// manager is elected leader.
+func (c *queueController) NeedLeaderElection() bool {
+	if le, ok := c.Controller.(manager.LeaderElectionRunnable); ok {
+		return le.NeedLeaderElection()
+	}
+	return true
+}
+
+// feed passes requests from our queue to the supplied controller-runtime
+// controller's queue, one for each free worker, until the supplied context is
+// done.
+func (c *queueController) feed(ctx context.Context, _ handler.EventHandler, q workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+	go func() {
+		<-ctx.Done()
+		c.queue.ShutDown()
+	}()
+	go func() {
+		for {
+			select {
+			case c.slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			item, shutdown := c.queue.Get()
+			if shutdown {
+				return
+			}
+			if _, ok := item.(reconcile.Request); !ok {
+				// The controller-runtime controller would drop this
+				// item without reconciling it.
+				c.queue.Forget(item)
+				c.queue.Done(item)
+				<-c.slots
+				continue
+			}
+			c.mx.Lock()
+			c.inflight[item] = true
+			c.mx.Unlock()
+			q.Add(item)
+		}
+	}()
+	return nil
+}
+
+// reconcile the supplied request, requeueing it using our queue if necessary.
+func (c *queueController) reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	defer c.done(req)
+
+	result, err := c.do.Reconcile(ctx, req)
+	switch {
+	case err != nil:
+		if !errors.Is(err, reconcile.TerminalError(nil)) {
+			c.queue.AddRateLimited(req)
+		}
+		// Stop the controller-runtime controller requeueing the request.
+		return reconcile.Result{}, reconcile.TerminalError(err)
+	case result.RequeueAfter > 0:
+		c.queue.Forget(req)
+		c.queue.AddAfter(req, result.RequeueAfter)
+		return reconcile.Result{}, nil
+	case result.Requeue:
+		c.queue.AddRateLimited(req)
+		return reconcile.Result{}, nil
+	}
+	c.queue.Forget(req)
+	return result, nil
+}
+
+// done marks the supplied request as processed, freeing its worker. Requests
+// the controller-runtime controller requeued itself weren't passed to it by
+// feed, so they don't occupy a slot.
+func (c *queueController) done(req reconcile.Request) {
+	c.mx.Lock()
+	ok := c.inflight[req]
+	delete(c.inflight, req)
+	c.mx.Unlock()
+	if !ok {
+		return
+	}
+	c.queue.Done(req)
+	<-c.slots
+}
+
+// A queuedSource adds requests to a different queue than the one it's started
+// with.
+type queuedSource struct {
+	source.Source
+	queue workqueue.RateLimitingInterface
+}
+
+func (s queuedSource) Start(ctx context.Context, h handler.EventHandler, _ workqueue.RateLimitingInterface, ps ...predicate.Predicate) error {
+	return s.Source.Start(ctx, h, s.queue, ps...)
+}
+
+func (s queuedSource) String() string {
+	return fmt.Sprintf("%s", s.Source)
+}
+
+// A queuedSyncingSource is a queuedSource whose source can sync.
+type queuedSyncingSource struct {
+	queuedSource
+	sync source.SyncingSource
+}
+
+func (s queuedSyncingSource) WaitForSync(ctx context.Context) error {
+	return s.sync.WaitForSync(ctx)
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestNewQueueController(t *testing.T) {
+	r := reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) { return reconcile.Result{}, nil })
+
+	type args struct {
+		name string
+		o    controller.Options
+		fn   NewQueueFn
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   error
+	}{
+		"NoReconciler": {
+			reason: "We should return controller-runtime's error if the controller has no reconciler.",
+			args: args{
+				name: "cool",
+				fn:   PriorityQueueFn,
+			},
+			want: errors.New("must specify Reconciler"),
+		},
+		"NoQueue": {
+			reason: "We should return an error if the controller has no queue constructor.",
+			args: args{
+				name: "cool",
+				o:    controller.Options{Reconciler: r},
+			},
+			want: errors.New(errNoQueue),
+		},
+		"Success": {
+			reason: "We should return a controller if it's configured correctly.",
+			args: args{
+				name: "cool",
+				o:    controller.Options{Reconciler: r},
+				fn:   PriorityQueueFn,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewQueueController(tc.args.name, &fake.Manager{Logger: logr.Discard()}, tc.args.o, tc.args.fn)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nNewQueueController(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestQueueController(t *testing.T) {
+	a := reconcile.Request{NamespacedName: types.NamespacedName{Name: "a"}}
+	b := reconcile.Request{NamespacedName: types.NamespacedName{Name: "b"}}
+
+	errBoom := errors.New("boom")
+
+	reconciled := make(chan reconcile.Request, 10)
+	failed := false
+	r := reconcile.Func(func(_ context.Context, req reconcile.Request) (reconcile.Result, error) {
+		reconciled <- req
+		if req == a && !failed {
+			failed = true
+			return reconcile.Result{}, errBoom
+		}
+		return reconcile.Result{}, nil
+	})
+
+	var made workqueue.RateLimitingInterface
+	fn := func(rl workqueue.RateLimiter) workqueue.RateLimitingInterface {
+		made = PriorityQueueFn(rl)
+		return made
+	}
+
+	o := controller.Options{
+		Reconciler: r,
+
+		// Retry immediately.
+		RateLimiter: workqueue.NewItemExponentialFailureRateLimiter(0, 0),
+	}
+	c, err := NewQueueController("cool", &fake.Manager{Logger: logr.Discard()}, o, fn)
+	if err != nil {
+		t.Fatalf("NewQueueController(...): %s", err)
+	}
+
+	add := func(req reconcile.Request) source.Source {
+		return source.Func(func(_ context.Context, _ handler.EventHandler, q workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+			if q != made {
+				t.Errorf("Watch(...): want source to be started with the queue made by the NewQueueFn")
+			}
+			q.Add(req)
+			return nil
+		})
+	}
+
+	// This source should be started when the controller starts.
+	if err := c.Watch(add(a), nil); err != nil {
+		t.Fatalf("Watch(...): %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Start(ctx) }()
+
+	got := make([]reconcile.Request, 0, 3)
+	next := func() {
+		select {
+		case req := <-reconciled:
+			got = append(got, req)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Start(...): timed out waiting for a reconcile")
+		}
+	}
+
+	// The request should be reconciled, then reconciled again after it fails.
+	next()
+	next()
+
+	// This source should be started immediately.
+	if err := c.Watch(add(b), nil); err != nil {
+		t.Fatalf("Watch(...): %s", err)
+	}
+	next()
+
+	want := []reconcile.Request{a, a, b}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Start(...): -want reconciles, +got reconciles:\n%s", diff)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Start(...): %s", err)
+	}
+}
+
+func TestQueueControllerPriority(t *testing.T) {
+	req := func(name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Name: name}}
+	}
+
+	release := make(chan struct{})
+	reconciled := make(chan reconcile.Request, 10)
+	r := reconcile.Func(func(_ context.Context, rq reconcile.Request) (reconcile.Result, error) {
+		reconciled <- rq
+		if rq == req("first") {
+			<-release
+		}
+		return reconcile.Result{}, nil
+	})
+
+	c, err := NewQueueController("cool", &fake.Manager{Logger: logr.Discard()}, controller.Options{Reconciler: r}, PriorityQueueFn)
+	if err != nil {
+		t.Fatalf("NewQueueController(...): %s", err)
+	}
+
+	add := func(item reconcile.Request, p Priority) source.Source {
+		return source.Func(func(_ context.Context, _ handler.EventHandler, q workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+			q.(PriorityAdder).AddWithPriority(item, p)
+			return nil
+		})
+	}
+
+	if err := c.Watch(add(req("first"), PriorityNormal), nil); err != nil {
+		t.Fatalf("Watch(...): %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Start(ctx) }()
+
+	got := make([]reconcile.Request, 0, 3)
+	next := func() {
+		select {
+		case rq := <-reconciled:
+			got = append(got, rq)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Start(...): timed out waiting for a reconcile")
+		}
+	}
+
+	// Add a poll then a change while the only worker is busy. The change
+	// should be reconciled first once the worker is free.
+	next()
+	if err := c.Watch(add(req("poll"), PriorityLow), nil); err != nil {
+		t.Fatalf("Watch(...): %s", err)
+	}
+	if err := c.Watch(add(req("change"), PriorityHigh), nil); err != nil {
+		t.Fatalf("Watch(...): %s", err)
+	}
+	close(release)
+	next()
+	next()
+
+	want := []reconcile.Request{req("first"), req("change"), req("poll")}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Start(...): -want reconciles, +got reconciles:\n%s", diff)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Start(...): %s", err)
+	}
+}
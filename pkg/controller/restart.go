@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+)
+
+// Default restart backoff.
+const (
+	DefaultRestartInitialBackoff = 1 * time.Second
+	DefaultRestartMaxBackoff     = 5 * time.Minute
+)
+
+// A RestartPolicy determines whether and when an Engine restarts a controller
+// that crashed, i.e. whose controller or cache returned an error.
+type RestartPolicy struct {
+	// OnFailure restarts controllers that crash. Controllers are never
+	// restarted if OnFailure is false.
+	OnFailure bool
+
+	// MaxAttempts is the maximum number of consecutive times a controller
+	// will be restarted. Zero means there is no maximum.
+	MaxAttempts int
+
+	// InitialBackoff is how long to wait before restarting a controller that
+	// crashed for the first time. The wait doubles each consecutive time the
+	// controller crashes, up to MaxBackoff. A controller that runs for longer
+	// than MaxBackoff before crashing is considered to have recovered, and is
+	// again restarted after InitialBackoff. DefaultRestartInitialBackoff is
+	// used if it's zero.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the longest to wait before restarting a controller.
+	// DefaultRestartMaxBackoff is used if it's zero, and InitialBackoff if
+	// it's shorter than InitialBackoff.
+	MaxBackoff time.Duration
+}
+
+// RestartNever returns a RestartPolicy that never restarts a controller.
+func RestartNever() RestartPolicy {
+	return RestartPolicy{}
+}
+
+// RestartOnFailure returns a RestartPolicy that restarts a controller each time
+// it crashes, with exponential backoff.
+func RestartOnFailure() RestartPolicy {
+	return RestartPolicy{OnFailure: true, InitialBackoff: DefaultRestartInitialBackoff, MaxBackoff: DefaultRestartMaxBackoff}
+}
+
+// RestartMaxAttempts returns a RestartPolicy that restarts a controller each
+// time it crashes, with exponential backoff, up to the supplied number of
+// consecutive times.
+func RestartMaxAttempts(n int) RestartPolicy {
+	p := RestartOnFailure()
+	p.MaxAttempts = n
+	return p
+}
+
+// limits returns the policy's initial and maximum backoff, defaulting them if
+// they're not set.
+func (p RestartPolicy) limits() (initial, maxBackoff time.Duration) {
+	initial, maxBackoff = p.InitialBackoff, p.MaxBackoff
+	if initial <= 0 {
+		initial = DefaultRestartInitialBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRestartMaxBackoff
+	}
+	if maxBackoff < initial {
+		maxBackoff = initial
+	}
+	return initial, maxBackoff
+}
+
+// backoff returns how long to wait before the supplied restart attempt, and
+// whether the controller should be restarted at all. Attempts start at one.
+func (p RestartPolicy) backoff(attempt int) (time.Duration, bool) {
+	if !p.OnFailure || (p.MaxAttempts > 0 && attempt > p.MaxAttempts) {
+		return 0, false
+	}
+	d, maxBackoff := p.limits()
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d, true
+}
+
+// A Crash describes a controller that crashed.
+type Crash struct {
+	// Name of the controller.
+	Name string
+
+	// Err is the error the controller or its cache returned.
+	Err error
+
+	// Attempts is the number of consecutive times the controller has crashed.
+	Attempts int
+
+	// Restart is true if the controller will be restarted.
+	Restart bool
+
+	// Backoff is how long the Engine will wait before restarting the
+	// controller.
+	Backoff time.Duration
+}
+
+// A CrashHandlerFn is called when a controller crashes.
+type CrashHandlerFn func(c Crash)
+
+// WithRestartPolicy configures whether and when an Engine restarts controllers
+// that crash, unless they were started or created with their own policy using
+// StartWithPolicy or CreateWithPolicy. The Engine restarts a controller by
+// creating it again with the options and watches it was originally created
+// with, using a new cache. Indexes added to the original cache using
+// NamedController's GetCache are added to the new cache, and the cache
+// returned by GetCache uses the new cache. Controllers are never restarted by
+// default.
+func WithRestartPolicy(p RestartPolicy) EngineOption {
+	return func(e *Engine) {
+		e.policy = p
+	}
+}
+
+// WithCrashHandler configures a function an Engine calls each time one of its
+// controllers crashes. The function is called after the Engine has decided
+// whether to restart the controller, and must not block.
+func WithCrashHandler(fn CrashHandlerFn) EngineOption {
+	return func(e *Engine) {
+		e.onCrash = fn
+	}
+}
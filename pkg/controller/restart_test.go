@@ -0,0 +1,341 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	type want struct {
+		backoff time.Duration
+		restart bool
+	}
+	cases := map[string]struct {
+		reason  string
+		p       RestartPolicy
+		attempt int
+		want    want
+	}{
+		"Never": {
+			reason:  "Controllers should never be restarted by default.",
+			p:       RestartNever(),
+			attempt: 1,
+			want:    want{restart: false},
+		},
+		"FirstAttempt": {
+			reason:  "The first restart should wait for the initial backoff.",
+			p:       RestartOnFailure(),
+			attempt: 1,
+			want:    want{backoff: DefaultRestartInitialBackoff, restart: true},
+		},
+		"ThirdAttempt": {
+			reason:  "The backoff should double each consecutive attempt.",
+			p:       RestartOnFailure(),
+			attempt: 3,
+			want:    want{backoff: 4 * DefaultRestartInitialBackoff, restart: true},
+		},
+		"MaxBackoff": {
+			reason:  "The backoff should never exceed the maximum backoff.",
+			p:       RestartOnFailure(),
+			attempt: 100,
+			want:    want{backoff: DefaultRestartMaxBackoff, restart: true},
+		},
+		"DefaultBackoff": {
+			reason:  "The default initial backoff should be used if the policy doesn't set one.",
+			p:       RestartPolicy{OnFailure: true},
+			attempt: 1,
+			want:    want{backoff: DefaultRestartInitialBackoff, restart: true},
+		},
+		"DefaultMaxBackoff": {
+			reason:  "The backoff should grow up to the default maximum backoff if the policy doesn't set one.",
+			p:       RestartPolicy{OnFailure: true, InitialBackoff: time.Second},
+			attempt: 100,
+			want:    want{backoff: DefaultRestartMaxBackoff, restart: true},
+		},
+		"MaxBelowInitial": {
+			reason:  "The backoff should never be less than the initial backoff.",
+			p:       RestartPolicy{OnFailure: true, InitialBackoff: time.Minute, MaxBackoff: time.Second},
+			attempt: 3,
+			want:    want{backoff: time.Minute, restart: true},
+		},
+		"MaxAttempts": {
+			reason:  "Controllers should not be restarted more than the maximum attempts.",
+			p:       RestartMaxAttempts(2),
+			attempt: 3,
+			want:    want{restart: false},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			backoff, restart := tc.p.backoff(tc.attempt)
+			if diff := cmp.Diff(tc.want, want{backoff: backoff, restart: restart}, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\np.backoff(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestEngineRestart(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	p := RestartMaxAttempts(2)
+	p.InitialBackoff = time.Millisecond
+	p.MaxBackoff = 10 * time.Millisecond
+
+	var created atomic.Int32
+	crashes := make(chan Crash, 10)
+	e := NewEngine(&fake.Manager{},
+		WithRestartPolicy(p),
+		WithCrashHandler(func(c Crash) { crashes <- c }),
+		WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return &MockCache{MockStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}}, nil
+		}),
+		WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+			created.Add(1)
+			return &MockController{MockStart: func(context.Context) error { return errBoom }}, nil
+		}),
+	)
+
+	if err := e.Start("coolcontroller", controller.Options{}); err != nil {
+		t.Fatalf("e.Start(...): %s", err)
+	}
+
+	got := make([]Crash, 0, 3)
+	for i := 0; i < 3; i++ {
+		select {
+		case c := <-crashes:
+			got = append(got, c)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for crash %d", i+1)
+		}
+	}
+
+	crash := errors.Wrap(errBoom, errCrashController)
+	want := []Crash{
+		{Name: "coolcontroller", Err: crash, Attempts: 1, Restart: true, Backoff: time.Millisecond},
+		{Name: "coolcontroller", Err: crash, Attempts: 2, Restart: true, Backoff: 2 * time.Millisecond},
+		{Name: "coolcontroller", Err: crash, Attempts: 3, Restart: false},
+	}
+	if diff := cmp.Diff(want, got, test.EquateErrors()); diff != "" {
+		t.Errorf("A crashed controller should be restarted until it exceeds the maximum attempts: -want crashes, +got crashes:\n%s", diff)
+	}
+	if diff := cmp.Diff(int32(3), created.Load()); diff != "" {
+		t.Errorf("A controller should be created again each time it is restarted: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(crash, e.Err("coolcontroller"), test.EquateErrors()); diff != "" {
+		t.Errorf("e.Err(...): -want error, +got error:\n%s", diff)
+	}
+}
+
+func TestEngineStopCancelsRestart(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	var created atomic.Int32
+	crashed := make(chan Crash, 1)
+	e := NewEngine(&fake.Manager{},
+		WithRestartPolicy(RestartOnFailure()),
+		WithCrashHandler(func(c Crash) { crashed <- c }),
+		WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return &MockCache{MockStart: func(context.Context) error { return errBoom }}, nil
+		}),
+		WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+			created.Add(1)
+			return &MockController{MockStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}}, nil
+		}),
+	)
+
+	if err := e.Start("coolcontroller", controller.Options{}); err != nil {
+		t.Fatalf("e.Start(...): %s", err)
+	}
+
+	select {
+	case c := <-crashed:
+		if diff := cmp.Diff(Crash{Name: "coolcontroller", Attempts: 1, Restart: true, Backoff: DefaultRestartInitialBackoff}, c, cmpopts.IgnoreFields(Crash{}, "Err")); diff != "" {
+			t.Errorf("A crashed controller should be scheduled to restart: -want, +got:\n%s", diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for crash")
+	}
+
+	// Stopping the controller while it waits to restart should cancel the
+	// restart.
+	e.Stop("coolcontroller")
+	time.Sleep(DefaultRestartInitialBackoff + 100*time.Millisecond)
+
+	if e.IsRunning("coolcontroller") {
+		t.Errorf("e.IsRunning(...): want false, got true")
+	}
+	if diff := cmp.Diff(int32(1), created.Load()); diff != "" {
+		t.Errorf("A stopped controller should not be restarted: -want, +got:\n%s", diff)
+	}
+}
+
+func TestEngineControllerRestartPolicy(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	p := RestartMaxAttempts(1)
+	p.InitialBackoff = time.Millisecond
+
+	crashes := make(chan Crash, 10)
+	e := NewEngine(&fake.Manager{},
+		WithCrashHandler(func(c Crash) { crashes <- c }),
+		WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return &MockCache{MockStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}}, nil
+		}),
+		WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+			return &MockController{MockStart: func(context.Context) error { return errBoom }}, nil
+		}),
+	)
+
+	// Only the controller started with its own policy should be restarted.
+	if err := e.StartWithPolicy("restarted", controller.Options{}, p); err != nil {
+		t.Fatalf("e.StartWithPolicy(...): %s", err)
+	}
+	if err := e.Start("notrestarted", controller.Options{}); err != nil {
+		t.Fatalf("e.Start(...): %s", err)
+	}
+
+	got := map[string][]bool{}
+	for i := 0; i < 3; i++ {
+		select {
+		case c := <-crashes:
+			got[c.Name] = append(got[c.Name], c.Restart)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for crash %d", i+1)
+		}
+	}
+
+	want := map[string][]bool{
+		"restarted":    {true, false},
+		"notrestarted": {false},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("A controller's restart policy should override the Engine's: -want restarts, +got restarts:\n%s", diff)
+	}
+}
+
+type indexCache struct {
+	MockCache
+
+	indexes []string
+}
+
+func (c *indexCache) IndexField(_ context.Context, _ client.Object, field string, _ client.IndexerFunc) error {
+	c.indexes = append(c.indexes, field)
+	return nil
+}
+
+func TestEngineRestartKeepsCache(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	p := RestartOnFailure()
+	p.InitialBackoff = time.Millisecond
+
+	var mx sync.Mutex
+	caches := make([]*indexCache, 0, 2)
+	var starts atomic.Int32
+	crashed := make(chan Crash, 1)
+	e := NewEngine(&fake.Manager{Scheme: runtime.NewScheme()},
+		WithRestartPolicy(p),
+		WithCrashHandler(func(c Crash) { crashed <- c }),
+		WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+			mx.Lock()
+			defer mx.Unlock()
+			ca := &indexCache{MockCache: MockCache{MockStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}}}
+			caches = append(caches, ca)
+			return ca, nil
+		}),
+		WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+			return &MockController{MockStart: func(ctx context.Context) error {
+				// Crash the first time the controller is started.
+				if starts.Add(1) == 1 {
+					return errBoom
+				}
+				<-ctx.Done()
+				return nil
+			}}, nil
+		}),
+	)
+
+	nc, err := e.Create("coolcontroller", controller.Options{})
+	if err != nil {
+		t.Fatalf("e.Create(...): %s", err)
+	}
+	if err := nc.GetCache().IndexField(context.Background(), &fake.Managed{}, "spec.coolField", nil); err != nil {
+		t.Fatalf("nc.GetCache().IndexField(...): %s", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := nc.Start(ctx); err != nil {
+		t.Fatalf("nc.Start(...): %s", err)
+	}
+
+	select {
+	case <-crashed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for crash")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for starts.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for restart")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	mx.Lock()
+	defer mx.Unlock()
+	if len(caches) != 2 {
+		t.Fatalf("A restarted controller should get a new cache: want 2 caches, got %d", len(caches))
+	}
+	if diff := cmp.Diff([]string{"spec.coolField"}, caches[1].indexes); diff != "" {
+		t.Errorf("Indexes should be added to a restarted controller's new cache: -want, +got:\n%s", diff)
+	}
+	if nc.GetCache().(*controllerCache).current() != caches[1] {
+		t.Errorf("nc.GetCache(): want the cache of the restarted controller")
+	}
+}
@@ -0,0 +1,418 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	kmeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/internal/lease"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+)
+
+// LabelKeyShardGroup labels the Leases of the members of a shard group.
+const LabelKeyShardGroup = "crossplane.io/shard-group"
+
+// Sharding defaults.
+const (
+	DefaultShardLeaseDuration = 15 * time.Second
+	DefaultShardRenewInterval = 5 * time.Second
+	DefaultShardVirtualNodes  = 100
+)
+
+// Error strings.
+const (
+	errListShard = "cannot list sharded resources"
+)
+
+// A Sharder assigns resources to the replicas of a sharded controller. Each
+// replica is a member of a shard group. Members announce themselves by
+// renewing a coordination.k8s.io Lease, and are removed from the group when
+// their Lease expires. Resources are assigned to members by consistent hashing
+// on their UID, so that only a fraction of resources move to another member
+// when the group's membership changes.
+//
+// Membership is eventually consistent. Two members may briefly both believe
+// they own a resource while membership changes. A member that can't renew its
+// Lease or list the group's Leases stops owning resources before its Lease
+// would expire, so that it doesn't keep reconciling resources other members
+// have taken over. It owns resources again once it can reach the API server.
+type Sharder struct {
+	member lease.Member
+
+	duration time.Duration
+	renew    time.Duration
+	vnodes   int
+	log      logging.Logger
+
+	mx       sync.RWMutex
+	members  []string
+	ring     []point
+	subs     map[chan struct{}]bool
+	lastSync time.Time
+}
+
+// A point on the consistent hash ring.
+type point struct {
+	hash   uint64
+	member string
+}
+
+var (
+	_ manager.Runnable               = &Sharder{}
+	_ manager.LeaderElectionRunnable = &Sharder{}
+)
+
+// A SharderOption configures a Sharder.
+type SharderOption func(*Sharder)
+
+// WithShardLeaseDuration configures how long a member's Lease is valid for
+// after it was last renewed. DefaultShardLeaseDuration is used by default.
+func WithShardLeaseDuration(d time.Duration) SharderOption {
+	return func(s *Sharder) {
+		s.duration = d
+	}
+}
+
+// WithShardRenewInterval configures how often a member renews its Lease, and
+// checks the group's membership. DefaultShardRenewInterval is used by default.
+func WithShardRenewInterval(d time.Duration) SharderOption {
+	return func(s *Sharder) {
+		s.renew = d
+	}
+}
+
+// WithShardVirtualNodes configures how many points each member has on the
+// consistent hash ring. More points spread resources more evenly between
+// members. DefaultShardVirtualNodes is used by default.
+func WithShardVirtualNodes(n int) SharderOption {
+	return func(s *Sharder) {
+		s.vnodes = n
+	}
+}
+
+// WithShardLogger configures the Sharder's logger.
+func WithShardLogger(l logging.Logger) SharderOption {
+	return func(s *Sharder) {
+		s.log = l
+	}
+}
+
+// NewSharder returns a Sharder for the supplied shard group. Leases are stored
+// in the supplied namespace. The identity must be unique within the group, and
+// a valid Kubernetes object name - e.g. the name of the replica's pod.
+func NewSharder(c client.Client, namespace, group, identity string, o ...SharderOption) *Sharder {
+	s := &Sharder{
+		duration: DefaultShardLeaseDuration,
+		renew:    DefaultShardRenewInterval,
+		vnodes:   DefaultShardVirtualNodes,
+		log:      logging.NewNopLogger(),
+		subs:     make(map[chan struct{}]bool),
+	}
+	for _, fn := range o {
+		fn(s)
+	}
+	s.member = lease.Member{
+		Client:    c,
+		Namespace: namespace,
+		LabelKey:  LabelKeyShardGroup,
+		Group:     group,
+		Identity:  identity,
+		Duration:  s.duration,
+	}
+	return s
+}
+
+// NeedLeaderElection returns false. Every replica must join its shard group.
+func (s *Sharder) NeedLeaderElection() bool {
+	return false
+}
+
+// Start joins the shard group, and keeps track of its membership until the
+// supplied context is done. The Sharder owns no resources until it has joined
+// the group. It leaves the group by deleting its Lease when it returns.
+func (s *Sharder) Start(ctx context.Context) error {
+	t := time.NewTicker(s.renew)
+	defer t.Stop()
+
+	for {
+		if err := s.sync(ctx, time.Now()); err != nil {
+			s.log.Info("Cannot sync shard group membership", "group", s.member.Group, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.leave()
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// sync renews the Sharder's Lease, and updates the group's membership. If it
+// can't, the Sharder stops owning resources once its Lease would expire before
+// the next sync. Other members may take over its resources once it expires.
+func (s *Sharder) sync(ctx context.Context, now time.Time) error {
+	members, err := s.live(ctx, now)
+	if err != nil {
+		s.mx.RLock()
+		stale := now.Sub(s.lastSync) > s.duration-s.renew
+		s.mx.RUnlock()
+		if stale {
+			s.setMembers(nil)
+		}
+		return err
+	}
+
+	s.mx.Lock()
+	s.lastSync = now
+	s.mx.Unlock()
+	s.setMembers(members)
+	return nil
+}
+
+// live renews the Sharder's Lease and returns the live members of the group.
+func (s *Sharder) live(ctx context.Context, now time.Time) ([]string, error) {
+	if err := s.member.Renew(ctx, now); err != nil {
+		return nil, err
+	}
+	return s.member.Live(ctx, now)
+}
+
+// leave the shard group, so that other members take over the Sharder's
+// resources without waiting for its Lease to expire.
+func (s *Sharder) leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.renew)
+	defer cancel()
+
+	if err := s.member.Leave(ctx); err != nil {
+		s.log.Info("Cannot leave shard group", "group", s.member.Group, "error", err)
+	}
+	s.setMembers(nil)
+}
+
+// setMembers updates the group's membership, and notifies subscribers if it
+// changed.
+func (s *Sharder) setMembers(members []string) {
+	sort.Strings(members)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if equal(s.members, members) {
+		return
+	}
+
+	ring := make([]point, 0, len(members)*s.vnodes)
+	for _, m := range members {
+		for i := 0; i < s.vnodes; i++ {
+			ring = append(ring, point{hash: hash(m + "#" + strconv.Itoa(i)), member: m})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+
+	s.log.Debug("Shard group membership changed", "group", s.member.Group, "members", members)
+	s.members = members
+	s.ring = ring
+
+	for ch := range s.subs {
+		// Subscribers only need to know that membership changed since they
+		// last checked, so we don't block if they haven't yet.
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Members returns the members of the shard group, ordered by identity.
+func (s *Sharder) Members() []string {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return append([]string{}, s.members...)
+}
+
+// Owner returns the identity of the member of the shard group that owns the
+// resource with the supplied UID. It returns an empty string if the group has
+// no members.
+func (s *Sharder) Owner(uid types.UID) string {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	if len(s.ring) == 0 {
+		return ""
+	}
+	h := hash(string(uid))
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= h })
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].member
+}
+
+// Owns returns true if the supplied resource is owned by this member of the
+// shard group.
+func (s *Sharder) Owns(o metav1.Object) bool {
+	return s.Owner(o.GetUID()) == s.member.Identity
+}
+
+// Predicate returns a predicate that filters out events for resources that
+// are not owned by this member of the shard group.
+func (s *Sharder) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(o client.Object) bool { return s.Owns(o) })
+}
+
+// Handler wraps the supplied EventHandler such that it ignores events for
+// resources that are not owned by this member of the shard group.
+func (s *Sharder) Handler(h handler.EventHandler) handler.EventHandler {
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.RateLimitingInterface) {
+			if s.Owns(e.Object) {
+				h.Create(ctx, e, q)
+			}
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			if s.Owns(e.ObjectNew) {
+				h.Update(ctx, e, q)
+			}
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			if s.Owns(e.Object) {
+				h.Delete(ctx, e, q)
+			}
+		},
+		GenericFunc: func(ctx context.Context, e event.GenericEvent, q workqueue.RateLimitingInterface) {
+			if s.Owns(e.Object) {
+				h.Generic(ctx, e, q)
+			}
+		},
+	}
+}
+
+// Source returns a source.Source that enqueues every resource of the kind of
+// the supplied list that this member of the shard group owns each time the
+// group's membership changes. Use it to take over resources that moved to
+// this member. Resources are listed using the Sharder's client.
+func (s *Sharder) Source(l client.ObjectList) source.Source {
+	return &shardSource{sharder: s, list: l}
+}
+
+func (s *Sharder) subscribe() chan struct{} {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	ch := make(chan struct{}, 1)
+	s.subs[ch] = true
+	return ch
+}
+
+func (s *Sharder) unsubscribe(ch chan struct{}) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	delete(s.subs, ch)
+}
+
+type shardSource struct {
+	sharder *Sharder
+	list    client.ObjectList
+}
+
+// Start enqueues the resources the Sharder owns each time the shard group's
+// membership changes, until the supplied context is done. Resources are
+// handled as generic events. Start does not block.
+func (ss *shardSource) Start(ctx context.Context, h handler.EventHandler, q workqueue.RateLimitingInterface, p ...predicate.Predicate) error {
+	reg := registration{handler: h, queue: q, predicates: p}
+	ch := ss.sharder.subscribe()
+	go func() {
+		defer ss.sharder.unsubscribe(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				if err := ss.enqueue(ctx, reg); err != nil {
+					ss.sharder.log.Info("Cannot enqueue sharded resources", "group", ss.sharder.member.Group, "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (ss *shardSource) enqueue(ctx context.Context, reg registration) error {
+	l, ok := ss.list.DeepCopyObject().(client.ObjectList)
+	if !ok {
+		return errors.Errorf("%T is not a client.ObjectList", ss.list)
+	}
+	if err := ss.sharder.member.Client.List(ctx, l); err != nil {
+		return errors.Wrap(err, errListShard)
+	}
+	items, err := kmeta.ExtractList(l)
+	if err != nil {
+		return errors.Wrap(err, errExtractList)
+	}
+	for _, i := range items {
+		o, ok := i.(client.Object)
+		if !ok || !ss.sharder.Owns(o) {
+			continue
+		}
+		reg.generic(ctx, event.GenericEvent{Object: o})
+	}
+	return nil
+}
+
+// hash the supplied string. FNV alone distributes similar strings (like UIDs
+// and virtual node names) poorly around the ring, so we mix its output using
+// MurmurHash3's finalizer.
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	k := h.Sum64()
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestSharder(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+
+	a := NewSharder(c, "crossplane-system", "cool", "a")
+	b := NewSharder(c, "crossplane-system", "cool", "b")
+
+	// Members don't own anything until they've joined the group.
+	if diff := cmp.Diff("", a.Owner("cool-uid")); diff != "" {
+		t.Errorf("a.Owner(...): -want, +got:\n%s", diff)
+	}
+
+	for _, s := range []*Sharder{a, b, a} {
+		if err := s.sync(ctx, time.Now()); err != nil {
+			t.Fatalf("s.sync(...): %s", err)
+		}
+	}
+	for _, s := range []*Sharder{a, b} {
+		if diff := cmp.Diff([]string{"a", "b"}, s.Members()); diff != "" {
+			t.Errorf("s.Members(): -want, +got:\n%s", diff)
+		}
+	}
+
+	owned := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		o := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{UID: types.UID(fmt.Sprintf("uid-%d", i))}}
+		if a.Owns(o) == b.Owns(o) {
+			t.Fatalf("Exactly one member should own %q", o.GetUID())
+		}
+		owned[a.Owner(o.GetUID())]++
+	}
+	for m, n := range owned {
+		// Consistent hashing won't split resources exactly evenly.
+		if n < 300 {
+			t.Errorf("Member %q owns %d of 1000 resources, want roughly half", m, n)
+		}
+	}
+
+	// Let b's Lease expire. Once a notices, it should own everything.
+	ls := &coordinationv1.Lease{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "crossplane-system", Name: "cool-b"}, ls); err != nil {
+		t.Fatalf("c.Get(...): %s", err)
+	}
+	expiry := metav1.NewMicroTime(time.Now().Add(-time.Hour))
+	ls.Spec.RenewTime = &expiry
+	if err := c.Update(ctx, ls); err != nil {
+		t.Fatalf("c.Update(...): %s", err)
+	}
+	if err := a.sync(ctx, time.Now()); err != nil {
+		t.Fatalf("a.sync(...): %s", err)
+	}
+	if diff := cmp.Diff([]string{"a"}, a.Members()); diff != "" {
+		t.Errorf("a.Members(): -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff("a", a.Owner("cool-uid")); diff != "" {
+		t.Errorf("a.Owner(...): -want, +got:\n%s", diff)
+	}
+
+	// Leaving the group should delete the member's Lease.
+	a.leave()
+	err := c.Get(ctx, types.NamespacedName{Namespace: "crossplane-system", Name: "cool-a"}, &coordinationv1.Lease{})
+	if client.IgnoreNotFound(err) != nil || err == nil {
+		t.Errorf("c.Get(...): want not found error, got %v", err)
+	}
+}
+
+func TestSharderStale(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	errBoom := errors.New("boom")
+
+	mc := &test.MockClient{
+		MockGet:    test.NewMockGetFn(nil),
+		MockUpdate: test.NewMockUpdateFn(nil),
+		MockList: test.NewMockListFn(nil, func(l client.ObjectList) error {
+			id, seconds, renewed := "a", int32(15), metav1.NewMicroTime(now)
+			l.(*coordinationv1.LeaseList).Items = []coordinationv1.Lease{{Spec: coordinationv1.LeaseSpec{HolderIdentity: &id, LeaseDurationSeconds: &seconds, RenewTime: &renewed}}}
+			return nil
+		}),
+	}
+	s := NewSharder(mc, "crossplane-system", "cool", "a", WithShardLeaseDuration(15*time.Second), WithShardRenewInterval(5*time.Second))
+	if err := s.sync(ctx, now); err != nil {
+		t.Fatalf("s.sync(...): %s", err)
+	}
+
+	// The API server becomes unavailable.
+	mc.MockGet = test.NewMockGetFn(errBoom)
+
+	// We keep owning resources while our Lease won't expire before the next
+	// sync...
+	if err := s.sync(ctx, now.Add(5*time.Second)); !errors.Is(err, errBoom) {
+		t.Errorf("s.sync(...): want error %q, got %v", errBoom, err)
+	}
+	if diff := cmp.Diff("a", s.Owner("cool-uid")); diff != "" {
+		t.Errorf("s.Owner(...): -want, +got:\n%s", diff)
+	}
+
+	// ...then stop owning them, so that other members can take over.
+	_ = s.sync(ctx, now.Add(15*time.Second))
+	if diff := cmp.Diff("", s.Owner("cool-uid")); diff != "" {
+		t.Errorf("s.Owner(...): -want, +got:\n%s", diff)
+	}
+
+	// We own resources again once we can reach the API server.
+	mc.MockGet = test.NewMockGetFn(nil)
+	if err := s.sync(ctx, now.Add(15*time.Second)); err != nil {
+		t.Fatalf("s.sync(...): %s", err)
+	}
+	if diff := cmp.Diff("a", s.Owner("cool-uid")); diff != "" {
+		t.Errorf("s.Owner(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestSharderHandler(t *testing.T) {
+	s := NewSharder(nil, "crossplane-system", "cool", "a")
+	s.setMembers([]string{"a", "b"})
+
+	var mine, theirs *corev1.ConfigMap
+	for i := 0; mine == nil || theirs == nil; i++ {
+		o := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("cm-%d", i), UID: types.UID(fmt.Sprintf("uid-%d", i))}}
+		if s.Owns(o) {
+			mine = o
+		} else {
+			theirs = o
+		}
+	}
+
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	h := s.Handler(&handler.EnqueueRequestForObject{})
+	h.Create(context.Background(), event.CreateEvent{Object: mine}, q)
+	h.Create(context.Background(), event.CreateEvent{Object: theirs}, q)
+
+	if diff := cmp.Diff(1, q.Len()); diff != "" {
+		t.Errorf("Only resources owned by the member should be enqueued: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(false, s.Predicate().Generic(event.GenericEvent{Object: theirs})); diff != "" {
+		t.Errorf("s.Predicate(): -want, +got:\n%s", diff)
+	}
+}
+
+func TestSharderSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	objs := make([]client.Object, 0, 20)
+	for i := 0; i < 20; i++ {
+		objs = append(objs, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      fmt.Sprintf("cm-%d", i),
+			UID:       types.UID(fmt.Sprintf("uid-%d", i)),
+		}})
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build()
+	s := NewSharder(c, "crossplane-system", "cool", "a")
+
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	if err := s.Source(&corev1.ConfigMapList{}).Start(ctx, &handler.EnqueueRequestForObject{}, q); err != nil {
+		t.Fatalf("Start(...): %s", err)
+	}
+
+	// When b leaves, a should take over all of b's resources.
+	s.setMembers([]string{"a", "b"})
+	waitFor(t, func() bool { return q.Len() > 0 && q.Len() < 20 })
+	s.setMembers([]string{"a"})
+	waitFor(t, func() bool { return q.Len() == 20 })
+}
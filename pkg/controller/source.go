@@ -0,0 +1,277 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Error strings.
+const (
+	errGetInformer     = "cannot get informer"
+	errAddEventHandler = "cannot add event handler"
+	errRemoveHandler   = "cannot remove event handler"
+)
+
+// A stoppableSource is a source.Source that watches a kind of object using an
+// informer. Unlike source.Kind it can be stopped, which removes its event
+// handler from the informer.
+type stoppableSource struct {
+	informers cache.Informers
+	kind      client.Object
+
+	// objects counts the objects in the informer, if set.
+	objects prometheus.Gauge
+
+	mx       sync.Mutex
+	informer cache.Informer
+	reg      toolscache.ResourceEventHandlerRegistration
+}
+
+var _ source.SyncingSource = &stoppableSource{}
+
+func newStoppableSource(i cache.Informers, kind client.Object) *stoppableSource {
+	return &stoppableSource{informers: i, kind: kind}
+}
+
+// Start the source. Events will be handled by the supplied handler, and added
+// to the supplied queue, until the source is stopped. Start does not block.
+func (s *stoppableSource) Start(ctx context.Context, h handler.EventHandler, q workqueue.RateLimitingInterface, p ...predicate.Predicate) error {
+	i, err := s.informers.GetInformer(ctx, s.kind, cache.BlockUntilSynced(false))
+	if err != nil {
+		return errors.Wrap(err, errGetInformer)
+	}
+	reg, err := i.AddEventHandler(eventHandler(ctx, h, q, p, s.objects))
+	if err != nil {
+		return errors.Wrap(err, errAddEventHandler)
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.informer = i
+	s.reg = reg
+	return nil
+}
+
+// WaitForSync blocks until the source's informer has synced.
+func (s *stoppableSource) WaitForSync(ctx context.Context) error {
+	return wait.PollUntilContextCancel(ctx, 100*time.Millisecond, true, func(context.Context) (bool, error) {
+		s.mx.Lock()
+		defer s.mx.Unlock()
+		return s.reg != nil && s.reg.HasSynced(), nil
+	})
+}
+
+// Stop the source by removing its event handler from the informer. Stopping
+// a source that was never started is a no-op.
+func (s *stoppableSource) Stop() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.reg == nil {
+		return nil
+	}
+	if err := s.informer.RemoveEventHandler(s.reg); err != nil {
+		return errors.Wrap(err, errRemoveHandler)
+	}
+	s.informer, s.reg = nil, nil
+	return nil
+}
+
+// A customSource wraps a custom source.Source so that it can be stopped. Most
+// sources can't remove the event handlers they add, so a stopped customSource
+// cancels the context its source was started with and drops any requests the
+// source adds to the queue afterwards.
+type customSource struct {
+	source.Source
+
+	mx      sync.Mutex
+	cancel  context.CancelFunc
+	stopped bool
+}
+
+var _ source.SyncingSource = &customSource{}
+
+// Start the wrapped source. Requests it adds to the supplied queue are
+// dropped once the customSource is stopped.
+func (s *customSource) Start(ctx context.Context, h handler.EventHandler, q workqueue.RateLimitingInterface, p ...predicate.Predicate) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	s.mx.Lock()
+	if s.stopped {
+		s.mx.Unlock()
+		cancel()
+		return nil
+	}
+	s.cancel = cancel
+	s.mx.Unlock()
+
+	return s.Source.Start(ctx, h, &stoppableQueue{RateLimitingInterface: q, s: s}, p...)
+}
+
+// WaitForSync blocks until the wrapped source has synced, if it can sync.
+func (s *customSource) WaitForSync(ctx context.Context) error {
+	if ss, ok := s.Source.(source.SyncingSource); ok {
+		return ss.WaitForSync(ctx)
+	}
+	return nil
+}
+
+// Stop the wrapped source.
+func (s *customSource) Stop() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.stopped = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+func (s *customSource) isStopped() bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.stopped
+}
+
+// A stoppableQueue drops the requests its customSource adds once the source
+// is stopped.
+type stoppableQueue struct {
+	workqueue.RateLimitingInterface
+	s *customSource
+}
+
+func (q *stoppableQueue) Add(item any) {
+	if !q.s.isStopped() {
+		q.RateLimitingInterface.Add(item)
+	}
+}
+
+func (q *stoppableQueue) AddAfter(item any, d time.Duration) {
+	if !q.s.isStopped() {
+		q.RateLimitingInterface.AddAfter(item, d)
+	}
+}
+
+func (q *stoppableQueue) AddRateLimited(item any) {
+	if !q.s.isStopped() {
+		q.RateLimitingInterface.AddRateLimited(item)
+	}
+}
+
+func (q *stoppableQueue) AddWithPriority(item any, p Priority) {
+	if q.s.isStopped() {
+		return
+	}
+	if pa, ok := q.RateLimitingInterface.(PriorityAdder); ok {
+		pa.AddWithPriority(item, p)
+		return
+	}
+	q.RateLimitingInterface.Add(item)
+}
+
+// kindOf returns the kind of object the supplied custom source watches, or nil
+// if it's unknown. source.Kind returns an unexported type, so we read the
+// Type field it has in common with other sources that watch a kind.
+func kindOf(src source.Source) client.Object {
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	f := v.Elem().FieldByName("Type")
+	if !f.IsValid() || !f.CanInterface() {
+		return nil
+	}
+	o, _ := f.Interface().(client.Object)
+	return o
+}
+
+// eventHandler adapts the supplied handler and predicates for use as an
+// informer's event handler. Objects are counted by the supplied gauge, if any,
+// regardless of the predicates.
+func eventHandler(ctx context.Context, h handler.EventHandler, q workqueue.RateLimitingInterface, ps []predicate.Predicate, objects prometheus.Gauge) toolscache.ResourceEventHandlerFuncs {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if objects != nil {
+				objects.Inc()
+			}
+			o, ok := obj.(client.Object)
+			if !ok {
+				return
+			}
+			e := event.CreateEvent{Object: o}
+			for _, p := range ps {
+				if !p.Create(e) {
+					return
+				}
+			}
+			h.Create(ctx, e, q)
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oo, ok := oldObj.(client.Object)
+			if !ok {
+				return
+			}
+			no, ok := newObj.(client.Object)
+			if !ok {
+				return
+			}
+			e := event.UpdateEvent{ObjectOld: oo, ObjectNew: no}
+			for _, p := range ps {
+				if !p.Update(e) {
+					return
+				}
+			}
+			h.Update(ctx, e, q)
+		},
+		DeleteFunc: func(obj any) {
+			if objects != nil {
+				objects.Dec()
+			}
+			e := event.DeleteEvent{}
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				e.DeleteStateUnknown = true
+				obj = tombstone.Obj
+			}
+			o, ok := obj.(client.Object)
+			if !ok {
+				return
+			}
+			e.Object = o
+			for _, p := range ps {
+				if !p.Delete(e) {
+					return
+				}
+			}
+			h.Delete(ctx, e, q)
+		},
+	}
+}
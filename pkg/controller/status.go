@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Error strings.
+const (
+	errFmtCrashed = "controllers have crashed: %s"
+)
+
+// ControllerStatus is the status of a controller started by an Engine.
+type ControllerStatus struct {
+	// Name of the controller.
+	Name string
+
+	// Running is true if the controller has been started, and has not been
+	// stopped or crashed.
+	Running bool
+
+	// Crashed is true if the controller's controller or cache returned an
+	// error, and the controller has not been stopped or started since.
+	Crashed bool
+
+	// Restarting is true if the controller crashed and will be restarted
+	// once its RestartPolicy's backoff elapses.
+	Restarting bool
+
+	// StartTime is when the controller was last started.
+	StartTime time.Time
+
+	// Watches are the kinds of object the controller watches using its own
+	// cache. Custom sources are omitted.
+	Watches []schema.GroupVersionKind
+
+	// Synced is true if the controller's cache has synced since it was last
+	// started.
+	Synced bool
+
+	// Err is the first error the controller encountered since it was last
+	// started, if any.
+	Err error
+}
+
+// MarshalJSON marshals the ControllerStatus to JSON.
+func (s ControllerStatus) MarshalJSON() ([]byte, error) {
+	watches := make([]string, len(s.Watches))
+	for i := range s.Watches {
+		watches[i] = s.Watches[i].String()
+	}
+	msg := ""
+	if s.Err != nil {
+		msg = s.Err.Error()
+	}
+	return json.Marshal(struct {
+		Name       string    `json:"name"`
+		Running    bool      `json:"running"`
+		Crashed    bool      `json:"crashed"`
+		Restarting bool      `json:"restarting"`
+		StartTime  time.Time `json:"startTime"`
+		Watches    []string  `json:"watches"`
+		Synced     bool      `json:"synced"`
+		Error      string    `json:"error,omitempty"`
+	}{
+		Name:       s.Name,
+		Running:    s.Running,
+		Crashed:    s.Crashed,
+		Restarting: s.Restarting,
+		StartTime:  s.StartTime,
+		Watches:    watches,
+		Synced:     s.Synced,
+		Error:      msg,
+	})
+}
+
+// Controllers returns the status of every controller the Engine has started,
+// ordered by name.
+func (e *Engine) Controllers() []ControllerStatus {
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+
+	out := make([]ControllerStatus, 0, len(e.runs))
+	for name, r := range e.runs {
+		_, running := e.started[name]
+		_, restarting := e.restarts[name]
+		out = append(out, ControllerStatus{
+			Name:       name,
+			Running:    running,
+			Crashed:    !running && !r.stopped && e.errors[name] != nil,
+			Restarting: restarting,
+			StartTime:  r.started,
+			Watches:    append([]schema.GroupVersionKind{}, r.ctrl.gvks...),
+			Synced:     r.synced,
+			Err:        e.errors[name],
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// StatusHandler returns an http.Handler that responds to GET requests with the
+// JSON encoded status of every controller the Engine has started.
+func (e *Engine) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(e.Controllers())
+	})
+}
+
+// HealthzCheck is a healthz.Checker that returns an error if any controller
+// the Engine has started has crashed and won't be restarted. Controllers that
+// crashed and are waiting to be restarted under their RestartPolicy are
+// considered healthy, so that the process isn't restarted while the Engine
+// recovers them.
+func (e *Engine) HealthzCheck(_ *http.Request) error {
+	crashed := make([]string, 0)
+	for _, s := range e.Controllers() {
+		if s.Crashed && !s.Restarting {
+			crashed = append(crashed, s.Name)
+		}
+	}
+	if len(crashed) > 0 {
+		return errors.Errorf(errFmtCrashed, strings.Join(crashed, ", "))
+	}
+	return nil
+}
+
+var _ healthz.Checker = (&Engine{}).HealthzCheck
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestEngineStatus(t *testing.T) {
+	errBoom := errors.New("boom")
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+
+	e := NewEngine(&fake.Manager{Scheme: runtime.NewScheme(), Cache: &MockCache{}},
+		WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return &MockCache{MockStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}}, nil
+		}),
+		WithNewControllerFn(func(name string, _ manager.Manager, _ controller.Options) (controller.Controller, error) {
+			return &MockController{
+				MockStart: func(ctx context.Context) error {
+					if name == "crashy" || name == "restarting" {
+						return errBoom
+					}
+					<-ctx.Done()
+					return nil
+				},
+				MockWatch: func(source.Source, handler.EventHandler, ...predicate.Predicate) error { return nil },
+			}, nil
+		}),
+	)
+
+	thing := &unstructured.Unstructured{}
+	thing.SetGroupVersionKind(gvk)
+	for _, name := range []string{"healthy", "crashy", "stopped"} {
+		if err := e.Start(name, controller.Options{}, For(thing, nil)); err != nil {
+			t.Fatalf("e.Start(%q, ...): %s", name, err)
+		}
+	}
+	e.Stop("stopped")
+
+	// This controller will be restarted after it crashes, but not soon.
+	p := RestartOnFailure()
+	p.InitialBackoff = time.Hour
+	if err := e.StartWithPolicy("restarting", controller.Options{}, p, For(thing, nil)); err != nil {
+		t.Fatalf("e.StartWithPolicy(%q, ...): %s", "restarting", err)
+	}
+
+	want := []ControllerStatus{
+		{Name: "crashy", Crashed: true, Watches: []schema.GroupVersionKind{gvk}, Synced: true, Err: errors.Wrap(errBoom, errCrashController)},
+		{Name: "healthy", Running: true, Watches: []schema.GroupVersionKind{gvk}, Synced: true},
+		{Name: "restarting", Crashed: true, Restarting: true, Watches: []schema.GroupVersionKind{gvk}, Synced: true, Err: errors.Wrap(errBoom, errCrashController)},
+		{Name: "stopped", Watches: []schema.GroupVersionKind{gvk}},
+	}
+
+	// Give the controllers a little time to sync their caches and crash.
+	var got []ControllerStatus
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		got = e.Controllers()
+		if got[0].Crashed && got[1].Synced && got[2].Restarting {
+			break
+		}
+	}
+
+	if diff := cmp.Diff(want, got, test.EquateErrors(), cmpopts.IgnoreFields(ControllerStatus{}, "StartTime", "Synced")); diff != "" {
+		t.Errorf("e.Controllers(): -want, +got:\n%s", diff)
+	}
+	if !got[1].Synced {
+		t.Errorf("e.Controllers(): want healthy controller to be synced")
+	}
+	for _, s := range got {
+		if s.StartTime.IsZero() {
+			t.Errorf("e.Controllers(): want start time of %q to be set", s.Name)
+		}
+	}
+
+	t.Run("StatusHandler", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.StatusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if diff := cmp.Diff(http.StatusOK, rec.Code); diff != "" {
+			t.Errorf("ServeHTTP(...): -want status, +got status:\n%s", diff)
+		}
+		body := []map[string]any{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("json.Unmarshal(...): %s", err)
+		}
+		if diff := cmp.Diff("controller error: boom", body[0]["error"]); diff != "" {
+			t.Errorf("ServeHTTP(...): -want error, +got error:\n%s", diff)
+		}
+		if diff := cmp.Diff([]any{"example.org/v1, Kind=Thing"}, body[1]["watches"]); diff != "" {
+			t.Errorf("ServeHTTP(...): -want watches, +got watches:\n%s", diff)
+		}
+	})
+
+	t.Run("HealthzCheck", func(t *testing.T) {
+		// Controllers waiting to be restarted shouldn't be unhealthy.
+		err := e.HealthzCheck(nil)
+		if diff := cmp.Diff(errors.Errorf(errFmtCrashed, "crashy"), err, test.EquateErrors()); diff != "" {
+			t.Errorf("e.HealthzCheck(...): -want error, +got error:\n%s", diff)
+		}
+
+		// Stopping the crashed controller should make the Engine healthy.
+		e.Stop("crashy")
+		if err := e.HealthzCheck(nil); err != nil {
+			t.Errorf("e.HealthzCheck(...): %s", err)
+		}
+	})
+
+	e.Stop("healthy")
+	e.Stop("restarting")
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+type MockInformer struct {
+	cache.Informer
+
+	mx      sync.Mutex
+	handler toolscache.ResourceEventHandler
+}
+
+type MockRegistration struct{}
+
+func (r *MockRegistration) HasSynced() bool { return true }
+
+func (i *MockInformer) AddEventHandler(h toolscache.ResourceEventHandler) (toolscache.ResourceEventHandlerRegistration, error) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	i.handler = h
+	return &MockRegistration{}, nil
+}
+
+func (i *MockInformer) RemoveEventHandler(toolscache.ResourceEventHandlerRegistration) error {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	i.handler = nil
+	return nil
+}
+
+func (i *MockInformer) HasHandler() bool {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	return i.handler != nil
+}
+
+func TestEngineWatches(t *testing.T) {
+	thingGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"}
+	otherGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Other"}
+	newerGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Newer"}
+	brokenGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Broken"}
+
+	errBoom := errors.New("boom")
+
+	thing := &unstructured.Unstructured{}
+	thing.SetGroupVersionKind(thingGVK)
+	other := &unstructured.Unstructured{}
+	other.SetGroupVersionKind(otherGVK)
+	newer := &unstructured.Unstructured{}
+	newer.SetGroupVersionKind(newerGVK)
+	broken := &unstructured.Unstructured{}
+	broken.SetGroupVersionKind(brokenGVK)
+
+	informers := map[schema.GroupVersionKind]*MockInformer{
+		thingGVK: {},
+		otherGVK: {},
+		newerGVK: {},
+	}
+
+	// Caches created by the Engine, and how many are running.
+	var mx sync.Mutex
+	caches := 0
+	running := 0
+
+	e := NewEngine(&fake.Manager{Scheme: runtime.NewScheme(), Cache: &MockCache{}},
+		WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+			mx.Lock()
+			caches++
+			mx.Unlock()
+			return &MockCache{
+				MockStart: func(ctx context.Context) error {
+					mx.Lock()
+					running++
+					mx.Unlock()
+					<-ctx.Done()
+					mx.Lock()
+					running--
+					mx.Unlock()
+					return nil
+				},
+				MockGetInformer: func(_ context.Context, obj client.Object, _ ...cache.InformerGetOption) (cache.Informer, error) {
+					return informers[obj.GetObjectKind().GroupVersionKind()], nil
+				},
+			}, nil
+		}),
+		WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+			return &MockController{
+				MockStart: func(ctx context.Context) error {
+					<-ctx.Done()
+					return nil
+				},
+				MockWatch: func(s source.Source, h handler.EventHandler, p ...predicate.Predicate) error {
+					if ss, ok := s.(*stoppableSource); ok && ss.kind.GetObjectKind().GroupVersionKind() == brokenGVK {
+						return errBoom
+					}
+					// Like a started controller, start the source immediately.
+					return s.Start(context.Background(), h, nil, p...)
+				},
+			}, nil
+		}),
+	)
+
+	err := e.StartWatches("coolcontroller", For(other, nil))
+	if diff := cmp.Diff(errors.Errorf(errFmtNotRunning, "coolcontroller"), err, test.EquateErrors()); diff != "" {
+		t.Errorf("e.StartWatches(...): Starting watches on a controller that isn't running should return an error: -want, +got:\n%s", diff)
+	}
+
+	if err := e.Start("coolcontroller", controller.Options{}, For(thing, nil)); err != nil {
+		t.Fatalf("e.Start(...): %s", err)
+	}
+	if err := e.StartWatches("coolcontroller", For(other, nil), For(other, nil)); err != nil {
+		t.Fatalf("e.StartWatches(...): %s", err)
+	}
+
+	// The controller has its own cache, and a cache for each kind it watches.
+	if diff := cmp.Diff(3, caches); diff != "" {
+		t.Errorf("Watching a new kind of object should start one new cache: -want caches, +got caches:\n%s", diff)
+	}
+	if !informers[otherGVK].HasHandler() {
+		t.Errorf("e.StartWatches(...): want an event handler to be added to the informer")
+	}
+	if diff := cmp.Diff([]schema.GroupVersionKind{thingGVK, otherGVK}, e.Controllers()[0].Watches); diff != "" {
+		t.Errorf("e.Controllers(): -want watches, +got watches:\n%s", diff)
+	}
+
+	// Wait for the controller's cache and the caches of both kinds to start.
+	waitFor(t, func() bool { mx.Lock(); defer mx.Unlock(); return running == 3 })
+
+	// If any watch can't be started, those that were should be rolled back.
+	err = e.StartWatches("coolcontroller", For(newer, nil), For(broken, nil))
+	if diff := cmp.Diff(errors.Wrap(errBoom, errWatch), err, test.EquateErrors()); diff != "" {
+		t.Errorf("e.StartWatches(...): -want error, +got error:\n%s", diff)
+	}
+	if informers[newerGVK].HasHandler() {
+		t.Errorf("e.StartWatches(...): want the event handlers of watches that were started to be removed when another fails")
+	}
+	if diff := cmp.Diff([]schema.GroupVersionKind{thingGVK, otherGVK}, e.Controllers()[0].Watches); diff != "" {
+		t.Errorf("e.Controllers(): -want watches, +got watches:\n%s", diff)
+	}
+	waitFor(t, func() bool { mx.Lock(); defer mx.Unlock(); return running == 3 })
+
+	if err := e.StopWatches("coolcontroller", thingGVK, otherGVK); err != nil {
+		t.Fatalf("e.StopWatches(...): %s", err)
+	}
+
+	if informers[thingGVK].HasHandler() || informers[otherGVK].HasHandler() {
+		t.Errorf("e.StopWatches(...): want event handlers to be removed from the informers")
+	}
+	if diff := cmp.Diff([]schema.GroupVersionKind{}, e.Controllers()[0].Watches); diff != "" {
+		t.Errorf("e.Controllers(): -want watches, +got watches:\n%s", diff)
+	}
+
+	// The caches of both kinds should be stopped, including that of the kind
+	// the controller was started with. The controller's own cache runs until
+	// the controller is stopped.
+	waitFor(t, func() bool { mx.Lock(); defer mx.Unlock(); return running == 1 })
+	if !e.IsRunning("coolcontroller") {
+		t.Errorf("e.IsRunning(...): Stopping watches should not stop the controller")
+	}
+
+	e.Stop("coolcontroller")
+	waitFor(t, func() bool { mx.Lock(); defer mx.Unlock(); return running == 0 })
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal("Timed out waiting for condition")
+}
+
+func TestEngineStartWatchesDoesNotBlock(t *testing.T) {
+	otherGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Other"}
+	other := &unstructured.Unstructured{}
+	other.SetGroupVersionKind(otherGVK)
+
+	// Watch blocks until unblocked, like a controller that is waiting for
+	// its sources to sync.
+	watching := make(chan struct{})
+	unblock := make(chan struct{})
+
+	e := NewEngine(&fake.Manager{Scheme: runtime.NewScheme(), Cache: &MockCache{}},
+		WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return &MockCache{MockStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}}, nil
+		}),
+		WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+			return &MockController{
+				MockStart: func(ctx context.Context) error {
+					<-ctx.Done()
+					return nil
+				},
+				MockWatch: func(source.Source, handler.EventHandler, ...predicate.Predicate) error {
+					close(watching)
+					<-unblock
+					return nil
+				},
+			}, nil
+		}),
+	)
+
+	if err := e.Start("coolcontroller", controller.Options{}); err != nil {
+		t.Fatalf("e.Start(...): %s", err)
+	}
+	defer e.Stop("coolcontroller")
+
+	errs := make(chan error)
+	go func() { errs <- e.StartWatches("coolcontroller", For(other, nil)) }()
+	<-watching
+
+	running := make(chan bool)
+	go func() { running <- e.IsRunning("coolcontroller") }()
+	select {
+	case r := <-running:
+		if !r {
+			t.Errorf("e.IsRunning(...): want the controller to be running")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("e.IsRunning(...): blocked while StartWatches was waiting for Watch")
+	}
+
+	close(unblock)
+	if err := <-errs; err != nil {
+		t.Errorf("e.StartWatches(...): %s", err)
+	}
+}
+
+func TestCustomSource(t *testing.T) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Thing"})
+	if diff := cmp.Diff(client.Object(u), kindOf(source.Kind(&MockCache{}, u))); diff != "" {
+		t.Errorf("kindOf(...): -want, +got:\n%s", diff)
+	}
+
+	var started context.Context
+	var q workqueue.RateLimitingInterface
+	src := &customSource{Source: source.Func(func(ctx context.Context, _ handler.EventHandler, sq workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+		started, q = ctx, sq
+		return nil
+	})}
+
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+	if err := src.Start(context.Background(), nil, queue); err != nil {
+		t.Fatalf("src.Start(...): %s", err)
+	}
+
+	q.Add("a")
+	src.Stop()
+	q.Add("b")
+
+	if started.Err() == nil {
+		t.Errorf("src.Stop(): want the wrapped source's context to be cancelled")
+	}
+	if diff := cmp.Diff(1, queue.Len()); diff != "" {
+		t.Errorf("src.Stop(): want requests added after the source is stopped to be dropped: -want, +got:\n%s", diff)
+	}
+}
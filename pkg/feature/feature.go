@@ -49,3 +49,26 @@ func (fs *Flags) Enabled(f Flag) bool {
 	defer fs.m.RUnlock()
 	return fs.enabled[f]
 }
+
+// Disable a feature flag.
+func (fs *Flags) Disable(f Flag) {
+	fs.m.Lock()
+	delete(fs.enabled, f)
+	fs.m.Unlock()
+}
+
+// Copy returns a copy of the flags. Enabling or disabling a flag in the copy
+// doesn't affect the original, and vice versa. Copying nil flags returns empty
+// flags.
+func (fs *Flags) Copy() *Flags {
+	out := &Flags{}
+	if fs == nil {
+		return out
+	}
+	fs.m.RLock()
+	defer fs.m.RUnlock()
+	for f := range fs.enabled {
+		out.Enable(f)
+	}
+	return out
+}
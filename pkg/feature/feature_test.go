@@ -59,3 +59,55 @@ func TestEnable(t *testing.T) {
 		}
 	})
 }
+
+func TestDisable(t *testing.T) {
+	var cool Flag = "cool"
+
+	t.Run("DisableEnabledFlag", func(t *testing.T) {
+		f := &Flags{}
+		f.Enable(cool)
+		f.Disable(cool)
+
+		if diff := cmp.Diff(false, f.Enabled(cool)); diff != "" {
+			t.Errorf("f.Enabled(...): -want, +got:\n%s", diff)
+		}
+	})
+
+	t.Run("DisableZeroValue", func(t *testing.T) {
+		f := &Flags{}
+		f.Disable(cool)
+
+		if diff := cmp.Diff(false, f.Enabled(cool)); diff != "" {
+			t.Errorf("f.Enabled(...): -want, +got:\n%s", diff)
+		}
+	})
+}
+
+func TestCopy(t *testing.T) {
+	var cool Flag = "cool"
+	var lame Flag = "lame"
+
+	t.Run("CopyIsIndependent", func(t *testing.T) {
+		f := &Flags{}
+		f.Enable(cool)
+
+		c := f.Copy()
+		c.Disable(cool)
+		c.Enable(lame)
+
+		if diff := cmp.Diff(true, f.Enabled(cool)); diff != "" {
+			t.Errorf("f.Enabled(cool): -want, +got:\n%s", diff)
+		}
+		if diff := cmp.Diff(false, f.Enabled(lame)); diff != "" {
+			t.Errorf("f.Enabled(lame): -want, +got:\n%s", diff)
+		}
+	})
+
+	t.Run("CopyNil", func(t *testing.T) {
+		var f *Flags
+
+		if diff := cmp.Diff(false, f.Copy().Enabled(cool)); diff != "" {
+			t.Errorf("f.Copy().Enabled(...): -want, +got:\n%s", diff)
+		}
+	})
+}
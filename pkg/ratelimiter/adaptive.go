@@ -0,0 +1,203 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiter
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Defaults for an Adaptive rate limiter.
+const (
+	DefaultAdaptiveIncrease = 0.1
+	DefaultAdaptiveDecrease = 0.5
+	DefaultAdaptiveCooldown = 1 * time.Second
+)
+
+// A Reporter is told whether calls that were subject to rate limiting
+// succeeded, or were throttled.
+type Reporter interface {
+	// Report whether a call succeeded. False means the call was throttled.
+	Report(success bool)
+}
+
+// An Adaptive rate limiter is a token bucket rate limiter that adjusts its
+// rate using additive increase, multiplicative decrease (AIMD). Its rate
+// increases by a small, fixed amount each time a call is reported to have
+// succeeded, and is multiplied by a factor less than one each time a call is
+// reported to have been throttled. The rate is always between its minimum and
+// maximum rate. Like the limiter returned by NewGlobal its burst is ten times
+// its rate.
+type Adaptive struct {
+	limiter *rate.Limiter
+
+	min      float64
+	max      float64
+	increase float64
+	decrease float64
+	cooldown time.Duration
+
+	now func() time.Time
+
+	mx           sync.Mutex
+	rps          float64
+	lastDecrease time.Time
+}
+
+var (
+	_ workqueue.RateLimiter = &Adaptive{}
+	_ Reporter              = &Adaptive{}
+)
+
+// An AdaptiveOption configures an Adaptive rate limiter.
+type AdaptiveOption func(a *Adaptive)
+
+// WithMinRate sets the minimum rate, in requests per second, an Adaptive rate
+// limiter will decrease to. The default is one request per second.
+func WithMinRate(rps float64) AdaptiveOption {
+	return func(a *Adaptive) {
+		a.min = rps
+	}
+}
+
+// WithMaxRate sets the maximum rate, in requests per second, an Adaptive rate
+// limiter will increase to. The default is ten times its initial rate.
+func WithMaxRate(rps float64) AdaptiveOption {
+	return func(a *Adaptive) {
+		a.max = rps
+	}
+}
+
+// WithIncrease sets how many requests per second an Adaptive rate limiter's
+// rate increases by each time a call succeeds.
+func WithIncrease(rps float64) AdaptiveOption {
+	return func(a *Adaptive) {
+		a.increase = rps
+	}
+}
+
+// WithDecrease sets the factor an Adaptive rate limiter's rate is multiplied
+// by each time a call is throttled. It should be between zero and one.
+func WithDecrease(factor float64) AdaptiveOption {
+	return func(a *Adaptive) {
+		a.decrease = factor
+	}
+}
+
+// WithDecreaseCooldown sets how long an Adaptive rate limiter waits after
+// decreasing its rate before it will decrease it again. Calls that were in
+// flight when the rate was decreased are likely to be throttled too, so
+// decreasing once for each of them would decrease the rate far more than
+// necessary.
+func WithDecreaseCooldown(d time.Duration) AdaptiveOption {
+	return func(a *Adaptive) {
+		a.cooldown = d
+	}
+}
+
+// NewAdaptive returns an Adaptive rate limiter with the supplied initial rate
+// of requests per second.
+func NewAdaptive(rps int, o ...AdaptiveOption) *Adaptive {
+	a := &Adaptive{
+		min:      1,
+		max:      float64(rps * 10),
+		increase: DefaultAdaptiveIncrease,
+		decrease: DefaultAdaptiveDecrease,
+		cooldown: DefaultAdaptiveCooldown,
+		now:      time.Now,
+		rps:      float64(rps),
+	}
+	for _, fn := range o {
+		fn(a)
+	}
+	a.rps = clamp(a.rps, a.min, a.max)
+	a.limiter = rate.NewLimiter(rate.Limit(a.rps), burst(a.rps))
+	return a
+}
+
+// Report whether a call succeeded. The rate is increased if the call
+// succeeded, and decreased if it was throttled.
+func (a *Adaptive) Report(success bool) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	now := a.now()
+	rps := a.rps + a.increase
+	if !success {
+		if now.Sub(a.lastDecrease) < a.cooldown {
+			return
+		}
+		a.lastDecrease = now
+		rps = a.rps * a.decrease
+	}
+
+	rps = clamp(rps, a.min, a.max)
+	if rps == a.rps {
+		return
+	}
+	a.rps = rps
+	a.limiter.SetLimitAt(now, rate.Limit(rps))
+	a.limiter.SetBurstAt(now, burst(rps))
+}
+
+// Rate returns the current rate, in requests per second.
+func (a *Adaptive) Rate() float64 {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	return a.rps
+}
+
+// Tokens returns the number of tokens currently available.
+func (a *Adaptive) Tokens() float64 {
+	return a.limiter.TokensAt(a.now())
+}
+
+// When returns how long the supplied item must wait before it is processed.
+func (a *Adaptive) When(_ any) time.Duration {
+	now := a.now()
+	return a.limiter.ReserveN(now, 1).DelayFrom(now)
+}
+
+// Forget is a no-op. An Adaptive rate limiter doesn't track items.
+func (a *Adaptive) Forget(_ any) {}
+
+// NumRequeues always returns zero. An Adaptive rate limiter doesn't track
+// items.
+func (a *Adaptive) NumRequeues(_ any) int {
+	return 0
+}
+
+func burst(rps float64) int {
+	b := int(rps * 10)
+	if b < 1 {
+		return 1
+	}
+	return b
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
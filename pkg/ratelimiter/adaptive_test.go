@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestAdaptiveReport(t *testing.T) {
+	type args struct {
+		reports []bool
+		elapsed time.Duration
+	}
+
+	cases := map[string]struct {
+		reason string
+		a      *Adaptive
+		args   args
+		want   float64
+	}{
+		"AdditiveIncrease": {
+			reason: "Each success should increase the rate by a fixed amount.",
+			a:      NewAdaptive(10, WithIncrease(0.5)),
+			args:   args{reports: []bool{true, true, true, true}},
+			want:   12,
+		},
+		"MaxRate": {
+			reason: "The rate should not increase beyond the maximum rate.",
+			a:      NewAdaptive(10, WithIncrease(1), WithMaxRate(11)),
+			args:   args{reports: []bool{true, true, true}},
+			want:   11,
+		},
+		"MultiplicativeDecrease": {
+			reason: "A throttled call should multiply the rate by the decrease factor.",
+			a:      NewAdaptive(10, WithDecrease(0.5)),
+			args:   args{reports: []bool{false}},
+			want:   5,
+		},
+		"Cooldown": {
+			reason: "Throttled calls reported during the cooldown should not decrease the rate again.",
+			a:      NewAdaptive(10, WithDecrease(0.5), WithDecreaseCooldown(time.Minute)),
+			args:   args{reports: []bool{false, false, false}, elapsed: time.Second},
+			want:   5,
+		},
+		"AfterCooldown": {
+			reason: "Throttled calls reported after the cooldown should decrease the rate again.",
+			a:      NewAdaptive(10, WithDecrease(0.5), WithDecreaseCooldown(time.Second)),
+			args:   args{reports: []bool{false, false}, elapsed: 2 * time.Second},
+			want:   2.5,
+		},
+		"MinRate": {
+			reason: "The rate should not decrease beyond the minimum rate.",
+			a:      NewAdaptive(10, WithDecrease(0.1), WithMinRate(2), WithDecreaseCooldown(0)),
+			args:   args{reports: []bool{false, false}},
+			want:   2,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			tc.a.now = func() time.Time { return now }
+			for _, success := range tc.args.reports {
+				tc.a.Report(success)
+				now = now.Add(tc.args.elapsed)
+			}
+			if diff := cmp.Diff(tc.want, tc.a.Rate(), cmpopts.EquateApprox(0, 0.0001)); diff != "" {
+				t.Errorf("\n%s\na.Rate(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAdaptiveWhen(t *testing.T) {
+	now := time.Now()
+	a := NewAdaptive(1, WithDecreaseCooldown(0))
+	a.now = func() time.Time { return now }
+
+	// The initial burst of ten is allowed immediately.
+	for i := 0; i < 10; i++ {
+		if d := a.When("item"); d != 0 {
+			t.Fatalf("a.When(...): want no delay during burst, got %s", d)
+		}
+	}
+	if diff := cmp.Diff(time.Second, a.When("item")); diff != "" {
+		t.Errorf("a.When(...): -want, +got:\n%s", diff)
+	}
+
+	// Once the rate has increased, requests should wait less.
+	for i := 0; i < 10; i++ {
+		a.Report(true)
+	}
+	now = now.Add(2 * time.Second)
+	if d := a.When("item"); d != 0 {
+		t.Errorf("a.When(...): want no delay after tokens accrue, got %s", d)
+	}
+	if d := a.When("item"); d >= time.Second {
+		t.Errorf("a.When(...): want delay under one second at an increased rate, got %s", d)
+	}
+}
@@ -33,11 +33,17 @@ func NewGlobal(rps int) *workqueue.BucketRateLimiter {
 	return &workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(rps), rps*10)}
 }
 
+// The base and maximum delays of the rate limiter returned by NewController.
+const (
+	DefaultBaseDelay = 1 * time.Second
+	DefaultMaxDelay  = 60 * time.Second
+)
+
 // NewController returns a rate limiter that takes the maximum delay between the
 // passed rate limiter and a per-item exponential backoff limiter. The
 // exponential backoff limiter has a base delay of 1s and a maximum of 60s.
 func NewController() ratelimiter.RateLimiter {
-	return workqueue.NewItemExponentialFailureRateLimiter(1*time.Second, 60*time.Second)
+	return workqueue.NewItemExponentialFailureRateLimiter(DefaultBaseDelay, DefaultMaxDelay)
 }
 
 // LimitRESTConfig returns a copy of the supplied REST config with rate limits
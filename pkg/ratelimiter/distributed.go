@@ -0,0 +1,274 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/crossplane/crossplane-runtime/internal/lease"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+)
+
+// LabelKeyRateLimitGroup labels the Leases of the members of a distributed
+// rate limit group.
+const LabelKeyRateLimitGroup = "crossplane.io/rate-limit-group"
+
+// Distributed rate limiter defaults.
+const (
+	DefaultDistributedLeaseDuration = 15 * time.Second
+	DefaultDistributedRenewInterval = 5 * time.Second
+	DefaultDistributedFallbackRate  = 1.0
+)
+
+// A Distributed rate limiter splits a budget of requests per second evenly
+// between the live members of a rate limit group, for example the replicas of
+// a provider, or several providers that share a cloud account. Members
+// announce themselves by renewing a coordination.k8s.io Lease, and are removed
+// from the group when their Lease expires. Each member recomputes its share of
+// the budget when it renews its Lease.
+//
+// A member that can't reach the API server can't know whether other members
+// joined the group. It keeps its last known share until its own Lease would
+// expire, then falls back to a conservative fallback rate until it can reach
+// the API server again. Members use the fallback rate until they first join
+// the group. Like the limiter returned by NewGlobal, a member's burst is ten
+// times its rate.
+type Distributed struct {
+	member lease.Member
+	budget float64
+
+	duration time.Duration
+	renew    time.Duration
+	fallback float64
+	log      logging.Logger
+
+	limiter *rate.Limiter
+
+	mx       sync.RWMutex
+	members  int
+	share    float64
+	lastSync time.Time
+}
+
+var (
+	_ workqueue.RateLimiter          = &Distributed{}
+	_ manager.Runnable               = &Distributed{}
+	_ manager.LeaderElectionRunnable = &Distributed{}
+)
+
+// A DistributedOption configures a Distributed rate limiter.
+type DistributedOption func(d *Distributed)
+
+// WithLeaseDuration configures how long a member's Lease is valid for after
+// it was last renewed. DefaultDistributedLeaseDuration is used by default.
+func WithLeaseDuration(d time.Duration) DistributedOption {
+	return func(dl *Distributed) {
+		dl.duration = d
+	}
+}
+
+// WithRenewInterval configures how often a member renews its Lease and
+// recomputes its share of the budget. DefaultDistributedRenewInterval is used
+// by default.
+func WithRenewInterval(d time.Duration) DistributedOption {
+	return func(dl *Distributed) {
+		dl.renew = d
+	}
+}
+
+// WithFallbackRate configures the rate, in requests per second, a member uses
+// when it doesn't know how many members are in the group.
+// DefaultDistributedFallbackRate is used by default.
+func WithFallbackRate(rps float64) DistributedOption {
+	return func(dl *Distributed) {
+		dl.fallback = rps
+	}
+}
+
+// WithLogger configures the Distributed rate limiter's logger.
+func WithLogger(l logging.Logger) DistributedOption {
+	return func(dl *Distributed) {
+		dl.log = l
+	}
+}
+
+// NewDistributed returns a Distributed rate limiter that is a member of the
+// supplied rate limit group. The group's members share the supplied budget of
+// requests per second. Leases are stored in the supplied namespace. The
+// identity must be unique within the group, and a valid Kubernetes object
+// name - e.g. the name of the replica's pod.
+func NewDistributed(c client.Client, namespace, group, identity string, budget int, o ...DistributedOption) *Distributed {
+	d := &Distributed{
+		budget:   float64(budget),
+		duration: DefaultDistributedLeaseDuration,
+		renew:    DefaultDistributedRenewInterval,
+		fallback: DefaultDistributedFallbackRate,
+		log:      logging.NewNopLogger(),
+	}
+	for _, fn := range o {
+		fn(d)
+	}
+	d.member = lease.Member{
+		Client:    c,
+		Namespace: namespace,
+		LabelKey:  LabelKeyRateLimitGroup,
+		Group:     group,
+		Identity:  identity,
+		Duration:  d.duration,
+	}
+	d.share = d.fallback
+	d.limiter = rate.NewLimiter(rate.Limit(d.share), burst(d.share))
+	return d
+}
+
+// NeedLeaderElection returns false. Every replica must join its rate limit
+// group.
+func (d *Distributed) NeedLeaderElection() bool {
+	return false
+}
+
+// Start joins the rate limit group, and keeps the member's share of the
+// budget up to date until the supplied context is done. It leaves the group
+// by deleting its Lease when it returns, so that other members can increase
+// their share without waiting for its Lease to expire.
+func (d *Distributed) Start(ctx context.Context) error {
+	t := time.NewTicker(d.renew)
+	defer t.Stop()
+
+	for {
+		if err := d.sync(ctx, time.Now()); err != nil {
+			d.log.Info("Cannot sync rate limit group membership", "group", d.member.Group, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			d.leave()
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// sync renews the member's Lease, and recomputes its share of the budget. If
+// it can't, it falls back to the fallback rate once its last known share is
+// stale.
+func (d *Distributed) sync(ctx context.Context, now time.Time) error {
+	members, err := d.count(ctx, now)
+	if err != nil {
+		d.mx.RLock()
+		stale := now.Sub(d.lastSync) > d.duration
+		d.mx.RUnlock()
+		if stale {
+			d.setShare(now, 0, d.fallback)
+		}
+		return err
+	}
+
+	d.mx.Lock()
+	d.lastSync = now
+	d.mx.Unlock()
+	d.setShare(now, members, d.budget/float64(members))
+	return nil
+}
+
+// count renews the member's Lease and returns the number of live members of
+// the group, including this one.
+func (d *Distributed) count(ctx context.Context, now time.Time) (int, error) {
+	if err := d.member.Renew(ctx, now); err != nil {
+		return 0, err
+	}
+	live, err := d.member.Live(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	// We count ourselves even if our Lease isn't listed yet, for example
+	// because the client reads from a cache that hasn't seen it.
+	members := 1
+	for _, id := range live {
+		if id != d.member.Identity {
+			members++
+		}
+	}
+	return members, nil
+}
+
+func (d *Distributed) leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), d.renew)
+	defer cancel()
+
+	if err := d.member.Leave(ctx); err != nil {
+		d.log.Info("Cannot leave rate limit group", "group", d.member.Group, "error", err)
+	}
+}
+
+// setShare sets the member's share of the budget. Zero members means the
+// number of members is unknown.
+func (d *Distributed) setShare(now time.Time, members int, share float64) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	if d.members == members && d.share == share {
+		return
+	}
+	d.log.Debug("Rate limit share changed", "group", d.member.Group, "members", members, "rps", share)
+	d.members = members
+	d.share = share
+	d.limiter.SetLimitAt(now, rate.Limit(share))
+	d.limiter.SetBurstAt(now, burst(share))
+}
+
+// Members returns the number of live members of the rate limit group, as of
+// when this member last synced. It returns zero if the number is unknown.
+func (d *Distributed) Members() int {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	return d.members
+}
+
+// Rate returns this member's share of the budget, in requests per second.
+func (d *Distributed) Rate() float64 {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	return d.share
+}
+
+// Tokens returns the number of tokens currently available.
+func (d *Distributed) Tokens() float64 {
+	return d.limiter.Tokens()
+}
+
+// When returns how long the supplied item must wait before it is processed.
+func (d *Distributed) When(_ any) time.Duration {
+	return d.limiter.Reserve().Delay()
+}
+
+// Forget is a no-op. A Distributed rate limiter doesn't track items.
+func (d *Distributed) Forget(_ any) {}
+
+// NumRequeues always returns zero. A Distributed rate limiter doesn't track
+// items.
+func (d *Distributed) NumRequeues(_ any) int {
+	return 0
+}
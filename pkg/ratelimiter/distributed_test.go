@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestDistributed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+
+	a := NewDistributed(c, "crossplane-system", "aws", "a", 100, WithFallbackRate(2))
+	b := NewDistributed(c, "crossplane-system", "aws", "b", 100, WithFallbackRate(2))
+
+	// Members use the fallback rate until they've joined the group.
+	if diff := cmp.Diff(2.0, a.Rate()); diff != "" {
+		t.Errorf("a.Rate(): -want, +got:\n%s", diff)
+	}
+
+	if err := a.sync(ctx, now); err != nil {
+		t.Fatalf("a.sync(...): %s", err)
+	}
+	if diff := cmp.Diff(100.0, a.Rate()); diff != "" {
+		t.Errorf("a.Rate(): -want, +got:\n%s", diff)
+	}
+
+	// Members should split the budget once they notice another member join.
+	for _, d := range []*Distributed{b, a} {
+		if err := d.sync(ctx, now); err != nil {
+			t.Fatalf("d.sync(...): %s", err)
+		}
+		if diff := cmp.Diff(2, d.Members()); diff != "" {
+			t.Errorf("d.Members(): -want, +got:\n%s", diff)
+		}
+		if diff := cmp.Diff(50.0, d.Rate()); diff != "" {
+			t.Errorf("d.Rate(): -want, +got:\n%s", diff)
+		}
+	}
+
+	// Members should take over the budget of members whose Lease expired.
+	later := now.Add(time.Minute)
+	if err := a.sync(ctx, later); err != nil {
+		t.Fatalf("a.sync(...): %s", err)
+	}
+	if diff := cmp.Diff(100.0, a.Rate()); diff != "" {
+		t.Errorf("a.Rate(): -want, +got:\n%s", diff)
+	}
+
+	// Leaving the group should delete the member's Lease.
+	a.leave()
+	err := c.Get(ctx, types.NamespacedName{Namespace: "crossplane-system", Name: "aws-a"}, &coordinationv1.Lease{})
+	if client.IgnoreNotFound(err) != nil || err == nil {
+		t.Errorf("c.Get(...): want not found error, got %v", err)
+	}
+}
+
+func TestDistributedFallback(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	errBoom := errors.New("boom")
+
+	mc := &test.MockClient{
+		MockGet:    test.NewMockGetFn(nil),
+		MockUpdate: test.NewMockUpdateFn(nil),
+		MockList:   test.NewMockListFn(nil),
+	}
+	d := NewDistributed(mc, "crossplane-system", "aws", "a", 100, WithFallbackRate(2), WithLeaseDuration(15*time.Second))
+	if err := d.sync(ctx, now); err != nil {
+		t.Fatalf("d.sync(...): %s", err)
+	}
+
+	// The API server becomes unavailable.
+	mc.MockGet = test.NewMockGetFn(errBoom)
+
+	// We keep our last known share until our Lease would have expired...
+	err := d.sync(ctx, now.Add(10*time.Second))
+	if !errors.Is(err, errBoom) {
+		t.Errorf("d.sync(...): want error %q, got %v", errBoom, err)
+	}
+	if diff := cmp.Diff(100.0, d.Rate()); diff != "" {
+		t.Errorf("d.Rate(): -want, +got:\n%s", diff)
+	}
+
+	// ...then fall back to the fallback rate.
+	_ = d.sync(ctx, now.Add(20*time.Second))
+	if diff := cmp.Diff(2.0, d.Rate()); diff != "" {
+		t.Errorf("d.Rate(): -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(0, d.Members()); diff != "" {
+		t.Errorf("d.Members(): -want, +got:\n%s", diff)
+	}
+}
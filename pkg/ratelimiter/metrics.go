@@ -0,0 +1,236 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+)
+
+// Metrics are Prometheus metrics for rate limiting Reconcilers, and rate
+// limiters wrapped by Instrument. Register them with a Prometheus registry,
+// e.g. controller-runtime's metrics.Registry.
+type Metrics struct {
+	throttled     *prometheus.CounterVec
+	throttleDelay *prometheus.HistogramVec
+	delay         *prometheus.HistogramVec
+
+	limitedDesc *prometheus.Desc
+	tokensDesc  *prometheus.Desc
+
+	mx      sync.RWMutex
+	limited map[string]func() float64
+	tokens  map[string]func() float64
+}
+
+// NewMetrics returns new rate limiter metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		throttled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Subsystem: "ratelimiter",
+			Name:      "throttled_total",
+			Help:      "The number of reconcile requests a controller's rate limiting Reconciler throttled.",
+		}, []string{"controller"}),
+		throttleDelay: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Subsystem: "ratelimiter",
+			Name:      "throttle_delay_seconds",
+			Help:      "How long a controller's rate limiting Reconciler told throttled reconcile requests to wait.",
+			Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300},
+		}, []string{"controller"}),
+		delay: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Subsystem: "ratelimiter",
+			Name:      "delay_seconds",
+			Help:      "How long a rate limiter told items to wait, including items that didn't need to wait.",
+			Buckets:   []float64{0, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300},
+		}, []string{"limiter"}),
+		limitedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("", "ratelimiter", "limited_requests"),
+			"The number of throttled reconcile requests a controller's rate limiting Reconciler is waiting to return.",
+			[]string{"controller"}, nil),
+		tokensDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("", "ratelimiter", "tokens"),
+			"The number of tokens available in a token bucket rate limiter.",
+			[]string{"limiter"}, nil),
+		limited: make(map[string]func() float64),
+		tokens:  make(map[string]func() float64),
+	}
+}
+
+// Describe sends the descriptors of the metrics to the supplied channel.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.throttled.Describe(ch)
+	m.throttleDelay.Describe(ch)
+	m.delay.Describe(ch)
+	ch <- m.limitedDesc
+	ch <- m.tokensDesc
+}
+
+// Collect sends the metrics to the supplied channel.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.throttled.Collect(ch)
+	m.throttleDelay.Collect(ch)
+	m.delay.Collect(ch)
+
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	for name, fn := range m.limited {
+		ch <- prometheus.MustNewConstMetric(m.limitedDesc, prometheus.GaugeValue, fn(), name)
+	}
+	for name, fn := range m.tokens {
+		ch <- prometheus.MustNewConstMetric(m.tokensDesc, prometheus.GaugeValue, fn(), name)
+	}
+}
+
+var _ prometheus.Collector = &Metrics{}
+
+// Forget the metrics of the rate limiting Reconciler or instrumented rate
+// limiter with the supplied name. Call it when a controller that uses a
+// Reconciler or rate limiter that won't be used again is stopped, so that its
+// metrics aren't exported forever. An Engine configured using
+// controller.WithRateLimiterMetrics forgets the metrics of each controller it
+// stops, assuming they were recorded using its name.
+func (m *Metrics) Forget(name string) {
+	if m == nil {
+		return
+	}
+	m.throttled.DeleteLabelValues(name)
+	m.throttleDelay.DeleteLabelValues(name)
+	m.delay.DeleteLabelValues(name)
+
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	delete(m.limited, name)
+	delete(m.tokens, name)
+}
+
+// All of the below methods may be called on nil Metrics, which record
+// nothing.
+
+func (m *Metrics) throttledRequest(controller string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.throttled.WithLabelValues(controller).Inc()
+	m.throttleDelay.WithLabelValues(controller).Observe(d.Seconds())
+}
+
+func (m *Metrics) delayed(limiter string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.delay.WithLabelValues(limiter).Observe(d.Seconds())
+}
+
+func (m *Metrics) observeLimited(controller string, fn func() float64) {
+	if m == nil {
+		return
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.limited[controller] = fn
+}
+
+func (m *Metrics) observeTokens(limiter string, fn func() float64) {
+	if m == nil {
+		return
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.tokens[limiter] = fn
+}
+
+// An InstrumentOption configures how a rate limiter or Reconciler is
+// instrumented.
+type InstrumentOption func(i *instrumentation)
+
+type instrumentation struct {
+	metrics *Metrics
+	log     logging.Logger
+}
+
+func newInstrumentation(o ...InstrumentOption) instrumentation {
+	i := instrumentation{log: logging.NewNopLogger()}
+	for _, fn := range o {
+		fn(&i)
+	}
+	return i
+}
+
+// WithMetrics records metrics using the supplied Metrics.
+func WithMetrics(m *Metrics) InstrumentOption {
+	return func(i *instrumentation) {
+		i.metrics = m
+	}
+}
+
+// WithDebugLogger emits debug logs using the supplied logger.
+func WithDebugLogger(l logging.Logger) InstrumentOption {
+	return func(i *instrumentation) {
+		i.log = l
+	}
+}
+
+// A tokenBucket reports how many tokens it has available.
+type tokenBucket interface {
+	Tokens() float64
+}
+
+// Instrument wraps the supplied rate limiter, for example one returned by
+// NewGlobal or NewController, such that it records how long it tells items to
+// wait, and logs items it delays. The number of tokens available is recorded
+// for token bucket rate limiters, including those returned by NewGlobal,
+// NewAdaptive, and NewDistributed. The supplied name must be unique among the
+// rate limiters instrumented using the same Metrics.
+func Instrument(name string, l ratelimiter.RateLimiter, o ...InstrumentOption) ratelimiter.RateLimiter {
+	il := &instrumented{name: name, inner: l, instrumentation: newInstrumentation(o...)}
+	switch tb := l.(type) {
+	case *workqueue.BucketRateLimiter:
+		il.metrics.observeTokens(name, tb.Limiter.Tokens)
+	case tokenBucket:
+		il.metrics.observeTokens(name, tb.Tokens)
+	}
+	return il
+}
+
+type instrumented struct {
+	instrumentation
+	name  string
+	inner ratelimiter.RateLimiter
+}
+
+func (l *instrumented) When(item any) time.Duration {
+	d := l.inner.When(item)
+	l.metrics.delayed(l.name, d)
+	if d > 0 {
+		l.log.Debug("Rate limiter delayed item", "limiter", l.name, "item", item, "delay", d)
+	}
+	return d
+}
+
+func (l *instrumented) Forget(item any) {
+	l.inner.Forget(item)
+}
+
+func (l *instrumented) NumRequeues(item any) int {
+	return l.inner.NumRequeues(item)
+}
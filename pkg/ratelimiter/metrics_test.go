@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcilerMetrics(t *testing.T) {
+	m := NewMetrics()
+	r := NewReconciler("cool", nil, &predictableRateLimiter{d: 8 * time.Second}, WithMetrics(m))
+
+	for _, name := range []string{"a", "b"} {
+		if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: name}}); err != nil {
+			t.Fatalf("r.Reconcile(...): %s", err)
+		}
+	}
+
+	if diff := cmp.Diff(2.0, testutil.ToFloat64(m.throttled.WithLabelValues("cool"))); diff != "" {
+		t.Errorf("throttled: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(1, testutil.CollectAndCount(m.throttleDelay)); diff != "" {
+		t.Errorf("throttleDelay: -want, +got:\n%s", diff)
+	}
+
+	want := `
+# HELP ratelimiter_limited_requests The number of throttled reconcile requests a controller's rate limiting Reconciler is waiting to return.
+# TYPE ratelimiter_limited_requests gauge
+ratelimiter_limited_requests{controller="cool"} 2
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want), "ratelimiter_limited_requests"); err != nil {
+		t.Errorf("limited: %s", err)
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	m := NewMetrics()
+	l := Instrument("global", NewGlobal(1), WithMetrics(m))
+
+	// The bucket holds ten tokens. The eleventh item must wait.
+	for i := 0; i < 11; i++ {
+		l.When("item")
+	}
+
+	if diff := cmp.Diff(1, testutil.CollectAndCount(m.delay)); diff != "" {
+		t.Errorf("delay: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(1, testutil.CollectAndCount(m, "ratelimiter_tokens")); diff != "" {
+		t.Errorf("tokens: -want, +got:\n%s", diff)
+	}
+	if tokens := m.tokens["global"](); tokens >= 0 {
+		t.Errorf("tokens: want a negative number of tokens after exceeding the burst, got %f", tokens)
+	}
+}
+
+func TestMetricsForget(t *testing.T) {
+	m := NewMetrics()
+	r := NewReconciler("cool", nil, &predictableRateLimiter{d: 8 * time.Second}, WithMetrics(m))
+	l := Instrument("cool", NewGlobal(1), WithMetrics(m))
+
+	if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "a"}}); err != nil {
+		t.Fatalf("r.Reconcile(...): %s", err)
+	}
+	l.When("a")
+
+	m.Forget("cool")
+
+	if diff := cmp.Diff(0, testutil.CollectAndCount(m)); diff != "" {
+		t.Errorf("m.Forget(...): Forgotten metrics should not be collected: -want, +got:\n%s", diff)
+	}
+}
@@ -29,6 +29,8 @@ import (
 // limited immediately return RequeueAfter: d without calling the wrapped
 // Reconciler, where d is imposed by the rate limiter.
 type Reconciler struct {
+	instrumentation
+
 	name  string
 	inner reconcile.Reconciler
 	limit ratelimiter.RateLimiter
@@ -40,14 +42,24 @@ type Reconciler struct {
 // NewReconciler wraps the supplied Reconciler, ensuring requests are passed to
 // it no more frequently than the supplied RateLimiter allows. Multiple uniquely
 // named Reconcilers can share the same RateLimiter.
-func NewReconciler(name string, r reconcile.Reconciler, l ratelimiter.RateLimiter) *Reconciler {
-	return &Reconciler{name: name, inner: r, limit: l, limited: make(map[string]struct{})}
+func NewReconciler(name string, r reconcile.Reconciler, l ratelimiter.RateLimiter, o ...InstrumentOption) *Reconciler {
+	rr := &Reconciler{
+		instrumentation: newInstrumentation(o...),
+		name:            name,
+		inner:           r,
+		limit:           l,
+		limited:         make(map[string]struct{}),
+	}
+	rr.metrics.observeLimited(name, rr.numLimited)
+	return rr
 }
 
 // Reconcile the supplied request subject to rate limiting.
 func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
 	item := r.name + req.String()
 	if d := r.when(req); d > 0 {
+		r.metrics.throttledRequest(r.name, d)
+		r.log.Debug("Throttled reconcile request", "controller", r.name, "request", req, "requeue-after", d)
 		return reconcile.Result{RequeueAfter: d}, nil
 	}
 	r.limit.Forget(item)
@@ -87,3 +99,11 @@ func (r *Reconciler) when(req reconcile.Request) time.Duration {
 
 	return d
 }
+
+// numLimited returns the number of requests that were rate limited, and have
+// not yet returned.
+func (r *Reconciler) numLimited() float64 {
+	r.limitedL.RLock()
+	defer r.limitedL.RUnlock()
+	return float64(len(r.limited))
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managed
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// Error strings.
+const (
+	errMarshalAuditRecord = "cannot marshal audit record"
+	errWriteAuditRecord   = "cannot write audit record"
+	errSyncAuditRecord    = "cannot sync audit record"
+	errOpenAuditFile      = "cannot open audit file"
+)
+
+// An AuditOperation is a mutation of an external resource.
+type AuditOperation string
+
+// Audited operations.
+const (
+	AuditOperationCreate AuditOperation = "Create"
+	AuditOperationUpdate AuditOperation = "Update"
+	AuditOperationDelete AuditOperation = "Delete"
+)
+
+// An AuditOutcome is the result of an AuditOperation.
+type AuditOutcome string
+
+// Audited outcomes.
+const (
+	AuditOutcomeSuccess AuditOutcome = "Success"
+	AuditOutcomeFailure AuditOutcome = "Failure"
+)
+
+// An AuditRecord records that the managed resource reconciler attempted to
+// mutate an external resource.
+type AuditRecord struct {
+	// Timestamp at which the operation completed.
+	Timestamp time.Time `json:"timestamp"`
+
+	// Operation that was attempted.
+	Operation AuditOperation `json:"operation"`
+
+	// Outcome of the operation.
+	Outcome AuditOutcome `json:"outcome"`
+
+	// Error returned by the operation, if it failed.
+	Error string `json:"error,omitempty"`
+
+	// APIVersion of the managed resource.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the managed resource.
+	Kind string `json:"kind"`
+
+	// Name of the managed resource.
+	Name string `json:"name"`
+
+	// ExternalName of the managed resource when the operation completed.
+	ExternalName string `json:"externalName,omitempty"`
+
+	// ProviderConfig used to connect to the external system.
+	ProviderConfig string `json:"providerConfig,omitempty"`
+
+	// Diff between the desired and observed state of the external resource
+	// that triggered the operation, if the ExternalClient reported one.
+	Diff string `json:"diff,omitempty"`
+}
+
+// An AuditSink records mutations of external resources.
+type AuditSink interface {
+	// Record the supplied AuditRecord. Records must be appended; an AuditSink
+	// must never modify or remove a previously recorded AuditRecord.
+	Record(ctx context.Context, r AuditRecord) error
+}
+
+// An AuditSinkFn is a function that satisfies the AuditSink interface.
+type AuditSinkFn func(ctx context.Context, r AuditRecord) error
+
+// Record the supplied AuditRecord.
+func (fn AuditSinkFn) Record(ctx context.Context, r AuditRecord) error {
+	return fn(ctx, r)
+}
+
+// A NopAuditSink does nothing.
+type NopAuditSink struct{}
+
+// Record does nothing. It never returns an error.
+func (s NopAuditSink) Record(_ context.Context, _ AuditRecord) error { return nil }
+
+// A JSONLinesAuditSink records each AuditRecord as a line of JSON.
+type JSONLinesAuditSink struct {
+	w  io.Writer
+	mx sync.Mutex
+}
+
+// NewJSONLinesAuditSink returns an AuditSink that writes each AuditRecord to
+// the supplied writer as a line of JSON.
+func NewJSONLinesAuditSink(w io.Writer) *JSONLinesAuditSink {
+	return &JSONLinesAuditSink{w: w}
+}
+
+// NewStdoutAuditSink returns an AuditSink that writes each AuditRecord to
+// stdout as a line of JSON.
+func NewStdoutAuditSink() *JSONLinesAuditSink {
+	return NewJSONLinesAuditSink(os.Stdout)
+}
+
+// NewFileAuditSink returns an AuditSink that appends each AuditRecord to the
+// supplied file as a line of JSON. The file is created if it does not exist.
+// Each record is synced to stable storage before Record returns. Callers
+// should Close the returned AuditSink when they are done with it.
+func NewFileAuditSink(path string) (*JSONLinesAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gosec // The path is supplied by the operator.
+	if err != nil {
+		return nil, errors.Wrap(err, errOpenAuditFile)
+	}
+	return NewJSONLinesAuditSink(f), nil
+}
+
+// Record the supplied AuditRecord as a line of JSON.
+func (s *JSONLinesAuditSink) Record(_ context.Context, r AuditRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, errMarshalAuditRecord)
+	}
+	b = append(b, '\n')
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	// A single write call ensures records are not interleaved, even if the
+	// underlying writer is shared with other processes (e.g. O_APPEND files).
+	if _, err := s.w.Write(b); err != nil {
+		return errors.Wrap(err, errWriteAuditRecord)
+	}
+	if sy, ok := s.w.(interface{ Sync() error }); ok && s.w != os.Stdout {
+		return errors.Wrap(sy.Sync(), errSyncAuditRecord)
+	}
+	return nil
+}
+
+// Close the underlying writer, if it can be closed. Stdout is never closed.
+func (s *JSONLinesAuditSink) Close() error {
+	c, ok := s.w.(io.Closer)
+	if !ok || s.w == os.Stdout {
+		return nil
+	}
+	return c.Close()
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managed
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+var _ AuditSink = &JSONLinesAuditSink{}
+
+func TestJSONLinesAuditSink(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	cases := map[string]struct {
+		reason string
+		r      []AuditRecord
+		want   string
+	}{
+		"Success": {
+			reason: "A successful operation should be written as a line of JSON without an error.",
+			r: []AuditRecord{{
+				Timestamp:    ts,
+				Operation:    AuditOperationCreate,
+				Outcome:      AuditOutcomeSuccess,
+				APIVersion:   "example.org/v1",
+				Kind:         "Bucket",
+				Name:         "cool",
+				ExternalName: "cool-bucket",
+			}},
+			want: `{"timestamp":"2024-01-02T03:04:05Z","operation":"Create","outcome":"Success","apiVersion":"example.org/v1","kind":"Bucket","name":"cool","externalName":"cool-bucket"}` + "\n",
+		},
+		"Appended": {
+			reason: "Each record should be appended as its own line.",
+			r: []AuditRecord{
+				{Timestamp: ts, Operation: AuditOperationUpdate, Outcome: AuditOutcomeSuccess, Diff: "-a +b"},
+				{Timestamp: ts, Operation: AuditOperationDelete, Outcome: AuditOutcomeFailure, Error: "boom"},
+			},
+			want: `{"timestamp":"2024-01-02T03:04:05Z","operation":"Update","outcome":"Success","apiVersion":"","kind":"","name":"","diff":"-a +b"}` + "\n" +
+				`{"timestamp":"2024-01-02T03:04:05Z","operation":"Delete","outcome":"Failure","error":"boom","apiVersion":"","kind":"","name":""}` + "\n",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			s := NewJSONLinesAuditSink(b)
+			for _, r := range tc.r {
+				if err := s.Record(context.Background(), r); err != nil {
+					t.Fatalf("\n%s\ns.Record(...): %s", tc.reason, err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+				t.Errorf("\n%s\ns.Record(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// Records should be appended across sinks that use the same file.
+	for i := 0; i < 2; i++ {
+		s, err := NewFileAuditSink(path)
+		if err != nil {
+			t.Fatalf("NewFileAuditSink(...): %s", err)
+		}
+		if err := s.Record(context.Background(), AuditRecord{Operation: AuditOperationCreate}); err != nil {
+			t.Fatalf("s.Record(...): %s", err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("s.Close(): %s", err)
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile(...): %s", err)
+	}
+	if got := bytes.Count(b, []byte("\n")); got != 2 {
+		t.Errorf("NewFileAuditSink(...): want 2 records, got %d", got)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("os.Stat(...): %s", err)
+	}
+	if diff := cmp.Diff(os.FileMode(0o600), fi.Mode().Perm()); diff != "" {
+		t.Errorf("NewFileAuditSink(...): -want mode, +got mode:\n%s", diff)
+	}
+}
+
+func TestReconcilerAudit(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	mg := func() resource.Managed {
+		m := &fake.Managed{}
+		m.SetName("cool")
+		meta.SetExternalName(m, "cool-bucket")
+		m.SetProviderConfigReference(&xpv1.Reference{Name: "default"})
+		return m
+	}
+
+	type want struct {
+		r []AuditRecord
+	}
+
+	cases := map[string]struct {
+		reason string
+		c      ExternalClient
+		want   want
+	}{
+		"UpdateSuccessful": {
+			reason: "A successful update should be recorded along with the diff that triggered it.",
+			c: &ExternalClientFns{
+				ObserveFn: func(_ context.Context, _ resource.Managed) (ExternalObservation, error) {
+					return ExternalObservation{ResourceExists: true, Diff: "-a +b"}, nil
+				},
+				UpdateFn: func(_ context.Context, _ resource.Managed) (ExternalUpdate, error) {
+					return ExternalUpdate{}, nil
+				},
+			},
+			want: want{
+				r: []AuditRecord{{
+					Operation:      AuditOperationUpdate,
+					Outcome:        AuditOutcomeSuccess,
+					APIVersion:     fake.GV.String(),
+					Kind:           "Managed",
+					Name:           "cool",
+					ExternalName:   "cool-bucket",
+					ProviderConfig: "default",
+					Diff:           "-a +b",
+				}},
+			},
+		},
+		"UpdateFailed": {
+			reason: "A failed update should be recorded along with its error.",
+			c: &ExternalClientFns{
+				ObserveFn: func(_ context.Context, _ resource.Managed) (ExternalObservation, error) {
+					return ExternalObservation{ResourceExists: true}, nil
+				},
+				UpdateFn: func(_ context.Context, _ resource.Managed) (ExternalUpdate, error) {
+					return ExternalUpdate{}, errBoom
+				},
+			},
+			want: want{
+				r: []AuditRecord{{
+					Operation:      AuditOperationUpdate,
+					Outcome:        AuditOutcomeFailure,
+					Error:          errBoom.Error(),
+					APIVersion:     fake.GV.String(),
+					Kind:           "Managed",
+					Name:           "cool",
+					ExternalName:   "cool-bucket",
+					ProviderConfig: "default",
+				}},
+			},
+		},
+		"UpToDate": {
+			reason: "Nothing should be recorded if the external resource was not mutated.",
+			c: &ExternalClientFns{
+				ObserveFn: func(_ context.Context, _ resource.Managed) (ExternalObservation, error) {
+					return ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil
+				},
+			},
+			want: want{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got []AuditRecord
+			m := &fake.Manager{
+				Client: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(o client.Object) error {
+						*o.(*fake.Managed) = *mg().(*fake.Managed)
+						return nil
+					}),
+					MockStatusUpdate: test.NewMockSubResourceUpdateFn(nil),
+				},
+				Scheme: fake.SchemeWith(&fake.Managed{}),
+			}
+			r := NewReconciler(m, resource.ManagedKind(fake.GVK(&fake.Managed{})),
+				WithInitializers(),
+				WithReferenceResolver(ReferenceResolverFn(func(_ context.Context, _ resource.Managed) error { return nil })),
+				WithExternalConnecter(ExternalConnectorFn(func(_ context.Context, _ resource.Managed) (ExternalClient, error) { return tc.c, nil })),
+				WithConnectionPublishers(),
+				WithFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error { return nil }}),
+				WithAuditSink(AuditSinkFn(func(_ context.Context, r AuditRecord) error {
+					got = append(got, r)
+					return nil
+				})),
+			)
+			if _, err := r.Reconcile(context.Background(), reconcile.Request{}); err != nil {
+				t.Fatalf("\n%s\nr.Reconcile(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.r, got, cmpopts.IgnoreFields(AuditRecord{}, "Timestamp")); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want records, +got records:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
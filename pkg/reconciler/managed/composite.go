@@ -0,0 +1,192 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managed
+
+import (
+	"context"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// Error strings.
+const (
+	errFmtObservePart = "cannot observe %s"
+	errFmtCreatePart  = "cannot create %s"
+	errFmtUpdatePart  = "cannot update %s"
+	errFmtDeletePart  = "cannot delete %s"
+)
+
+// A NamedExternalClient is an ExternalClient that manages one part of an
+// external resource that is composed of several external objects.
+type NamedExternalClient struct {
+	// Name of the part of the external resource this client manages, for
+	// example 'bucket policy'. Used in error messages and diffs.
+	Name string
+
+	ExternalClient
+}
+
+// A CompositeExternalClient is an ExternalClient for a managed resource that
+// corresponds to several external objects, for example a bucket along with its
+// policy and lifecycle rules. Each external object is managed by one of an
+// ordered list of ExternalClients. The order is the dependency order; objects
+// are created and updated in order, and deleted in reverse order.
+//
+// A CompositeExternalClient remembers the result of its most recent
+// observation and uses it to only create, update, or delete the parts that need
+// it. It is therefore not safe to share between reconciles; an
+// ExternalConnecter should return a new CompositeExternalClient each time it is
+// called.
+type CompositeExternalClient struct {
+	parts []NamedExternalClient
+
+	// The most recent observation of each part, if any.
+	observed []ExternalObservation
+}
+
+// NewCompositeExternalClient returns an ExternalClient that composes the
+// supplied ExternalClients, in dependency order.
+func NewCompositeExternalClient(parts ...NamedExternalClient) *CompositeExternalClient {
+	return &CompositeExternalClient{parts: parts}
+}
+
+// Observe each part of the external resource. The external resource exists and
+// is up to date only when all of its parts are. Its connection details are the
+// union of those of its parts; when parts return the same key the later part
+// wins.
+//
+// Parts are observed in dependency order. Observation stops at the first part
+// that does not exist; the parts after it are reported not to exist, so that
+// they're created in order after the part they depend on.
+//
+// All parts are observed when the managed resource has been deleted, and the
+// external resource is reported to exist if any of its parts exist. This
+// ensures parts that were created before a partial creation failure are
+// deleted.
+func (c *CompositeExternalClient) Observe(ctx context.Context, mg resource.Managed) (ExternalObservation, error) {
+	c.observed = make([]ExternalObservation, len(c.parts))
+
+	all := ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: ConnectionDetails{}}
+	anyExists := false
+	diffs := make([]string, 0, len(c.parts))
+	for i, p := range c.parts {
+		o, err := p.Observe(ctx, mg)
+		if err != nil {
+			c.observed = nil
+			return ExternalObservation{}, errors.Wrapf(err, errFmtObservePart, p.Name)
+		}
+		c.observed[i] = o
+
+		all.ResourceExists = all.ResourceExists && o.ResourceExists
+		all.ResourceUpToDate = all.ResourceUpToDate && o.ResourceUpToDate
+		all.ResourceLateInitialized = all.ResourceLateInitialized || o.ResourceLateInitialized
+		anyExists = anyExists || o.ResourceExists
+		for k, v := range o.ConnectionDetails {
+			all.ConnectionDetails[k] = v
+		}
+		if o.Diff != "" {
+			diffs = append(diffs, p.Name+":\n"+o.Diff)
+		}
+		if !o.ResourceExists && !meta.WasDeleted(mg) {
+			break
+		}
+	}
+
+	if meta.WasDeleted(mg) {
+		all.ResourceExists = anyExists
+	}
+	all.ResourceUpToDate = all.ResourceExists && all.ResourceUpToDate
+	all.Diff = strings.Join(diffs, "\n")
+	return all, nil
+}
+
+// Create the parts of the external resource that do not exist, in order. Parts
+// that the most recent observation found to exist are not created again.
+func (c *CompositeExternalClient) Create(ctx context.Context, mg resource.Managed) (ExternalCreation, error) {
+	all := ExternalCreation{ConnectionDetails: ConnectionDetails{}}
+	for i, p := range c.parts {
+		if c.exists(i) {
+			continue
+		}
+		cr, err := p.Create(ctx, mg)
+		if err != nil {
+			return all, errors.Wrapf(err, errFmtCreatePart, p.Name)
+		}
+		for k, v := range cr.ConnectionDetails {
+			all.ConnectionDetails[k] = v
+		}
+	}
+	return all, nil
+}
+
+// Update the parts of the external resource that are not up to date, in order.
+// Parts that do not exist are created.
+func (c *CompositeExternalClient) Update(ctx context.Context, mg resource.Managed) (ExternalUpdate, error) {
+	all := ExternalUpdate{ConnectionDetails: ConnectionDetails{}}
+	for i, p := range c.parts {
+		if !c.exists(i) {
+			cr, err := p.Create(ctx, mg)
+			if err != nil {
+				return all, errors.Wrapf(err, errFmtCreatePart, p.Name)
+			}
+			for k, v := range cr.ConnectionDetails {
+				all.ConnectionDetails[k] = v
+			}
+			continue
+		}
+		if c.upToDate(i) {
+			continue
+		}
+		u, err := p.Update(ctx, mg)
+		if err != nil {
+			return all, errors.Wrapf(err, errFmtUpdatePart, p.Name)
+		}
+		for k, v := range u.ConnectionDetails {
+			all.ConnectionDetails[k] = v
+		}
+	}
+	return all, nil
+}
+
+// Delete the parts of the external resource that exist, in reverse order.
+func (c *CompositeExternalClient) Delete(ctx context.Context, mg resource.Managed) error {
+	for i := len(c.parts) - 1; i >= 0; i-- {
+		if c.observed != nil && !c.observed[i].ResourceExists {
+			continue
+		}
+		if err := c.parts[i].Delete(ctx, mg); err != nil {
+			return errors.Wrapf(err, errFmtDeletePart, c.parts[i].Name)
+		}
+	}
+	return nil
+}
+
+// exists returns true if the most recent observation found the part at the
+// supplied index to exist. It returns false if there was no observation.
+func (c *CompositeExternalClient) exists(i int) bool {
+	return c.observed != nil && c.observed[i].ResourceExists
+}
+
+// upToDate returns true if the most recent observation found the part at the
+// supplied index to be up to date. It returns false if there was no
+// observation.
+func (c *CompositeExternalClient) upToDate(i int) bool {
+	return c.observed != nil && c.observed[i].ResourceUpToDate
+}
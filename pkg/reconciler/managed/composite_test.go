@@ -0,0 +1,302 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+var _ ExternalClient = &CompositeExternalClient{}
+
+// part returns a NamedExternalClient that appends each call it receives to the
+// supplied slice, and returns the supplied observation.
+func part(name string, calls *[]string, o ExternalObservation, err error) NamedExternalClient {
+	return NamedExternalClient{
+		Name: name,
+		ExternalClient: &ExternalClientFns{
+			ObserveFn: func(_ context.Context, _ resource.Managed) (ExternalObservation, error) {
+				*calls = append(*calls, "observe "+name)
+				return o, nil
+			},
+			CreateFn: func(_ context.Context, _ resource.Managed) (ExternalCreation, error) {
+				*calls = append(*calls, "create "+name)
+				return ExternalCreation{ConnectionDetails: ConnectionDetails{name: []byte("created")}}, err
+			},
+			UpdateFn: func(_ context.Context, _ resource.Managed) (ExternalUpdate, error) {
+				*calls = append(*calls, "update "+name)
+				return ExternalUpdate{ConnectionDetails: ConnectionDetails{name: []byte("updated")}}, err
+			},
+			DeleteFn: func(_ context.Context, _ resource.Managed) error {
+				*calls = append(*calls, "delete "+name)
+				return err
+			},
+		},
+	}
+}
+
+func TestCompositeExternalClientObserve(t *testing.T) {
+	errBoom := errors.New("boom")
+	now := metav1.Now()
+	deleted := &fake.Managed{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}}
+
+	type want struct {
+		o     ExternalObservation
+		calls []string
+		err   error
+	}
+
+	cases := map[string]struct {
+		reason string
+		parts  func(calls *[]string) []NamedExternalClient
+		mg     resource.Managed
+		want   want
+	}{
+		"AllExistAndUpToDate": {
+			reason: "The external resource should exist and be up to date when all of its parts are, with merged connection details.",
+			parts: func(calls *[]string) []NamedExternalClient {
+				return []NamedExternalClient{
+					part("bucket", calls, ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: ConnectionDetails{"a": []byte("1"), "b": []byte("1")}}, nil),
+					part("policy", calls, ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: ConnectionDetails{"b": []byte("2")}}, nil),
+				}
+			},
+			mg: &fake.Managed{},
+			want: want{
+				o:     ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: ConnectionDetails{"a": []byte("1"), "b": []byte("2")}},
+				calls: []string{"observe bucket", "observe policy"},
+			},
+		},
+		"PartiallyExists": {
+			reason: "The external resource should not exist when only some of its parts do.",
+			parts: func(calls *[]string) []NamedExternalClient {
+				return []NamedExternalClient{
+					part("bucket", calls, ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil),
+					part("policy", calls, ExternalObservation{}, nil),
+				}
+			},
+			mg: &fake.Managed{},
+			want: want{
+				o:     ExternalObservation{ConnectionDetails: ConnectionDetails{}},
+				calls: []string{"observe bucket", "observe policy"},
+			},
+		},
+		"StopsAtMissingPart": {
+			reason: "Parts after the first part that does not exist should not be observed, and should be reported not to exist.",
+			parts: func(calls *[]string) []NamedExternalClient {
+				return []NamedExternalClient{
+					part("bucket", calls, ExternalObservation{}, nil),
+					part("policy", calls, ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: ConnectionDetails{"b": []byte("2")}}, nil),
+				}
+			},
+			mg: &fake.Managed{},
+			want: want{
+				o:     ExternalObservation{ConnectionDetails: ConnectionDetails{}},
+				calls: []string{"observe bucket"},
+			},
+		},
+		"PartiallyExistsDeleted": {
+			reason: "The external resource should exist when any of its parts do and the managed resource was deleted.",
+			parts: func(calls *[]string) []NamedExternalClient {
+				return []NamedExternalClient{
+					part("bucket", calls, ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil),
+					part("policy", calls, ExternalObservation{}, nil),
+				}
+			},
+			mg: deleted,
+			want: want{
+				o:     ExternalObservation{ResourceExists: true, ConnectionDetails: ConnectionDetails{}},
+				calls: []string{"observe bucket", "observe policy"},
+			},
+		},
+		"MissingPartDeleted": {
+			reason: "All parts should be observed when the managed resource was deleted, so that later parts that exist are deleted.",
+			parts: func(calls *[]string) []NamedExternalClient {
+				return []NamedExternalClient{
+					part("bucket", calls, ExternalObservation{}, nil),
+					part("policy", calls, ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil),
+				}
+			},
+			mg: deleted,
+			want: want{
+				o:     ExternalObservation{ResourceExists: true, ConnectionDetails: ConnectionDetails{}},
+				calls: []string{"observe bucket", "observe policy"},
+			},
+		},
+		"NotUpToDate": {
+			reason: "The external resource should not be up to date when any of its parts are not, and diffs should be combined.",
+			parts: func(calls *[]string) []NamedExternalClient {
+				return []NamedExternalClient{
+					part("bucket", calls, ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil),
+					part("policy", calls, ExternalObservation{ResourceExists: true, Diff: "-a +b", ResourceLateInitialized: true}, nil),
+				}
+			},
+			mg: &fake.Managed{},
+			want: want{
+				o:     ExternalObservation{ResourceExists: true, ResourceLateInitialized: true, ConnectionDetails: ConnectionDetails{}, Diff: "policy:\n-a +b"},
+				calls: []string{"observe bucket", "observe policy"},
+			},
+		},
+		"ObserveError": {
+			reason: "Errors observing a part should be returned.",
+			parts: func(calls *[]string) []NamedExternalClient {
+				return []NamedExternalClient{{
+					Name: "bucket",
+					ExternalClient: &ExternalClientFns{ObserveFn: func(_ context.Context, _ resource.Managed) (ExternalObservation, error) {
+						return ExternalObservation{}, errBoom
+					}},
+				}}
+			},
+			mg: &fake.Managed{},
+			want: want{
+				calls: []string{},
+				err:   errors.Wrapf(errBoom, errFmtObservePart, "bucket"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			calls := []string{}
+			c := NewCompositeExternalClient(tc.parts(&calls)...)
+			o, err := c.Observe(context.Background(), tc.mg)
+			if diff := cmp.Diff(tc.want.o, o); diff != "" {
+				t.Errorf("\n%s\nc.Observe(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.calls, calls); diff != "" {
+				t.Errorf("\n%s\nc.Observe(...): -want calls, +got calls:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nc.Observe(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCompositeExternalClientMutations(t *testing.T) {
+	errBoom := errors.New("boom")
+	now := metav1.Now()
+	deleted := &fake.Managed{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}}
+
+	type want struct {
+		calls []string
+		err   error
+	}
+
+	cases := map[string]struct {
+		reason string
+		parts  func(calls *[]string) []NamedExternalClient
+		mg     resource.Managed
+		op     func(ctx context.Context, c *CompositeExternalClient) error
+		want   want
+	}{
+		"CreateMissingInOrder": {
+			reason: "Only parts that do not exist should be created, in order.",
+			parts: func(calls *[]string) []NamedExternalClient {
+				return []NamedExternalClient{
+					part("bucket", calls, ExternalObservation{ResourceExists: true}, nil),
+					part("policy", calls, ExternalObservation{}, nil),
+					part("lifecycle", calls, ExternalObservation{}, nil),
+				}
+			},
+			mg: &fake.Managed{},
+			op: func(ctx context.Context, c *CompositeExternalClient) error {
+				_, err := c.Create(ctx, &fake.Managed{})
+				return err
+			},
+			want: want{
+				calls: []string{"observe bucket", "observe policy", "create policy", "create lifecycle"},
+			},
+		},
+		"CreateStopsOnError": {
+			reason: "Creation should stop at the first part that cannot be created.",
+			parts: func(calls *[]string) []NamedExternalClient {
+				return []NamedExternalClient{
+					part("bucket", calls, ExternalObservation{}, errBoom),
+					part("policy", calls, ExternalObservation{}, nil),
+				}
+			},
+			mg: &fake.Managed{},
+			op: func(ctx context.Context, c *CompositeExternalClient) error {
+				_, err := c.Create(ctx, &fake.Managed{})
+				return err
+			},
+			want: want{
+				calls: []string{"observe bucket", "create bucket"},
+				err:   errors.Wrapf(errBoom, errFmtCreatePart, "bucket"),
+			},
+		},
+		"UpdateOnlyStale": {
+			reason: "Only parts that are not up to date should be updated.",
+			parts: func(calls *[]string) []NamedExternalClient {
+				return []NamedExternalClient{
+					part("bucket", calls, ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil),
+					part("policy", calls, ExternalObservation{ResourceExists: true}, nil),
+				}
+			},
+			mg: &fake.Managed{},
+			op: func(ctx context.Context, c *CompositeExternalClient) error {
+				_, err := c.Update(ctx, &fake.Managed{})
+				return err
+			},
+			want: want{
+				calls: []string{"observe bucket", "observe policy", "update policy"},
+			},
+		},
+		"DeleteExistingInReverse": {
+			reason: "Only parts that exist should be deleted, in reverse order.",
+			parts: func(calls *[]string) []NamedExternalClient {
+				return []NamedExternalClient{
+					part("bucket", calls, ExternalObservation{ResourceExists: true}, nil),
+					part("policy", calls, ExternalObservation{}, nil),
+					part("lifecycle", calls, ExternalObservation{ResourceExists: true}, nil),
+				}
+			},
+			mg: deleted,
+			op: func(ctx context.Context, c *CompositeExternalClient) error {
+				return c.Delete(ctx, deleted)
+			},
+			want: want{
+				calls: []string{"observe bucket", "observe policy", "observe lifecycle", "delete lifecycle", "delete bucket"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			calls := []string{}
+			c := NewCompositeExternalClient(tc.parts(&calls)...)
+			if _, err := c.Observe(context.Background(), tc.mg); err != nil {
+				t.Fatalf("\n%s\nc.Observe(...): %s", tc.reason, err)
+			}
+			err := tc.op(context.Background(), c)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\n-want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.calls, calls); diff != "" {
+				t.Errorf("\n%s\n-want calls, +got calls:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -30,11 +31,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/controller"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/feature"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reference"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 )
 
@@ -80,6 +83,7 @@ const (
 	reasonCannotUnpublish         event.Reason = "CannotUnpublishConnectionDetails"
 	reasonCannotUpdate            event.Reason = "CannotUpdateExternalResource"
 	reasonCannotUpdateManaged     event.Reason = "CannotUpdateManagedResource"
+	reasonCannotAudit             event.Reason = "CannotRecordAuditRecord"
 	reasonManagementPolicyInvalid event.Reason = "CannotUseInvalidManagementPolicy"
 
 	reasonDeleted event.Reason = "DeletedExternalResource"
@@ -87,6 +91,9 @@ const (
 	reasonUpdated event.Reason = "UpdatedExternalResource"
 	reasonPending event.Reason = "PendingExternalResource"
 
+	reasonWaitingForRefs event.Reason = "WaitingForReferences"
+	reasonReferenceCycle event.Reason = "ReferenceCycle"
+
 	reasonReconciliationPaused event.Reason = "ReconciliationPaused"
 )
 
@@ -467,6 +474,7 @@ type ExternalUpdate struct {
 type Reconciler struct {
 	client     client.Client
 	newManaged func() resource.Managed
+	kind       schema.GroupVersionKind
 
 	pollInterval     time.Duration
 	pollIntervalHook PollIntervalHook
@@ -487,6 +495,8 @@ type Reconciler struct {
 
 	log    logging.Logger
 	record event.Recorder
+	audit  AuditSink
+	shard  Shard
 }
 
 type mrManaged struct {
@@ -544,6 +554,20 @@ func WithPollInterval(after time.Duration) ReconcilerOption {
 	}
 }
 
+// WithControllerOptions configures the Reconciler using the supplied
+// controller options. It sets the poll interval and timeout of the Reconciler
+// to those of the options, unless they're zero.
+func WithControllerOptions(o controller.Options) ReconcilerOption {
+	return func(r *Reconciler) {
+		if o.PollInterval > 0 {
+			r.pollInterval = o.PollInterval
+		}
+		if o.Timeout > 0 {
+			r.timeout = o.Timeout
+		}
+	}
+}
+
 // PollIntervalHook represents the function type passed to the
 // WithPollIntervalHook option to support dynamic computation of the poll
 // interval.
@@ -657,6 +681,36 @@ func WithRecorder(er event.Recorder) ReconcilerOption {
 	}
 }
 
+// WithAuditSink specifies where the Reconciler should record every create,
+// update, and delete it performs on an external resource. Nothing is recorded
+// by default.
+func WithAuditSink(s AuditSink) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.audit = s
+	}
+}
+
+// A Shard determines whether a managed resource is owned by this replica of a
+// sharded controller.
+type Shard interface {
+	// Owns returns true if the supplied resource is owned by this replica.
+	Owns(o metav1.Object) bool
+}
+
+// WithShard specifies that the Reconciler should only reconcile managed
+// resources owned by the supplied Shard, so that a resource is not reconciled
+// by more than one replica when it moves to another shard. Requests for other
+// managed resources are requeued after the poll interval, so that they're
+// reconciled if they move to this shard. Wire the Shard's source of resources
+// that moved to this shard (e.g. controller.Sharder's Source) into the
+// controller to reconcile them as soon as they move. All managed resources are
+// reconciled by default.
+func WithShard(s Shard) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.shard = s
+	}
+}
+
 // WithManagementPolicies enables support for management policies.
 func WithManagementPolicies() ReconcilerOption {
 	return func(r *Reconciler) {
@@ -691,6 +745,7 @@ func NewReconciler(m manager.Manager, of resource.ManagedKind, o ...ReconcilerOp
 	r := &Reconciler{
 		client:                      m.GetClient(),
 		newManaged:                  nm,
+		kind:                        schema.GroupVersionKind(of),
 		pollInterval:                defaultPollInterval,
 		pollIntervalHook:            defaultPollIntervalHook,
 		creationGracePeriod:         defaultGracePeriod,
@@ -700,6 +755,7 @@ func NewReconciler(m manager.Manager, of resource.ManagedKind, o ...ReconcilerOp
 		supportedManagementPolicies: defaultSupportedManagementPolicies(),
 		log:                         logging.NewNopLogger(),
 		record:                      event.NewNopRecorder(),
+		audit:                       NopAuditSink{},
 	}
 
 	for _, ro := range o {
@@ -735,6 +791,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (resu
 		return reconcile.Result{}, errors.Wrap(resource.IgnoreNotFound(err), errGetManaged)
 	}
 
+	// The managed resource moved to (or always belonged to) another shard.
+	// That shard's replica will reconcile it. We check again after the poll
+	// interval in case it moves to this shard.
+	if r.shard != nil && !r.shard.Owns(managed) {
+		log.Debug("Managed resource is owned by another shard", "requeue-after", r.pollInterval)
+		return reconcile.Result{RequeueAfter: r.pollInterval}, nil
+	}
+
 	record := r.record.WithAnnotations("external-name", meta.GetExternalName(managed))
 	log = log.WithValues(
 		"uid", managed.GetUID(),
@@ -872,6 +936,16 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (resu
 			if kerrors.IsConflict(err) {
 				return reconcile.Result{Requeue: true}, nil
 			}
+			if reference.IsCycle(err) {
+				record.Event(managed, event.Warning(reasonReferenceCycle, err))
+				managed.SetConditions(xpv1.ReferenceCycle(err))
+				return reconcile.Result{Requeue: true}, errors.Wrap(r.client.Status().Update(ctx, managed), errUpdateManagedStatus)
+			}
+			if reference.IsWaiting(err) {
+				record.Event(managed, event.Normal(reasonWaitingForRefs, err.Error()))
+				managed.SetConditions(xpv1.WaitingForReferences(err))
+				return reconcile.Result{Requeue: true}, errors.Wrap(r.client.Status().Update(ctx, managed), errUpdateManagedStatus)
+			}
 			record.Event(managed, event.Warning(reasonCannotResolveRefs, err))
 			managed.SetConditions(xpv1.ReconcileError(err))
 			return reconcile.Result{Requeue: true}, errors.Wrap(r.client.Status().Update(ctx, managed), errUpdateManagedStatus)
@@ -940,7 +1014,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (resu
 		log = log.WithValues("deletion-timestamp", managed.GetDeletionTimestamp())
 
 		if observation.ResourceExists && policy.ShouldDelete() {
-			if err := external.Delete(externalCtx, managed); err != nil {
+			err := external.Delete(externalCtx, managed)
+			r.recordAudit(ctx, log, record, managed, AuditOperationDelete, observation.Diff, err)
+			if err != nil {
 				// We'll hit this condition if we can't delete our external
 				// resource, for example if our provider credentials don't have
 				// access to delete it. If this is the first time we encounter
@@ -1044,6 +1120,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (resu
 		}
 
 		creation, err := external.Create(externalCtx, managed)
+		r.recordAudit(ctx, log, record, managed, AuditOperationCreate, observation.Diff, err)
 		if err != nil {
 			// We'll hit this condition if we can't create our external
 			// resource, for example if our provider credentials don't have
@@ -1167,6 +1244,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (resu
 	}
 
 	update, err := external.Update(externalCtx, managed)
+	r.recordAudit(ctx, log, record, managed, AuditOperationUpdate, observation.Diff, err)
 	if err != nil {
 		// We'll hit this condition if we can't update our external resource,
 		// for example if our provider credentials don't have access to update
@@ -1200,3 +1278,30 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (resu
 	managed.SetConditions(xpv1.ReconcileSuccess())
 	return reconcile.Result{RequeueAfter: reconcileAfter}, errors.Wrap(r.client.Status().Update(ctx, managed), errUpdateManagedStatus)
 }
+
+// recordAudit records an attempt to mutate the external resource represented
+// by the supplied managed resource. Failing to record an attempt does not fail
+// the reconcile, because the external resource has already been mutated.
+func (r *Reconciler) recordAudit(ctx context.Context, log logging.Logger, record event.Recorder, mg resource.Managed, op AuditOperation, diff string, err error) {
+	ar := AuditRecord{
+		Timestamp:    time.Now(),
+		Operation:    op,
+		Outcome:      AuditOutcomeSuccess,
+		APIVersion:   r.kind.GroupVersion().String(),
+		Kind:         r.kind.Kind,
+		Name:         mg.GetName(),
+		ExternalName: meta.GetExternalName(mg),
+		Diff:         diff,
+	}
+	if err != nil {
+		ar.Outcome = AuditOutcomeFailure
+		ar.Error = err.Error()
+	}
+	if pc := mg.GetProviderConfigReference(); pc != nil {
+		ar.ProviderConfig = pc.Name
+	}
+	if err := r.audit.Record(ctx, ar); err != nil {
+		log.Info("Cannot record audit record", "error", err, "operation", op)
+		record.Event(mg, event.Warning(reasonCannotAudit, err))
+	}
+}
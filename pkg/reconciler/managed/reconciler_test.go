@@ -33,8 +33,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/controller"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reference"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
@@ -85,6 +87,20 @@ func TestReconciler(t *testing.T) {
 			},
 			want: want{result: reconcile.Result{}},
 		},
+		"NotOwnedByShard": {
+			reason: "Managed resources owned by another shard should be requeued after the poll interval.",
+			args: args{
+				m: &fake.Manager{
+					Client: &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+					Scheme: fake.SchemeWith(&fake.Managed{}),
+				},
+				mg: resource.ManagedKind(fake.GVK(&fake.Managed{})),
+				o: []ReconcilerOption{
+					WithShard(MockShard(func(metav1.Object) bool { return false })),
+				},
+			},
+			want: want{result: reconcile.Result{RequeueAfter: defaultPollInterval}},
+		},
 		"UnpublishConnectionDetailsDeletionPolicyDeleteOrpahn": {
 			reason: "Errors unpublishing connection details should trigger a requeue after a short wait.",
 			args: args{
@@ -260,6 +276,62 @@ func TestReconciler(t *testing.T) {
 			},
 			want: want{result: reconcile.Result{Requeue: true}},
 		},
+		"ResolveReferencesWaiting": {
+			reason: "Waiting for referenced resources to become ready should trigger a requeue after a short wait.",
+			args: args{
+				m: &fake.Manager{
+					Client: &test.MockClient{
+						MockGet: test.NewMockGetFn(nil),
+						MockStatusUpdate: test.MockSubResourceUpdateFn(func(_ context.Context, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+							want := &fake.Managed{}
+							want.SetConditions(xpv1.WaitingForReferences(errors.Wrap(&reference.WaitingError{Blockers: []string{"vpc"}}, "spec.forProvider.vpcId")))
+							if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+								reason := "Waiting for referenced resources should be reported as a conditioned status naming the blockers."
+								t.Errorf("\nReason: %s\n-want, +got:\n%s", reason, diff)
+							}
+							return nil
+						}),
+					},
+					Scheme: fake.SchemeWith(&fake.Managed{}),
+				},
+				mg: resource.ManagedKind(fake.GVK(&fake.Managed{})),
+				o: []ReconcilerOption{
+					WithInitializers(),
+					WithReferenceResolver(ReferenceResolverFn(func(_ context.Context, res resource.Managed) error {
+						return errors.Wrap(&reference.WaitingError{Blockers: []string{"vpc"}}, "spec.forProvider.vpcId")
+					})),
+				},
+			},
+			want: want{result: reconcile.Result{Requeue: true}},
+		},
+		"ResolveReferencesCycle": {
+			reason: "References that form a cycle should be reported and trigger a requeue after a short wait.",
+			args: args{
+				m: &fake.Manager{
+					Client: &test.MockClient{
+						MockGet: test.NewMockGetFn(nil),
+						MockStatusUpdate: test.MockSubResourceUpdateFn(func(_ context.Context, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+							want := &fake.Managed{}
+							want.SetConditions(xpv1.ReferenceCycle(&reference.CycleError{Cycle: []string{"a", "b", "a"}}))
+							if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+								reason := "References that form a cycle should be reported as a conditioned status describing the cycle."
+								t.Errorf("\nReason: %s\n-want, +got:\n%s", reason, diff)
+							}
+							return nil
+						}),
+					},
+					Scheme: fake.SchemeWith(&fake.Managed{}),
+				},
+				mg: resource.ManagedKind(fake.GVK(&fake.Managed{})),
+				o: []ReconcilerOption{
+					WithInitializers(),
+					WithReferenceResolver(ReferenceResolverFn(func(_ context.Context, res resource.Managed) error {
+						return &reference.CycleError{Cycle: []string{"a", "b", "a"}}
+					})),
+				},
+			},
+			want: want{result: reconcile.Result{Requeue: true}},
+		},
 		"ExternalConnectError": {
 			reason: "Errors connecting to the provider should trigger a requeue after a short wait.",
 			args: args{
@@ -2240,3 +2312,40 @@ func TestShouldDelete(t *testing.T) {
 		})
 	}
 }
+
+type MockShard func(o metav1.Object) bool
+
+func (fn MockShard) Owns(o metav1.Object) bool { return fn(o) }
+
+func TestWithControllerOptions(t *testing.T) {
+	type want struct {
+		pollInterval time.Duration
+		timeout      time.Duration
+	}
+	cases := map[string]struct {
+		reason string
+		o      controller.Options
+		want   want
+	}{
+		"ZeroValues": {
+			reason: "The Reconciler's poll interval and timeout should be unchanged if the options don't set them.",
+			o:      controller.Options{},
+			want:   want{pollInterval: defaultPollInterval, timeout: reconcileTimeout},
+		},
+		"Set": {
+			reason: "The Reconciler should use the options' poll interval and timeout.",
+			o:      controller.Options{PollInterval: 5 * time.Minute, Timeout: 2 * time.Minute},
+			want:   want{pollInterval: 5 * time.Minute, timeout: 2 * time.Minute},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{pollInterval: defaultPollInterval, timeout: reconcileTimeout}
+			WithControllerOptions(tc.o)(r)
+			got := want{pollInterval: r.pollInterval, timeout: r.timeout}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nWithControllerOptions(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
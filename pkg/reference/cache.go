@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// DefaultMaxConcurrentGets is the default maximum number of referenced
+// resources an APIResolver gets concurrently when resolving several
+// references.
+const DefaultMaxConcurrentGets = 10
+
+// An APIResolverOption configures an APIResolver.
+type APIResolverOption func(*APIResolver)
+
+// WithMaxConcurrentGets configures the maximum number of referenced resources
+// an APIResolver gets concurrently when resolving several references. Values
+// less than one are treated as one; i.e. references are resolved serially.
+func WithMaxConcurrentGets(n int) APIResolverOption {
+	return func(r *APIResolver) {
+		r.maxConcurrentGets = n
+	}
+}
+
+// WithListThreshold configures an APIResolver to resolve references using
+// List rather than Get. Each referenced resource is read using a List with a
+// metadata.name field selector, unless at least the supplied number of
+// references must be resolved at once. The API server can't select a set of
+// names using a field selector, so those are resolved using a single List of
+// all resources of the referenced kind (in the referenced namespace, if any).
+// Prefer a lower threshold when the client reads from a cache, and a higher
+// one or none (the default) when resolving resources of a kind that has many
+// instances. A cache must index metadata.name to serve a List that selects it.
+func WithListThreshold(n int) APIResolverOption {
+	return func(r *APIResolver) {
+		r.listThreshold = n
+	}
+}
+
+// An objectCache memoises the referenced resources an APIResolver reads, so
+// that each is read at most once no matter how many requests refer to it.
+type objectCache struct {
+	mx      sync.RWMutex
+	objects map[string]client.Object
+	listed  map[string]bool
+}
+
+func (c *objectCache) get(key string) (client.Object, bool) {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	o, ok := c.objects[key]
+	if !ok {
+		return nil, false
+	}
+	return o.DeepCopyObject().(client.Object), true //nolint:forcetypeassert // Guaranteed to be a client.Object.
+}
+
+func (c *objectCache) add(key string, o client.Object) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.objects == nil {
+		c.objects = make(map[string]client.Object)
+	}
+	c.objects[key] = o.DeepCopyObject().(client.Object) //nolint:forcetypeassert // Guaranteed to be a client.Object.
+}
+
+// setListed records that all resources of a kind were listed, and thus that
+// any resource of that kind that isn't cached doesn't exist.
+func (c *objectCache) setListed(key string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.listed == nil {
+		c.listed = make(map[string]bool)
+	}
+	c.listed[key] = true
+}
+
+func (c *objectCache) isListed(key string) bool {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return c.listed[key]
+}
+
+// cacheKey returns a key that identifies the named object of the supplied
+// object's kind. The key of a kind of object is that of an empty name.
+func cacheKey(o client.Object, nn types.NamespacedName) string {
+	kind := fmt.Sprintf("%T", o)
+	if u, ok := o.(*unstructured.Unstructured); ok {
+		kind = u.GroupVersionKind().String()
+	}
+	return kind + "/" + nn.String()
+}
+
+// get returns the named referenced resource, reading it from the API server
+// only if it has not already been read by this APIResolver.
+func (r *APIResolver) get(ctx context.Context, to To, name string) (client.Object, error) {
+	nn := types.NamespacedName{Namespace: to.Namespace, Name: name}
+	key := cacheKey(to.object(), nn)
+	if o, ok := r.cache.get(key); ok {
+		return o, nil
+	}
+	if r.cache.isListed(cacheKey(to.object(), types.NamespacedName{Namespace: to.Namespace})) {
+		return nil, kerrors.NewNotFound(schema.GroupResource{}, name)
+	}
+	o, err := r.read(ctx, to, nn)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.add(key, o)
+	return o, nil
+}
+
+// read the named referenced resource using a List with a metadata.name field
+// selector if the APIResolver has a list threshold, or a Get if it doesn't.
+func (r *APIResolver) read(ctx context.Context, to To, nn types.NamespacedName) (client.Object, error) {
+	if r.listThreshold < 1 || to.list() == nil {
+		o := to.object().DeepCopyObject().(client.Object) //nolint:forcetypeassert // Guaranteed to be a client.Object.
+		return o, r.client.Get(ctx, nn, o)
+	}
+
+	// References are read concurrently, so each needs its own list.
+	l := to.list().DeepCopyObject()
+	if to.ObjectList != nil {
+		to.ObjectList = l.(client.ObjectList) //nolint:forcetypeassert // Guaranteed to be a client.ObjectList.
+	} else {
+		to.List = l.(resource.ManagedList) //nolint:forcetypeassert // Guaranteed to be a resource.ManagedList.
+	}
+	if err := r.client.List(ctx, to.list(), client.InNamespace(nn.Namespace), client.MatchingFields{"metadata.name": nn.Name}); err != nil {
+		return nil, errors.Wrap(err, errListObjects)
+	}
+	objs, err := to.items()
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range objs {
+		if o.GetName() == nn.Name {
+			return o, nil
+		}
+	}
+	return nil, kerrors.NewNotFound(schema.GroupResource{}, nn.Name)
+}
+
+// getAll returns the named referenced resources, and any error encountered
+// reading each of them. Resources that have not already been read by this
+// APIResolver are read concurrently, or using a single List of their kind if
+// there are at least as many as the APIResolver's list threshold.
+func (r *APIResolver) getAll(ctx context.Context, to To, names []string) ([]client.Object, []error) {
+	objs := make([]client.Object, len(names))
+	errs := make([]error, len(names))
+
+	if r.listThreshold > 0 && len(names) >= r.listThreshold {
+		if err := r.list(ctx, to); err != nil {
+			for i := range errs {
+				errs[i] = err
+			}
+			return objs, errs
+		}
+	}
+
+	limit := r.maxConcurrentGets
+	if limit < 1 {
+		limit = 1
+	}
+	g := &errgroup.Group{}
+	g.SetLimit(limit)
+	for i := range names {
+		i := i
+		g.Go(func() error {
+			objs[i], errs[i] = r.get(ctx, to, names[i])
+			return nil
+		})
+	}
+	_ = g.Wait() // Errors are returned per resource.
+	return objs, errs
+}
+
+// list reads all referenced resources of the supplied kind into the
+// APIResolver's cache, unless they've already been listed.
+func (r *APIResolver) list(ctx context.Context, to To) error {
+	lk := cacheKey(to.object(), types.NamespacedName{Namespace: to.Namespace})
+	if r.cache.isListed(lk) {
+		return nil
+	}
+	objs, err := ListObjects(ctx, r.client, to)
+	if err != nil {
+		return err
+	}
+	for _, o := range objs {
+		r.cache.add(cacheKey(to.object(), types.NamespacedName{Namespace: to.Namespace, Name: o.GetName()}), o)
+	}
+	r.cache.setListed(lk)
+	return nil
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func named(name string) *fake.Managed {
+	mg := &fake.Managed{}
+	mg.SetName(name)
+	meta.SetExternalName(mg, "ext-"+name)
+	return mg
+}
+
+func TestResolveMemoised(t *testing.T) {
+	var gets atomic.Int32
+	c := &test.MockClient{
+		MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+			gets.Add(1)
+			*obj.(*fake.Managed) = *named(key.Name)
+			return nil
+		},
+	}
+
+	r := NewAPIResolver(c, &fake.Managed{})
+	for _, name := range []string{"a", "b", "a"} {
+		rsp, err := r.Resolve(context.Background(), ResolutionRequest{
+			Reference: &xpv1.Reference{Name: name},
+			To:        To{Managed: &fake.Managed{}},
+			Extract:   ExternalName(),
+		})
+		if err != nil {
+			t.Fatalf("r.Resolve(...): %s", err)
+		}
+		if diff := cmp.Diff("ext-"+name, rsp.ResolvedValue); diff != "" {
+			t.Errorf("r.Resolve(...): -want, +got:\n%s", diff)
+		}
+	}
+	if diff := cmp.Diff(int32(2), gets.Load()); diff != "" {
+		t.Errorf("Each referenced resource should be read once: -want gets, +got gets:\n%s", diff)
+	}
+}
+
+func TestResolveMultipleConcurrently(t *testing.T) {
+	const limit = 3
+
+	var mx sync.Mutex
+	var inflight, peak int
+	c := &test.MockClient{
+		MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+			mx.Lock()
+			inflight++
+			if inflight > peak {
+				peak = inflight
+			}
+			mx.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+			*obj.(*fake.Managed) = *named(key.Name)
+
+			mx.Lock()
+			inflight--
+			mx.Unlock()
+			return nil
+		},
+	}
+
+	refs := []xpv1.Reference{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}, {Name: "f"}}
+	r := NewAPIResolver(c, &fake.Managed{}, WithMaxConcurrentGets(limit))
+	got, err := r.ResolveMultiple(context.Background(), MultiResolutionRequest{
+		References: refs,
+		To:         To{Managed: &fake.Managed{}},
+		Extract:    ExternalName(),
+	})
+	if err != nil {
+		t.Fatalf("r.ResolveMultiple(...): %s", err)
+	}
+	want := MultiResolutionResponse{
+		ResolvedValues:     []string{"ext-a", "ext-b", "ext-c", "ext-d", "ext-e", "ext-f"},
+		ResolvedReferences: refs,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("r.ResolveMultiple(...): -want, +got:\n%s", diff)
+	}
+	if peak > limit {
+		t.Errorf("r.ResolveMultiple(...): want at most %d concurrent gets, got %d", limit, peak)
+	}
+}
+
+func TestResolveMultipleListThreshold(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	var lists atomic.Int32
+	list := func(_ context.Context, l client.ObjectList, opts ...client.ListOption) error {
+		lists.Add(1)
+		lo := &client.ListOptions{}
+		lo.ApplyOptions(opts)
+		items := []resource.Managed{}
+		for _, mg := range []resource.Managed{named("a"), named("b"), named("c")} {
+			if lo.FieldSelector == nil || lo.FieldSelector.Matches(fields.Set{"metadata.name": mg.GetName()}) {
+				items = append(items, mg)
+			}
+		}
+		l.(*FakeManagedList).Items = items
+		return nil
+	}
+
+	type want struct {
+		rsp   MultiResolutionResponse
+		err   error
+		lists int32
+	}
+	cases := map[string]struct {
+		reason string
+		c      client.Reader
+		refs   []xpv1.Reference
+		want   want
+	}{
+		"BelowThreshold": {
+			reason: "Referenced resources should be read using a List with a name field selector if there are fewer than the threshold.",
+			c:      &test.MockClient{MockGet: test.NewMockGetFn(errBoom), MockList: list},
+			refs:   []xpv1.Reference{{Name: "a"}},
+			want: want{
+				rsp: MultiResolutionResponse{
+					ResolvedValues:     []string{"ext-a"},
+					ResolvedReferences: []xpv1.Reference{{Name: "a"}},
+				},
+				lists: 1,
+			},
+		},
+		"BelowThresholdNotFound": {
+			reason: "A NotFound error should be returned if no referenced resource has the selected name.",
+			c:      &test.MockClient{MockGet: test.NewMockGetFn(errBoom), MockList: list},
+			refs:   []xpv1.Reference{{Name: "z"}},
+			want: want{
+				err:   errors.Wrap(kerrors.NewNotFound(schema.GroupResource{}, "z"), errGetManaged),
+				lists: 1,
+			},
+		},
+		"BelowThresholdListError": {
+			reason: "Errors listing a referenced resource by name should be returned.",
+			c:      &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+			refs:   []xpv1.Reference{{Name: "a"}},
+			want: want{
+				err: errors.Wrap(errors.Wrap(errBoom, errListObjects), errGetManaged),
+			},
+		},
+		"List": {
+			reason: "Referenced resources should be read using a single List if there are at least the threshold.",
+			c:      &test.MockClient{MockGet: test.NewMockGetFn(errBoom), MockList: list},
+			refs:   []xpv1.Reference{{Name: "c"}, {Name: "a"}},
+			want: want{
+				rsp: MultiResolutionResponse{
+					ResolvedValues:     []string{"ext-c", "ext-a"},
+					ResolvedReferences: []xpv1.Reference{{Name: "c"}, {Name: "a"}},
+				},
+				lists: 1,
+			},
+		},
+		"NotListed": {
+			reason: "A NotFound error should be returned if a referenced resource was not listed.",
+			c:      &test.MockClient{MockGet: test.NewMockGetFn(errBoom), MockList: list},
+			refs:   []xpv1.Reference{{Name: "a"}, {Name: "z"}},
+			want: want{
+				err:   errors.Wrap(kerrors.NewNotFound(schema.GroupResource{}, "z"), errGetManaged),
+				lists: 1,
+			},
+		},
+		"ListError": {
+			reason: "Errors listing referenced resources should be returned.",
+			c:      &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+			refs:   []xpv1.Reference{{Name: "a"}, {Name: "b"}},
+			want: want{
+				err: errors.Wrap(errors.Wrap(errBoom, errListObjects), errGetManaged),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			lists.Store(0)
+			r := NewAPIResolver(tc.c, &fake.Managed{}, WithListThreshold(2))
+			got, err := r.ResolveMultiple(context.Background(), MultiResolutionRequest{
+				References: tc.refs,
+				To:         To{Managed: &fake.Managed{}, List: &FakeManagedList{}},
+				Extract:    ExternalName(),
+			})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.ResolveMultiple(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.rsp, got); diff != "" {
+				t.Errorf("\n%s\nr.ResolveMultiple(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.lists, lists.Load()); diff != "" {
+				t.Errorf("\n%s\nr.ResolveMultiple(...): -want lists, +got lists:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
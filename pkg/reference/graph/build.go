@@ -0,0 +1,209 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/reference"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// Error strings.
+const (
+	errFmtList = "cannot list %s resources"
+)
+
+// Resources of a particular kind to include in a dependency graph.
+type Resources struct {
+	// Kind of the resources.
+	Kind schema.GroupKind
+
+	// To lists the resources. Its Namespace, if any, is also used as the
+	// namespace of resources of this kind that are referred to by name.
+	To reference.To
+
+	// Fields of the resources that refer to other resources.
+	Fields []reference.ReferenceField
+}
+
+// A Builder builds a dependency graph of resources by walking their reference
+// fields.
+type Builder struct {
+	client    client.Reader
+	resources []Resources
+}
+
+// NewBuilder returns a Builder that builds a dependency graph of the supplied
+// kinds of resources.
+func NewBuilder(c client.Reader, rs ...Resources) *Builder {
+	return &Builder{client: c, resources: rs}
+}
+
+// Build a dependency graph of all resources of the Builder's kinds. A resource
+// depends on each resource it refers to. A resource with an unresolved
+// selector depends on every resource the selector currently selects, since it
+// may select any of them. Referenced resources that are not of one of the
+// Builder's kinds are included, but their own references are not walked.
+func (b *Builder) Build(ctx context.Context) (*Graph, error) {
+	listed := make(map[schema.GroupKind][]client.Object, len(b.resources))
+	namespace := make(map[schema.GroupKind]string, len(b.resources))
+	for _, rs := range b.resources {
+		objs, err := reference.ListObjects(ctx, b.client, rs.To)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtList, rs.Kind)
+		}
+		listed[rs.Kind] = objs
+		namespace[rs.Kind] = rs.To.Namespace
+	}
+
+	g := New()
+	for _, rs := range b.resources {
+		for _, o := range listed[rs.Kind] {
+			from := Node{Kind: rs.Kind, Namespace: o.GetNamespace(), Name: o.GetName()}
+			g.AddNode(from)
+
+			for _, f := range rs.Fields {
+				refs, sels := f.Get(o)
+				for _, ref := range refs {
+					g.AddEdge(Edge{From: from, To: Node{Kind: f.To, Namespace: namespace[f.To], Name: ref.Name}, Field: f.Reference})
+				}
+				for i := range sels {
+					for _, to := range listed[f.To] {
+						if reference.Selects(&sels[i], o, to) {
+							g.AddEdge(Edge{From: from, To: Node{Kind: f.To, Namespace: to.GetNamespace(), Name: to.GetName()}, Field: f.Selector})
+						}
+					}
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+// A ReferenceResolver resolves references to other managed resources. It
+// satisfies the managed reconciler's ReferenceResolver interface.
+type ReferenceResolver interface {
+	ResolveReferences(ctx context.Context, mg resource.Managed) error
+}
+
+// DefaultGraphTTL is how long a CycleDetectingResolver reuses a dependency
+// graph before it builds a new one.
+const DefaultGraphTTL = 30 * time.Second
+
+// A CycleDetectingResolver wraps a ReferenceResolver. When the wrapped resolver
+// is waiting for a managed resource's references to be resolved it checks
+// whether the resource's references form a cycle, in which case they'll never
+// be resolved.
+//
+// Building a dependency graph lists every resource of the Builder's kinds, so
+// a CycleDetectingResolver reuses the graph it built for all resources it
+// resolves until the graph is older than its TTL. A new cycle may therefore
+// take up to the TTL to be detected.
+type CycleDetectingResolver struct {
+	wrapped ReferenceResolver
+	builder *Builder
+	kind    schema.GroupKind
+	ttl     time.Duration
+	now     func() time.Time
+
+	mx    sync.Mutex
+	graph *Graph
+	built time.Time
+}
+
+// A CycleDetectingResolverOption configures a CycleDetectingResolver.
+type CycleDetectingResolverOption func(r *CycleDetectingResolver)
+
+// WithGraphTTL configures how long a CycleDetectingResolver reuses a
+// dependency graph before it builds a new one. DefaultGraphTTL is used by
+// default.
+func WithGraphTTL(d time.Duration) CycleDetectingResolverOption {
+	return func(r *CycleDetectingResolver) {
+		r.ttl = d
+	}
+}
+
+// NewCycleDetectingResolver returns a ReferenceResolver that resolves the
+// references of the supplied kind of managed resource using the supplied
+// resolver, and detects cycles using a graph built by the supplied Builder.
+// The Builder should include the supplied kind. Use it with the managed
+// reconciler's WithReferenceResolver option to report a ReferenceCycle
+// condition when a managed resource's references form a cycle.
+func NewCycleDetectingResolver(b *Builder, gk schema.GroupKind, rr ReferenceResolver, o ...CycleDetectingResolverOption) *CycleDetectingResolver {
+	r := &CycleDetectingResolver{wrapped: rr, builder: b, kind: gk, ttl: DefaultGraphTTL, now: time.Now}
+	for _, fn := range o {
+		fn(r)
+	}
+	return r
+}
+
+// ResolveReferences of the supplied managed resource. A CycleError is returned
+// if the references could not be resolved because they form a cycle.
+func (r *CycleDetectingResolver) ResolveReferences(ctx context.Context, mg resource.Managed) error {
+	err := r.wrapped.ResolveReferences(ctx, mg)
+
+	// Only references that are waiting for other resources to become ready
+	// can form a cycle. Other errors won't be fixed by breaking one.
+	if !reference.IsNotReady(err) {
+		return err
+	}
+
+	g, gerr := r.getGraph(ctx)
+	if gerr != nil {
+		// We can't tell whether the references form a cycle. Return the
+		// original error, which is more relevant.
+		return err
+	}
+	if c := g.CycleContaining(Node{Kind: r.kind, Namespace: mg.GetNamespace(), Name: mg.GetName()}); c != nil {
+		return cycleError(c)
+	}
+	return err
+}
+
+// getGraph returns the cached dependency graph, building a new one if it is
+// older than the resolver's TTL. The graph is built without holding the lock,
+// so that building it doesn't block resolving resources whose references
+// aren't waiting.
+func (r *CycleDetectingResolver) getGraph(ctx context.Context) (*Graph, error) {
+	now := r.now()
+
+	r.mx.Lock()
+	g, built := r.graph, r.built
+	r.mx.Unlock()
+
+	if g != nil && now.Sub(built) < r.ttl {
+		return g, nil
+	}
+	g, err := r.builder.Build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if r.graph == nil || now.After(r.built) {
+		r.graph, r.built = g, now
+	}
+	return g, nil
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/reference"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func object(kind, name string, labels map[string]string, spec map[string]any) unstructured.Unstructured {
+	u := unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	u.SetGroupVersionKind(schema.GroupVersionKind{Group: "ec2.aws", Version: "v1", Kind: kind})
+	u.SetName(name)
+	u.SetLabels(labels)
+	return u
+}
+
+func resources(gk schema.GroupKind, fields ...reference.ReferenceField) Resources {
+	return Resources{Kind: gk, To: reference.ToUnstructured(gk.WithVersion("v1")), Fields: fields}
+}
+
+func TestBuild(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	list := func(_ context.Context, l client.ObjectList, _ ...client.ListOption) error {
+		ul := l.(*unstructured.UnstructuredList)
+		switch ul.GetKind() {
+		case "VPCList":
+			ul.Items = []unstructured.Unstructured{
+				object("VPC", "a", map[string]string{"env": "prod"}, map[string]any{"peerRef": map[string]any{"name": "b"}}),
+				object("VPC", "b", map[string]string{"env": "dev"}, map[string]any{}),
+			}
+		case "SubnetList":
+			ul.Items = []unstructured.Unstructured{
+				object("Subnet", "a", nil, map[string]any{"vpcIdRef": map[string]any{"name": "a"}}),
+				object("Subnet", "b", nil, map[string]any{"vpcIdSelector": map[string]any{"matchLabels": map[string]any{"env": "dev"}}}),
+			}
+		}
+		return nil
+	}
+
+	rs := []Resources{
+		resources(vpcs, reference.ReferenceField{To: vpcs, Reference: "spec.peerRef"}),
+		resources(subnets, reference.ReferenceField{To: vpcs, Reference: "spec.vpcIdRef", Selector: "spec.vpcIdSelector"}),
+	}
+
+	type want struct {
+		edges []Edge
+		err   error
+	}
+	cases := map[string]struct {
+		reason string
+		c      client.Reader
+		want   want
+	}{
+		"ListError": {
+			reason: "Errors listing resources should be returned.",
+			c:      &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+			want: want{
+				err: errors.Wrapf(errors.Wrap(errBoom, "cannot list referenced resources"), errFmtList, vpcs),
+			},
+		},
+		"Success": {
+			reason: "Resources should depend on the resources they refer to, and the resources their unresolved selectors select.",
+			c:      &test.MockClient{MockList: list},
+			want: want{
+				edges: []Edge{
+					{From: subnet("a"), To: vpc("a"), Field: "spec.vpcIdRef"},
+					{From: subnet("b"), To: vpc("b"), Field: "spec.vpcIdSelector"},
+					{From: vpc("a"), To: vpc("b"), Field: "spec.peerRef"},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			g, err := NewBuilder(tc.c, rs...).Build(context.Background())
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nb.Build(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.edges, g.Edges()); diff != "" {
+				t.Errorf("\n%s\nb.Build(...): -want edges, +got edges:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCycleDetectingResolver(t *testing.T) {
+	errBoom := errors.New("boom")
+	errWaiting := errors.Wrap(&reference.WaitingError{Blockers: []string{"b"}}, "spec.peerRef")
+
+	// VPC a and b are peered with each other.
+	list := func(_ context.Context, l client.ObjectList, _ ...client.ListOption) error {
+		l.(*unstructured.UnstructuredList).Items = []unstructured.Unstructured{
+			object("VPC", "a", nil, map[string]any{"peerRef": map[string]any{"name": "b"}}),
+			object("VPC", "b", nil, map[string]any{"peerRef": map[string]any{"name": "a"}}),
+			object("VPC", "c", nil, map[string]any{"peerRef": map[string]any{"name": "a"}}),
+		}
+		return nil
+	}
+	b := NewBuilder(&test.MockClient{MockList: list}, resources(vpcs, reference.ReferenceField{To: vpcs, Reference: "spec.peerRef"}))
+
+	named := func(name string) resource.Managed {
+		mg := &fake.Managed{}
+		mg.SetName(name)
+		return mg
+	}
+
+	cases := map[string]struct {
+		reason string
+		rr     ReferenceResolver
+		mg     resource.Managed
+		want   error
+	}{
+		"Resolved": {
+			reason: "No error should be returned if references were resolved.",
+			rr:     fakeResolver{},
+			mg:     named("a"),
+		},
+		"NotWaiting": {
+			reason: "The wrapped resolver's error should be returned if it is not waiting for other resources.",
+			rr:     fakeResolver{err: errBoom},
+			mg:     named("a"),
+			want:   errBoom,
+		},
+		"Cycle": {
+			reason: "A CycleError should be returned if references could not be resolved because they form a cycle.",
+			rr:     fakeResolver{err: errWaiting},
+			mg:     named("a"),
+			want:   &reference.CycleError{Cycle: []string{"VPC.ec2.aws/a", "VPC.ec2.aws/b", "VPC.ec2.aws/a"}},
+		},
+		"CycleNotReady": {
+			reason: "A CycleError should be returned if references could not be resolved because the values they refer to are not ready and they form a cycle.",
+			rr:     fakeResolver{err: errors.Wrap(notReadyError{}, "spec.peerRef")},
+			mg:     named("b"),
+			want:   &reference.CycleError{Cycle: []string{"VPC.ec2.aws/b", "VPC.ec2.aws/a", "VPC.ec2.aws/b"}},
+		},
+		"NoCycle": {
+			reason: "The wrapped resolver's error should be returned if the resource is not part of a cycle.",
+			rr:     fakeResolver{err: errWaiting},
+			mg:     named("c"),
+			want:   errWaiting,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := NewCycleDetectingResolver(b, vpcs, tc.rr).ResolveReferences(context.Background(), tc.mg)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.ResolveReferences(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCycleDetectingResolverLists(t *testing.T) {
+	errBoom := errors.New("boom")
+	errWaiting := &reference.WaitingError{Blockers: []string{"b"}}
+
+	type want struct {
+		lists int
+	}
+
+	cases := map[string]struct {
+		reason  string
+		rr      ReferenceResolver
+		elapsed time.Duration
+		want    want
+	}{
+		"NotWaiting": {
+			reason: "No resources should be listed if the wrapped resolver is not waiting for other resources.",
+			rr:     fakeResolver{err: errBoom},
+			want:   want{lists: 0},
+		},
+		"Cached": {
+			reason: "Resources should be listed once while the graph is younger than its TTL.",
+			rr:     fakeResolver{err: errWaiting},
+			want:   want{lists: 1},
+		},
+		"Expired": {
+			reason:  "Resources should be listed again once the graph is older than its TTL.",
+			rr:      fakeResolver{err: errWaiting},
+			elapsed: DefaultGraphTTL,
+			want:    want{lists: 3},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			lists := 0
+			list := func(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+				lists++
+				return nil
+			}
+			b := NewBuilder(&test.MockClient{MockList: list}, resources(vpcs, reference.ReferenceField{To: vpcs, Reference: "spec.peerRef"}))
+			r := NewCycleDetectingResolver(b, vpcs, tc.rr)
+
+			now := time.Now()
+			r.now = func() time.Time { return now }
+			for _, n := range []string{"a", "b", "c"} {
+				mg := &fake.Managed{}
+				mg.SetName(n)
+				_ = r.ResolveReferences(context.Background(), mg)
+				now = now.Add(tc.elapsed)
+			}
+
+			if diff := cmp.Diff(tc.want.lists, lists); diff != "" {
+				t.Errorf("\n%s\nr.ResolveReferences(...): -want lists, +got lists:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+type notReadyError struct{}
+
+func (notReadyError) Error() string  { return "not ready" }
+func (notReadyError) NotReady() bool { return true }
+
+type fakeResolver struct {
+	err error
+}
+
+func (f fakeResolver) ResolveReferences(_ context.Context, _ resource.Managed) error {
+	return f.err
+}
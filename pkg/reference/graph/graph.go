@@ -0,0 +1,401 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package graph builds and analyses the dependency graph formed by references
+// between resources.
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reference"
+)
+
+// A Node in the dependency graph is a resource.
+type Node struct {
+	Kind      schema.GroupKind
+	Namespace string
+	Name      string
+}
+
+// String returns a human readable representation of the Node, for example
+// 'VPC.ec2.aws.upbound.io/my-vpc'.
+func (n Node) String() string {
+	if n.Namespace == "" {
+		return n.Kind.String() + "/" + n.Name
+	}
+	return n.Kind.String() + "/" + n.Namespace + "/" + n.Name
+}
+
+// MarshalJSON marshals the Node to JSON.
+func (n Node) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Group     string `json:"group"`
+		Kind      string `json:"kind"`
+		Namespace string `json:"namespace,omitempty"`
+		Name      string `json:"name"`
+	}{Group: n.Kind.Group, Kind: n.Kind.Kind, Namespace: n.Namespace, Name: n.Name})
+}
+
+// An Edge in the dependency graph indicates that the From resource depends on
+// the To resource, because a field of the From resource refers to it.
+type Edge struct {
+	From Node `json:"from"`
+	To   Node `json:"to"`
+
+	// Field is the path of the reference or selector that refers to the To
+	// resource.
+	Field string `json:"field"`
+}
+
+// A Graph of dependencies between resources.
+type Graph struct {
+	nodes map[Node]struct{}
+	edges map[Edge]struct{}
+}
+
+// New returns an empty Graph.
+func New() *Graph {
+	return &Graph{nodes: make(map[Node]struct{}), edges: make(map[Edge]struct{})}
+}
+
+// AddNode adds the supplied resource to the graph.
+func (g *Graph) AddNode(n Node) {
+	g.nodes[n] = struct{}{}
+}
+
+// AddEdge adds the supplied dependency to the graph, adding its resources if
+// they are not already part of the graph.
+func (g *Graph) AddEdge(e Edge) {
+	g.AddNode(e.From)
+	g.AddNode(e.To)
+	g.edges[e] = struct{}{}
+}
+
+// Nodes returns the resources in the graph, in a deterministic order.
+func (g *Graph) Nodes() []Node {
+	nodes := make([]Node, 0, len(g.nodes))
+	for n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+// Edges returns the dependencies in the graph, in a deterministic order.
+func (g *Graph) Edges() []Edge {
+	edges := make([]Edge, 0, len(g.edges))
+	for e := range g.edges {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.From != b.From {
+			return less(a.From, b.From)
+		}
+		if a.To != b.To {
+			return less(a.To, b.To)
+		}
+		return a.Field < b.Field
+	})
+	return edges
+}
+
+// DependenciesOf returns the resources the supplied resource depends on, in a
+// deterministic order.
+func (g *Graph) DependenciesOf(n Node) []Node {
+	return g.adjacent()[n]
+}
+
+// adjacent returns each resource's distinct dependencies, in a deterministic
+// order.
+func (g *Graph) adjacent() map[Node][]Node {
+	adj := make(map[Node][]Node, len(g.nodes))
+	seen := make(map[[2]Node]bool, len(g.edges))
+	for _, e := range g.Edges() {
+		if seen[[2]Node{e.From, e.To}] {
+			continue
+		}
+		seen[[2]Node{e.From, e.To}] = true
+		adj[e.From] = append(adj[e.From], e.To)
+	}
+	return adj
+}
+
+// Cycles returns one cycle for each group of resources that depend on each
+// other, directly or indirectly. Each cycle starts and ends with the same
+// resource. Resources that depend on themselves form a cycle of their own.
+func (g *Graph) Cycles() [][]Node {
+	adj := g.adjacent()
+	sccs := cyclic(g.Nodes(), adj)
+	cycles := make([][]Node, len(sccs))
+	for i, scc := range sccs {
+		cycles[i] = cycleFrom(scc[0], scc, adj)
+	}
+	return cycles
+}
+
+// CycleContaining returns a cycle that contains the supplied resource, or nil
+// if the resource is not part of a cycle.
+func (g *Graph) CycleContaining(n Node) []Node {
+	adj := g.adjacent()
+	for _, scc := range cyclic(g.Nodes(), adj) {
+		if contains(scc, n) {
+			return cycleFrom(n, scc, adj)
+		}
+	}
+	return nil
+}
+
+// CreationOrder returns the resources in the graph ordered such that every
+// resource appears after the resources it depends on. Resources that could be
+// created at the same point are ordered deterministically. A CycleError is
+// returned if the graph contains a cycle, since no such order exists.
+func (g *Graph) CreationOrder() ([]Node, error) {
+	adj := g.adjacent()
+	dependents := make(map[Node][]Node, len(g.nodes))
+	pending := make(map[Node]int, len(g.nodes))
+	for from, tos := range adj {
+		pending[from] = len(tos)
+		for _, to := range tos {
+			dependents[to] = append(dependents[to], from)
+		}
+	}
+
+	ready := make([]Node, 0)
+	for _, n := range g.Nodes() {
+		if pending[n] == 0 {
+			ready = append(ready, n)
+		}
+	}
+
+	order := make([]Node, 0, len(g.nodes))
+	for len(ready) > 0 {
+		n := ready[0]
+		ready = ready[1:]
+		order = append(order, n)
+
+		unblocked := make([]Node, 0)
+		for _, d := range dependents[n] {
+			pending[d]--
+			if pending[d] == 0 {
+				unblocked = append(unblocked, d)
+			}
+		}
+		sortNodes(unblocked)
+		ready = append(ready, unblocked...)
+	}
+
+	if len(order) != len(g.nodes) {
+		return nil, cycleError(g.Cycles()[0])
+	}
+	return order, nil
+}
+
+// DeletionOrder returns the resources in the graph ordered such that every
+// resource appears before the resources it depends on. A CycleError is
+// returned if the graph contains a cycle, since no such order exists.
+func (g *Graph) DeletionOrder() ([]Node, error) {
+	order, err := g.CreationOrder()
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order, nil
+}
+
+// MarshalJSON marshals the graph to JSON as lists of nodes and edges.
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Nodes []Node `json:"nodes"`
+		Edges []Edge `json:"edges"`
+	}{Nodes: g.Nodes(), Edges: g.Edges()})
+}
+
+// WriteDOT writes the graph to the supplied writer in the Graphviz DOT
+// language. Dependencies that form part of a cycle are highlighted.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	// Resources are in a cycle if they're part of a strongly connected
+	// component, i.e. a group of resources that depend on each other.
+	scc := make(map[Node]int)
+	for i, c := range cyclic(g.Nodes(), g.adjacent()) {
+		for _, n := range c {
+			scc[n] = i + 1
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, "digraph references {"); err != nil {
+		return err
+	}
+	for _, n := range g.Nodes() {
+		attrs := ""
+		if scc[n] != 0 {
+			attrs = " [color=red]"
+		}
+		if _, err := fmt.Fprintf(w, "\t%q%s;\n", n.String(), attrs); err != nil {
+			return err
+		}
+	}
+	for _, e := range g.Edges() {
+		attrs := ""
+		if scc[e.From] != 0 && scc[e.From] == scc[e.To] {
+			attrs = ", color=red"
+		}
+		if _, err := fmt.Fprintf(w, "\t%q -> %q [label=%q%s];\n", e.From.String(), e.To.String(), e.Field, attrs); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// components returns the strongly connected components of the graph formed by
+// the supplied nodes and adjacency, using Tarjan's algorithm. Each component is
+// sorted, and components are ordered by their first node.
+func components(nodes []Node, adj map[Node][]Node) [][]Node {
+	index := make(map[Node]int, len(nodes))
+	low := make(map[Node]int, len(nodes))
+	onStack := make(map[Node]bool, len(nodes))
+	stack := make([]Node, 0)
+	sccs := make([][]Node, 0)
+
+	var connect func(n Node)
+	connect = func(n Node) {
+		index[n] = len(index)
+		low[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+
+		for _, m := range adj[n] {
+			if _, visited := index[m]; !visited {
+				connect(m)
+				low[n] = lowest(low[n], low[m])
+			} else if onStack[m] {
+				low[n] = lowest(low[n], index[m])
+			}
+		}
+
+		if low[n] != index[n] {
+			return
+		}
+		scc := make([]Node, 0)
+		for {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[m] = false
+			scc = append(scc, m)
+			if m == n {
+				break
+			}
+		}
+		sortNodes(scc)
+		sccs = append(sccs, scc)
+	}
+
+	for _, n := range nodes {
+		if _, visited := index[n]; !visited {
+			connect(n)
+		}
+	}
+	sort.Slice(sccs, func(i, j int) bool { return less(sccs[i][0], sccs[j][0]) })
+	return sccs
+}
+
+// cyclic returns the strongly connected components of the supplied graph that
+// contain a cycle; those with more than one node, or whose only node depends on
+// itself.
+func cyclic(nodes []Node, adj map[Node][]Node) [][]Node {
+	sccs := make([][]Node, 0)
+	for _, scc := range components(nodes, adj) {
+		if len(scc) == 1 && !contains(adj[scc[0]], scc[0]) {
+			continue
+		}
+		sccs = append(sccs, scc)
+	}
+	return sccs
+}
+
+// cycleFrom returns the shortest cycle that starts and ends with the supplied
+// node, which must be part of the supplied strongly connected component.
+func cycleFrom(start Node, scc []Node, adj map[Node][]Node) []Node {
+	parent := map[Node]Node{}
+	queue := []Node{start}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, m := range adj[n] {
+			if !contains(scc, m) {
+				continue
+			}
+			if m == start {
+				cycle := []Node{start}
+				for c := n; c != start; c = parent[c] {
+					cycle = append(cycle, c)
+				}
+				cycle = append(cycle, start)
+				for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return cycle
+			}
+			if _, seen := parent[m]; !seen {
+				parent[m] = n
+				queue = append(queue, m)
+			}
+		}
+	}
+	return nil
+}
+
+// cycleError returns a CycleError describing the supplied cycle.
+func cycleError(cycle []Node) *reference.CycleError {
+	names := make([]string, len(cycle))
+	for i := range cycle {
+		names[i] = cycle[i].String()
+	}
+	return &reference.CycleError{Cycle: names}
+}
+
+func lowest(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func contains(nodes []Node, n Node) bool {
+	for _, m := range nodes {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
+
+func less(a, b Node) bool {
+	return a.String() < b.String()
+}
+
+func sortNodes(nodes []Node) {
+	sort.Slice(nodes, func(i, j int) bool { return less(nodes[i], nodes[j]) })
+}
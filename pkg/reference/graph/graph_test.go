@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reference"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+var (
+	vpcs    = schema.GroupKind{Group: "ec2.aws", Kind: "VPC"}
+	subnets = schema.GroupKind{Group: "ec2.aws", Kind: "Subnet"}
+)
+
+func vpc(name string) Node    { return Node{Kind: vpcs, Name: name} }
+func subnet(name string) Node { return Node{Kind: subnets, Name: name} }
+
+func graphOf(edges ...Edge) *Graph {
+	g := New()
+	for _, e := range edges {
+		g.AddEdge(e)
+	}
+	return g
+}
+
+func TestCycles(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		g      *Graph
+		want   [][]Node
+	}{
+		"Acyclic": {
+			reason: "A graph without cycles should have no cycles.",
+			g: graphOf(
+				Edge{From: subnet("a"), To: vpc("a")},
+				Edge{From: subnet("b"), To: vpc("a")},
+			),
+			want: [][]Node{},
+		},
+		"SelfReference": {
+			reason: "A resource that refers to itself should form a cycle.",
+			g:      graphOf(Edge{From: vpc("a"), To: vpc("a")}),
+			want:   [][]Node{{vpc("a"), vpc("a")}},
+		},
+		"Cycles": {
+			reason: "One cycle should be returned for each group of resources that depend on each other.",
+			g: graphOf(
+				Edge{From: subnet("a"), To: vpc("a")},
+				Edge{From: vpc("a"), To: subnet("b")},
+				Edge{From: subnet("b"), To: subnet("a")},
+				Edge{From: vpc("b"), To: vpc("c")},
+				Edge{From: vpc("c"), To: vpc("b")},
+				Edge{From: vpc("d"), To: vpc("b")},
+			),
+			want: [][]Node{
+				{subnet("a"), vpc("a"), subnet("b"), subnet("a")},
+				{vpc("b"), vpc("c"), vpc("b")},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := tc.g.Cycles()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\ng.Cycles(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCreationOrder(t *testing.T) {
+	type want struct {
+		creation []Node
+		deletion []Node
+		err      error
+	}
+	cases := map[string]struct {
+		reason string
+		g      *Graph
+		want   want
+	}{
+		"Acyclic": {
+			reason: "Resources should be created after, and deleted before, the resources they depend on.",
+			g: func() *Graph {
+				g := graphOf(
+					Edge{From: subnet("b"), To: vpc("a"), Field: "spec.vpcIdRef"},
+					Edge{From: subnet("a"), To: vpc("a"), Field: "spec.vpcIdRef"},
+					Edge{From: subnet("a"), To: vpc("b"), Field: "spec.vpcIdSelector"},
+					Edge{From: vpc("a"), To: vpc("c"), Field: "spec.peerRef"},
+				)
+				g.AddNode(vpc("d"))
+				return g
+			}(),
+			want: want{
+				creation: []Node{vpc("b"), vpc("c"), vpc("d"), vpc("a"), subnet("a"), subnet("b")},
+				deletion: []Node{subnet("b"), subnet("a"), vpc("a"), vpc("d"), vpc("c"), vpc("b")},
+			},
+		},
+		"Cycle": {
+			reason: "A CycleError should be returned if the resources depend on each other.",
+			g: graphOf(
+				Edge{From: subnet("a"), To: vpc("a")},
+				Edge{From: vpc("a"), To: subnet("a")},
+				Edge{From: vpc("b"), To: vpc("a")},
+			),
+			want: want{
+				err: &reference.CycleError{Cycle: []string{"Subnet.ec2.aws/a", "VPC.ec2.aws/a", "Subnet.ec2.aws/a"}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			creation, err := tc.g.CreationOrder()
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ng.CreationOrder(): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.creation, creation); diff != "" {
+				t.Errorf("\n%s\ng.CreationOrder(): -want, +got:\n%s", tc.reason, diff)
+			}
+			deletion, err := tc.g.DeletionOrder()
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ng.DeletionOrder(): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.deletion, deletion); diff != "" {
+				t.Errorf("\n%s\ng.DeletionOrder(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	g := graphOf(
+		Edge{From: subnet("a"), To: vpc("a"), Field: "spec.vpcIdRef"},
+		Edge{From: vpc("a"), To: vpc("b"), Field: "spec.peerRef"},
+		Edge{From: vpc("b"), To: vpc("a"), Field: "spec.peerRef"},
+	)
+
+	t.Run("DOT", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		if err := g.WriteDOT(b); err != nil {
+			t.Fatalf("g.WriteDOT(...): %s", err)
+		}
+		want := `digraph references {
+	"Subnet.ec2.aws/a";
+	"VPC.ec2.aws/a" [color=red];
+	"VPC.ec2.aws/b" [color=red];
+	"Subnet.ec2.aws/a" -> "VPC.ec2.aws/a" [label="spec.vpcIdRef"];
+	"VPC.ec2.aws/a" -> "VPC.ec2.aws/b" [label="spec.peerRef", color=red];
+	"VPC.ec2.aws/b" -> "VPC.ec2.aws/a" [label="spec.peerRef", color=red];
+}
+`
+		if diff := cmp.Diff(want, b.String()); diff != "" {
+			t.Errorf("g.WriteDOT(...): -want, +got:\n%s", diff)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		got, err := json.Marshal(g)
+		if err != nil {
+			t.Fatalf("json.Marshal(...): %s", err)
+		}
+		want := `{"nodes":[` +
+			`{"group":"ec2.aws","kind":"Subnet","name":"a"},` +
+			`{"group":"ec2.aws","kind":"VPC","name":"a"},` +
+			`{"group":"ec2.aws","kind":"VPC","name":"b"}],` +
+			`"edges":[` +
+			`{"from":{"group":"ec2.aws","kind":"Subnet","name":"a"},"to":{"group":"ec2.aws","kind":"VPC","name":"a"},"field":"spec.vpcIdRef"},` +
+			`{"from":{"group":"ec2.aws","kind":"VPC","name":"a"},"to":{"group":"ec2.aws","kind":"VPC","name":"b"},"field":"spec.peerRef"},` +
+			`{"from":{"group":"ec2.aws","kind":"VPC","name":"b"},"to":{"group":"ec2.aws","kind":"VPC","name":"a"},"field":"spec.peerRef"}]}`
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("json.Marshal(...): -want, +got:\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"context"
+
+	kmeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+)
+
+// ReferencesIndex is the name of the field index built by IndexReferences.
+const ReferencesIndex = "crossplane.io/references"
+
+// A ReferenceField describes a field of a referencing resource that refers to
+// another kind of resource.
+type ReferenceField struct {
+	// To is the kind of resource the field refers to.
+	To schema.GroupKind
+
+	// Reference is the field path of an xpv1.Reference or of an array of
+	// xpv1.Reference, for example 'spec.forProvider.vpcIdRef'. The path may
+	// contain wildcards, for example 'spec.forProvider.rules[*].vpcIdRef'.
+	Reference string
+
+	// Selector is the field path of the xpv1.Selector used to select a value
+	// for Reference, if any, for example 'spec.forProvider.vpcIdSelector'.
+	// The path may contain the same wildcards as Reference, for example
+	// 'spec.forProvider.rules[*].vpcIdSelector'. Selectors are only indexed
+	// while the corresponding reference is unset; once a reference has been
+	// selected it is indexed as any other reference would be.
+	Selector string
+}
+
+// Get returns the references and the unresolved selectors of the supplied
+// referencing resource's field. References without a name are omitted.
+func (f ReferenceField) Get(o client.Object) ([]xpv1.Reference, []xpv1.Selector) {
+	p, err := fieldpath.PaveObject(o)
+	if err != nil {
+		return nil, nil
+	}
+	return getReferences(p, f.Reference), getSelectors(p, f.Selector, f.Reference)
+}
+
+// referenceKey returns the index key for a reference to the supplied name.
+func referenceKey(gk schema.GroupKind, name string) string {
+	return gk.String() + "/" + name
+}
+
+// selectorKey returns the index key for an unresolved selector.
+func selectorKey(gk schema.GroupKind) string {
+	return gk.String() + "/*"
+}
+
+// IndexReferences returns an IndexerFunc that indexes a referencing resource by
+// the resources it refers to via the supplied fields. Each reference is indexed
+// by the kind and name of the resource it refers to. Each unresolved selector is
+// indexed by the kind of resource it may select. The IndexerFunc should be
+// registered with ReferencesIndex, and used with an
+// EnqueueRequestsForReferencers handler.
+func IndexReferences(fields ...ReferenceField) client.IndexerFunc {
+	return func(o client.Object) []string {
+		p, err := fieldpath.PaveObject(o)
+		if err != nil {
+			return nil
+		}
+
+		keys := make([]string, 0)
+		for _, f := range fields {
+			for _, ref := range getReferences(p, f.Reference) {
+				keys = append(keys, referenceKey(f.To, ref.Name))
+			}
+			if len(getSelectors(p, f.Selector, f.Reference)) > 0 {
+				keys = append(keys, selectorKey(f.To))
+			}
+		}
+		return keys
+	}
+}
+
+// getReferences returns the references at the supplied field path, which may
+// contain wildcards. Fields that are unset or are not references are ignored.
+func getReferences(p *fieldpath.Paved, path string) []xpv1.Reference {
+	if path == "" {
+		return nil
+	}
+	paths, err := p.ExpandWildcards(path)
+	if err != nil {
+		return nil
+	}
+
+	refs := make([]xpv1.Reference, 0)
+	for _, path := range paths {
+		v, err := p.GetValue(path)
+		if err != nil {
+			continue
+		}
+		switch v.(type) {
+		case []any:
+			rs := []xpv1.Reference{}
+			if err := p.GetValueInto(path, &rs); err != nil {
+				continue
+			}
+			refs = append(refs, rs...)
+		case map[string]any:
+			r := xpv1.Reference{}
+			if err := p.GetValueInto(path, &r); err != nil {
+				continue
+			}
+			refs = append(refs, r)
+		}
+	}
+
+	// Drop references without a name; they don't refer to anything yet.
+	named := refs[:0]
+	for _, r := range refs {
+		if r.Name != "" {
+			named = append(named, r)
+		}
+	}
+	return named
+}
+
+// getSelectors returns the unresolved selectors at the supplied selector field
+// path, which may contain wildcards. A selector is unresolved while the
+// reference it selects a value for is unset. Each wildcard of the reference
+// path is expanded like the corresponding wildcard of the selector path, so
+// that a selector in an array element is only resolved by a reference in the
+// same element. Fields that are unset or are not selectors are ignored.
+func getSelectors(p *fieldpath.Paved, selector, reference string) []xpv1.Selector {
+	if selector == "" {
+		return nil
+	}
+	paths, err := p.ExpandWildcards(selector)
+	if err != nil {
+		return nil
+	}
+
+	sels := make([]xpv1.Selector, 0)
+	for _, path := range paths {
+		s := xpv1.Selector{}
+		if err := p.GetValueInto(path, &s); err != nil {
+			continue
+		}
+		if len(getReferences(p, expandLike(reference, selector, path))) > 0 {
+			continue
+		}
+		sels = append(sels, s)
+	}
+	return sels
+}
+
+// expandLike returns the supplied field path with its wildcards replaced by
+// the segments that the wildcards of the supplied pattern were expanded to in
+// the supplied expanded path. Wildcards that have no corresponding wildcard in
+// the pattern are left as is.
+func expandLike(path, pattern, expanded string) string {
+	ps, err := fieldpath.Parse(path)
+	if err != nil {
+		return path
+	}
+	pt, err := fieldpath.Parse(pattern)
+	if err != nil {
+		return path
+	}
+	ex, err := fieldpath.Parse(expanded)
+	if err != nil || len(pt) != len(ex) {
+		return path
+	}
+
+	expansions := make([]fieldpath.Segment, 0)
+	for i := range pt {
+		if isWildcard(pt[i]) {
+			expansions = append(expansions, ex[i])
+		}
+	}
+	for i := range ps {
+		if len(expansions) == 0 {
+			break
+		}
+		if isWildcard(ps[i]) {
+			ps[i] = expansions[0]
+			expansions = expansions[1:]
+		}
+	}
+	return ps.String()
+}
+
+func isWildcard(s fieldpath.Segment) bool {
+	return s.Type == fieldpath.SegmentField && s.Field == "*"
+}
+
+type adder interface {
+	Add(item any)
+}
+
+// EnqueueRequestsForReferencers enqueues a reconcile.Request for each resource
+// that refers to the resource that triggered an event. It uses the field index
+// built by IndexReferences to find referencing resources. Referenced resources
+// are typically watched using pkg/controller, for example:
+//
+//	controller.For(&v1beta1.VPC{}, reference.NewEnqueueRequestsForReferencers(c, &v1beta1.SubnetList{}, vpc, fields...))
+//
+// Update events only trigger a reconcile if the referenced resource's external
+// name or readiness changed, since those are the values references typically
+// resolve to or wait for.
+type EnqueueRequestsForReferencers struct {
+	client client.Reader
+	list   client.ObjectList
+	to     schema.GroupKind
+	fields []ReferenceField
+}
+
+// NewEnqueueRequestsForReferencers returns an EnqueueRequestsForReferencers
+// handler for events that concern the supplied kind of referenced resource.
+// The supplied client must be able to list referencing resources using the
+// field index built by IndexReferences with the supplied fields. The supplied
+// list is the kind of referencing resource to enqueue.
+func NewEnqueueRequestsForReferencers(c client.Reader, l client.ObjectList, to schema.GroupKind, fields ...ReferenceField) *EnqueueRequestsForReferencers {
+	return &EnqueueRequestsForReferencers{client: c, list: l, to: to, fields: fields}
+}
+
+// Create enqueues the referencers of the created resource.
+func (e *EnqueueRequestsForReferencers) Create(ctx context.Context, evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	e.add(ctx, evt.Object, q)
+}
+
+// Update enqueues the referencers of the updated resource if its external name
+// or readiness changed.
+func (e *EnqueueRequestsForReferencers) Update(ctx context.Context, evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	if meta.GetExternalName(evt.ObjectOld) == meta.GetExternalName(evt.ObjectNew) && isReady(evt.ObjectOld) == isReady(evt.ObjectNew) {
+		return
+	}
+	e.add(ctx, evt.ObjectNew, q)
+}
+
+// Delete enqueues the referencers of the deleted resource.
+func (e *EnqueueRequestsForReferencers) Delete(ctx context.Context, evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	e.add(ctx, evt.Object, q)
+}
+
+// Generic enqueues the referencers of the supplied resource.
+func (e *EnqueueRequestsForReferencers) Generic(ctx context.Context, evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	e.add(ctx, evt.Object, q)
+}
+
+func (e *EnqueueRequestsForReferencers) add(ctx context.Context, o client.Object, q adder) {
+	if o == nil {
+		return
+	}
+
+	// Event handlers can't return errors. Referencers that we fail to enqueue
+	// here will notice the change when they're next polled.
+	for _, r := range e.referencers(ctx, referenceKey(e.to, o.GetName())) {
+		q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: r.GetNamespace(), Name: r.GetName()}})
+	}
+
+	for _, r := range e.referencers(ctx, selectorKey(e.to)) {
+		if e.selects(r, o) {
+			q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: r.GetNamespace(), Name: r.GetName()}})
+		}
+	}
+}
+
+// referencers returns the referencing resources indexed by the supplied key.
+func (e *EnqueueRequestsForReferencers) referencers(ctx context.Context, key string) []client.Object {
+	l := e.list.DeepCopyObject().(client.ObjectList) //nolint:forcetypeassert // Guaranteed to be an ObjectList.
+	if err := e.client.List(ctx, l, client.MatchingFields{ReferencesIndex: key}); err != nil {
+		return nil
+	}
+	items, err := kmeta.ExtractList(l)
+	if err != nil {
+		return nil
+	}
+	objs := make([]client.Object, 0, len(items))
+	for _, i := range items {
+		if o, ok := i.(client.Object); ok {
+			objs = append(objs, o)
+		}
+	}
+	return objs
+}
+
+// selects returns true if any of the referencer's unresolved selectors of the
+// handler's kind of referenced resource would select the supplied resource.
+func (e *EnqueueRequestsForReferencers) selects(referencer, o client.Object) bool {
+	p, err := fieldpath.PaveObject(referencer)
+	if err != nil {
+		return false
+	}
+	for _, f := range e.fields {
+		if f.To != e.to {
+			continue
+		}
+		sels := getSelectors(p, f.Selector, f.Reference)
+		for i := range sels {
+			if Selects(&sels[i], referencer, o) {
+				return true
+			}
+		}
+	}
+	return false
+}
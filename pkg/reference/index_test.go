@@ -0,0 +1,237 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+var _ handler.EventHandler = &EnqueueRequestsForReferencers{}
+
+var (
+	vpc    = schema.GroupKind{Group: "example.org", Kind: "VPC"}
+	subnet = schema.GroupKind{Group: "example.org", Kind: "Subnet"}
+
+	vpcField = ReferenceField{
+		To:        vpc,
+		Reference: "spec.forProvider.vpcIdRef",
+		Selector:  "spec.forProvider.vpcIdSelector",
+	}
+	subnetsField = ReferenceField{
+		To:        subnet,
+		Reference: "spec.forProvider.rules[*].subnetIdRefs",
+	}
+	rulesField = ReferenceField{
+		To:        vpc,
+		Reference: "spec.forProvider.rules[*].vpcIdRef",
+		Selector:  "spec.forProvider.rules[*].vpcIdSelector",
+	}
+)
+
+func referencer(name string, forProvider map[string]any) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"forProvider": forProvider}}}
+	u.SetName(name)
+	return u
+}
+
+func TestIndexReferences(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		o      client.Object
+		want   []string
+	}{
+		"Reference": {
+			reason: "A reference should be indexed by the kind and name of the resource it refers to.",
+			o: referencer("cool", map[string]any{
+				"vpcIdRef":      map[string]any{"name": "cool-vpc"},
+				"vpcIdSelector": map[string]any{"matchLabels": map[string]any{"cool": "true"}},
+			}),
+			want: []string{"VPC.example.org/cool-vpc"},
+		},
+		"Selector": {
+			reason: "An unresolved selector should be indexed by the kind of resource it may select.",
+			o: referencer("cool", map[string]any{
+				"vpcIdSelector": map[string]any{"matchLabels": map[string]any{"cool": "true"}},
+			}),
+			want: []string{"VPC.example.org/*"},
+		},
+		"WildcardReferences": {
+			reason: "Arrays of references should be indexed, including those found via wildcards.",
+			o: referencer("cool", map[string]any{
+				"rules": []any{
+					map[string]any{"subnetIdRefs": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}},
+					map[string]any{"subnetIdRefs": []any{map[string]any{"name": "c"}}},
+				},
+			}),
+			want: []string{"Subnet.example.org/a", "Subnet.example.org/b", "Subnet.example.org/c"},
+		},
+		"WildcardSelectors": {
+			reason: "Unresolved selectors found via wildcards should be indexed, even if other elements' references are set.",
+			o: referencer("cool", map[string]any{
+				"rules": []any{
+					map[string]any{"vpcIdRef": map[string]any{"name": "cool-vpc"}},
+					map[string]any{"vpcIdSelector": map[string]any{"matchLabels": map[string]any{"cool": "true"}}},
+				},
+			}),
+			want: []string{"VPC.example.org/cool-vpc", "VPC.example.org/*"},
+		},
+		"WildcardSelectorsResolved": {
+			reason: "Selectors found via wildcards should not be indexed once the reference in the same element is set.",
+			o: referencer("cool", map[string]any{
+				"rules": []any{
+					map[string]any{
+						"vpcIdRef":      map[string]any{"name": "cool-vpc"},
+						"vpcIdSelector": map[string]any{"matchLabels": map[string]any{"cool": "true"}},
+					},
+				},
+			}),
+			want: []string{"VPC.example.org/cool-vpc"},
+		},
+		"NoReferences": {
+			reason: "A resource without references should not be indexed.",
+			o:      referencer("cool", map[string]any{}),
+			want:   []string{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := IndexReferences(vpcField, subnetsField, rulesField)(tc.o)
+			if diff := cmp.Diff(tc.want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("\n%s\nIndexReferences(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestEnqueueRequestsForReferencers(t *testing.T) {
+	// The client lists the referencers whose index keys match the requested
+	// key, as a cache with the index registered would.
+	referencers := []*unstructured.Unstructured{
+		referencer("by-ref", map[string]any{"vpcIdRef": map[string]any{"name": "cool-vpc"}}),
+		referencer("by-selector", map[string]any{"vpcIdSelector": map[string]any{"matchLabels": map[string]any{"cool": "true"}}}),
+		referencer("by-other-selector", map[string]any{"vpcIdSelector": map[string]any{"matchLabels": map[string]any{"cool": "false"}}}),
+		referencer("unrelated", map[string]any{"vpcIdRef": map[string]any{"name": "other-vpc"}}),
+		referencer("by-rule-selector", map[string]any{"rules": []any{
+			map[string]any{"vpcIdRef": map[string]any{"name": "other-vpc"}},
+			map[string]any{"vpcIdSelector": map[string]any{"matchLabels": map[string]any{"cool": "true"}}},
+		}}),
+		referencer("by-resolved-rule-selector", map[string]any{"rules": []any{
+			map[string]any{
+				"vpcIdRef":      map[string]any{"name": "other-vpc"},
+				"vpcIdSelector": map[string]any{"matchLabels": map[string]any{"cool": "true"}},
+			},
+		}}),
+	}
+	c := &test.MockClient{
+		MockList: func(_ context.Context, l client.ObjectList, opts ...client.ListOption) error {
+			lo := &client.ListOptions{}
+			lo.ApplyOptions(opts)
+			key, _ := lo.FieldSelector.RequiresExactMatch(ReferencesIndex)
+			ul := l.(*unstructured.UnstructuredList)
+			for _, r := range referencers {
+				for _, k := range IndexReferences(vpcField, rulesField)(r) {
+					if k == key {
+						ul.Items = append(ul.Items, *r)
+					}
+				}
+			}
+			return nil
+		},
+	}
+
+	target := func(ready bool, extName string) *fake.Managed {
+		mg := &fake.Managed{ObjectMeta: metav1.ObjectMeta{Name: "cool-vpc", Labels: map[string]string{"cool": "true"}}}
+		meta.SetExternalName(mg, extName)
+		if ready {
+			mg.SetConditions(xpv1.Available())
+		}
+		return mg
+	}
+
+	cases := map[string]struct {
+		reason string
+		evt    func(h *EnqueueRequestsForReferencers, q workqueue.RateLimitingInterface)
+		want   []string
+	}{
+		"Create": {
+			reason: "Resources that refer to or may select a created resource should be enqueued.",
+			evt: func(h *EnqueueRequestsForReferencers, q workqueue.RateLimitingInterface) {
+				h.Create(context.Background(), event.CreateEvent{Object: target(false, "a")}, q)
+			},
+			want: []string{"by-ref", "by-selector", "by-rule-selector"},
+		},
+		"ExternalNameChanged": {
+			reason: "Referencers should be enqueued when the external name of the referenced resource changes.",
+			evt: func(h *EnqueueRequestsForReferencers, q workqueue.RateLimitingInterface) {
+				h.Update(context.Background(), event.UpdateEvent{ObjectOld: target(false, "a"), ObjectNew: target(false, "b")}, q)
+			},
+			want: []string{"by-ref", "by-selector", "by-rule-selector"},
+		},
+		"BecameReady": {
+			reason: "Referencers should be enqueued when the referenced resource becomes ready.",
+			evt: func(h *EnqueueRequestsForReferencers, q workqueue.RateLimitingInterface) {
+				h.Update(context.Background(), event.UpdateEvent{ObjectOld: target(false, "a"), ObjectNew: target(true, "a")}, q)
+			},
+			want: []string{"by-ref", "by-selector", "by-rule-selector"},
+		},
+		"Unchanged": {
+			reason: "Referencers should not be enqueued when neither the external name nor readiness changed.",
+			evt: func(h *EnqueueRequestsForReferencers, q workqueue.RateLimitingInterface) {
+				h.Update(context.Background(), event.UpdateEvent{ObjectOld: target(true, "a"), ObjectNew: target(true, "a")}, q)
+			},
+			want: []string{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+			defer q.ShutDown()
+
+			h := NewEnqueueRequestsForReferencers(c, &unstructured.UnstructuredList{}, vpc, vpcField, rulesField)
+			tc.evt(h, q)
+
+			got := []string{}
+			for q.Len() > 0 {
+				i, _ := q.Get()
+				got = append(got, i.(reconcile.Request).Name)
+				q.Done(i)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("\n%s\n-want enqueued, +got enqueued:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
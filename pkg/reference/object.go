@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+// Error strings.
+const (
+	errSecretFieldPath  = "cannot extract a value from a Secret using a field path; use ExtractSecretKey"
+	errSecretDataKey    = "cannot extract a value from a Secret using ExtractDataKey; use ExtractSecretKey"
+	errNotSecret        = "referenced object is not a Secret"
+	errConvertSecret    = "cannot convert unstructured object to a Secret"
+	errMarshalSensitive = "refusing to marshal a sensitive value"
+)
+
+// redacted is how a SensitiveValue is represented when formatted or logged.
+const redacted = "REDACTED"
+
+// ToUnstructured returns a To that refers to objects of the supplied kind.
+// The kind need not be known to the client's scheme. Set the Namespace of the
+// returned To to refer to namespaced objects.
+func ToUnstructured(gvk schema.GroupVersionKind) To {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	l := &unstructured.UnstructuredList{}
+	l.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	return To{Object: u, ObjectList: l}
+}
+
+// ExtractDataKey extracts the value of the supplied key from the data of the
+// resolved object, for example a ConfigMap. A ConfigMap's binary data is used
+// if its data does not contain the key. A WaitingError is returned if the key
+// is not set. Values can't be extracted from Secrets; use ExtractSecretKey.
+func ExtractDataKey(key string) TypedExtractValueFn[string] {
+	path := fieldpath.Segments{fieldpath.Field("data"), fieldpath.Field(key)}.String()
+	return func(o client.Object) (string, error) {
+		if isSecret(o) {
+			return "", errors.New(errSecretDataKey)
+		}
+		if cm, ok := o.(*corev1.ConfigMap); ok {
+			if v, ok := cm.Data[key]; ok && v != "" {
+				return v, nil
+			}
+			if v, ok := cm.BinaryData[key]; ok && len(v) > 0 {
+				return string(v), nil
+			}
+			return "", &WaitingError{Field: path, Blockers: []string{o.GetName()}}
+		}
+		return ExtractFieldPathAs[string](path)(o)
+	}
+}
+
+// A SensitiveValue is a value extracted from a Secret. It is redacted when it
+// is formatted or logged, and it refuses to be marshalled to JSON so that it
+// can't be written to a resource's spec. Use Reveal to access the value.
+type SensitiveValue struct {
+	v []byte
+}
+
+// Reveal the sensitive value.
+func (s SensitiveValue) Reveal() []byte {
+	return s.v
+}
+
+// String returns a redacted representation of the sensitive value.
+func (s SensitiveValue) String() string {
+	return redacted
+}
+
+// GoString returns a redacted representation of the sensitive value.
+func (s SensitiveValue) GoString() string {
+	return redacted
+}
+
+// Format the sensitive value as a redacted string, regardless of verb.
+func (s SensitiveValue) Format(f fmt.State, _ rune) {
+	_, _ = f.Write([]byte(redacted))
+}
+
+// MarshalLog returns a redacted representation of the sensitive value. It
+// satisfies logr.Marshaler.
+func (s SensitiveValue) MarshalLog() any {
+	return redacted
+}
+
+// MarshalJSON always returns an error.
+func (s SensitiveValue) MarshalJSON() ([]byte, error) {
+	return nil, errors.New(errMarshalSensitive)
+}
+
+// ExtractSecretKey extracts the value of the supplied key from the data of the
+// resolved Secret. The value is returned as a SensitiveValue. A WaitingError
+// is returned if the key is not set.
+func ExtractSecretKey(key string) TypedExtractValueFn[SensitiveValue] {
+	path := fieldpath.Segments{fieldpath.Field("data"), fieldpath.Field(key)}.String()
+	return func(o client.Object) (SensitiveValue, error) {
+		if !isSecret(o) {
+			return SensitiveValue{}, errors.New(errNotSecret)
+		}
+		s, ok := o.(*corev1.Secret)
+		if !ok {
+			s = &corev1.Secret{}
+			u, _ := o.(*unstructured.Unstructured) // isSecret guarantees this.
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), s); err != nil {
+				return SensitiveValue{}, errors.Wrap(err, errConvertSecret)
+			}
+		}
+		v, ok := s.Data[key]
+		if !ok || len(v) == 0 {
+			return SensitiveValue{}, &WaitingError{Field: path, Blockers: []string{o.GetName()}}
+		}
+		return SensitiveValue{v: v}, nil
+	}
+}
+
+// isSecret returns true if the supplied object is a Secret.
+func isSecret(o client.Object) bool {
+	switch s := o.(type) {
+	case *corev1.Secret:
+		return true
+	case *unstructured.Unstructured:
+		return s.GroupVersionKind().GroupKind() == schema.GroupKind{Kind: "Secret"}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestExtractDataKey(t *testing.T) {
+	cm := &unstructured.Unstructured{}
+	cm.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	cm.SetName("cool")
+	_ = unstructured.SetNestedField(cm.Object, "unstructured", "data", "cool.key")
+
+	secret := &unstructured.Unstructured{}
+	secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+
+	type want struct {
+		v   string
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		o      client.Object
+		want   want
+	}{
+		"ConfigMapData": {
+			reason: "A value should be extracted from a ConfigMap's data.",
+			o:      &corev1.ConfigMap{Data: map[string]string{"cool.key": "data"}},
+			want:   want{v: "data"},
+		},
+		"ConfigMapBinaryData": {
+			reason: "A value should be extracted from a ConfigMap's binary data if it's not in its data.",
+			o:      &corev1.ConfigMap{BinaryData: map[string][]byte{"cool.key": []byte("binary")}},
+			want:   want{v: "binary"},
+		},
+		"ConfigMapMissingKey": {
+			reason: "A WaitingError should be returned if the ConfigMap does not contain the key.",
+			o:      &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cool"}},
+			want:   want{err: &WaitingError{Field: "data[cool.key]", Blockers: []string{"cool"}}},
+		},
+		"Unstructured": {
+			reason: "A value should be extracted from the data of an unstructured object.",
+			o:      cm,
+			want:   want{v: "unstructured"},
+		},
+		"Secret": {
+			reason: "Values should never be extracted from a Secret.",
+			o:      &corev1.Secret{Data: map[string][]byte{"cool.key": []byte("secret")}},
+			want:   want{err: errors.New(errSecretDataKey)},
+		},
+		"UnstructuredSecret": {
+			reason: "Values should never be extracted from an unstructured Secret.",
+			o:      secret,
+			want:   want{err: errors.New(errSecretDataKey)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v, err := ExtractDataKey("cool.key")(tc.o)
+			if diff := cmp.Diff(tc.want.v, v); diff != "" {
+				t.Errorf("\n%s\nExtractDataKey(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nExtractDataKey(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExtractSecretKey(t *testing.T) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	_ = unstructured.SetNestedField(u.Object, "c2VjcmV0", "data", "key") // base64 of 'secret'.
+
+	type want struct {
+		v   []byte
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		o      client.Object
+		want   want
+	}{
+		"Secret": {
+			reason: "A value should be extracted from a Secret's data.",
+			o:      &corev1.Secret{Data: map[string][]byte{"key": []byte("secret")}},
+			want:   want{v: []byte("secret")},
+		},
+		"UnstructuredSecret": {
+			reason: "A value should be extracted from an unstructured Secret's data.",
+			o:      u,
+			want:   want{v: []byte("secret")},
+		},
+		"MissingKey": {
+			reason: "A WaitingError should be returned if the Secret does not contain the key.",
+			o:      &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cool"}},
+			want:   want{err: &WaitingError{Field: "data.key", Blockers: []string{"cool"}}},
+		},
+		"NotASecret": {
+			reason: "An error should be returned if the object is not a Secret.",
+			o:      &corev1.ConfigMap{},
+			want:   want{err: errors.New(errNotSecret)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v, err := ExtractSecretKey("key")(tc.o)
+			if diff := cmp.Diff(tc.want.v, v.Reveal()); diff != "" {
+				t.Errorf("\n%s\nExtractSecretKey(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nExtractSecretKey(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSensitiveValue(t *testing.T) {
+	s := SensitiveValue{v: []byte("secret")}
+
+	for _, verb := range []string{"%s", "%v", "%+v", "%#v", "%q", "%x", "%d"} {
+		if got := fmt.Sprintf(verb, s); got != redacted {
+			t.Errorf("fmt.Sprintf(%q, ...): want %q, got %q", verb, redacted, got)
+		}
+	}
+
+	// A sensitive value should never be written to a resource's spec.
+	spec := struct {
+		Value SensitiveValue `json:"value"`
+	}{Value: s}
+	if _, err := json.Marshal(spec); err == nil {
+		t.Errorf("json.Marshal(...): want error, got nil")
+	}
+}
+
+func TestResolveTypedObject(t *testing.T) {
+	gvk := corev1.SchemeGroupVersion.WithKind("ConfigMap")
+	ref := &xpv1.Reference{Name: "cool"}
+
+	c := &test.MockClient{
+		MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+			if key.Namespace != "default" {
+				return errors.Errorf("unexpected namespace %q", key.Namespace)
+			}
+			u := obj.(*unstructured.Unstructured)
+			if u.GroupVersionKind() != gvk {
+				return errors.Errorf("unexpected kind %q", u.GroupVersionKind())
+			}
+			u.SetName(key.Name)
+			return unstructured.SetNestedField(u.Object, "10.0.0.0/16", "data", "cidr")
+		},
+	}
+
+	to := ToUnstructured(gvk)
+	to.Namespace = "default"
+
+	r := NewAPIResolver(c, &fake.Managed{})
+	got, err := ResolveTyped(context.Background(), r, TypedResolutionRequest[string]{
+		Reference: ref,
+		To:        to,
+		Extract:   ExtractDataKey("cidr"),
+	})
+	if err != nil {
+		t.Fatalf("ResolveTyped(...): %s", err)
+	}
+	want := TypedResolutionResponse[string]{ResolvedValue: "10.0.0.0/16", ResolvedReference: ref}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ResolveTyped(...): -want, +got:\n%s", diff)
+	}
+
+	// Values can only be extracted from managed resources using an untyped
+	// ResolutionRequest.
+	_, err = r.Resolve(context.Background(), ResolutionRequest{Reference: ref, To: to, Extract: ExternalName()})
+	if diff := cmp.Diff(errors.New(errNotManaged), err, test.EquateErrors()); diff != "" {
+		t.Errorf("r.Resolve(...): -want error, +got error:\n%s", diff)
+	}
+}
@@ -20,14 +20,17 @@ package reference
 
 import (
 	"context"
+	"fmt"
 	"strconv"
+	"strings"
 
-	kerrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	kmeta "k8s.io/apimachinery/pkg/api/meta"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 )
@@ -36,10 +39,100 @@ import (
 const (
 	errGetManaged  = "cannot get referenced resource"
 	errListManaged = "cannot list resources that match selector"
+	errListObjects = "cannot list referenced resources"
 	errNoMatches   = "no resources matched selector"
 	errNoValue     = "referenced field was empty (referenced resource may not yet be ready)"
+	errNotManaged  = "referenced object is not a managed resource"
+
+	errFmtNotReady          = "waiting for referenced resources to become ready: %s"
+	errFmtFieldNotAvailable = "waiting for %s of referenced resources to become available: %s"
+	errFmtUnsupportedValue  = "cannot use value of type %T at %s as a reference value"
+	errFmtCycle             = "references form a cycle: %s"
 )
 
+// A WaitingError indicates that a reference could not be resolved because the
+// resources it refers to are not yet ready, or because the field a value is
+// extracted from is not yet available. Resolution should be retried once they
+// are.
+type WaitingError struct {
+	// Blockers are the names of the referenced resources that are not yet
+	// ready.
+	Blockers []string
+
+	// Field is the path of the field that is not yet available. It is empty
+	// if the referenced resources are waited for because they are not ready.
+	Field string
+}
+
+// Error returns a message naming the referenced resources that are not yet
+// ready.
+func (e *WaitingError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf(errFmtFieldNotAvailable, e.Field, strings.Join(e.Blockers, ", "))
+	}
+	return fmt.Sprintf(errFmtNotReady, strings.Join(e.Blockers, ", "))
+}
+
+// IsWaiting returns true if the supplied error indicates that a reference
+// could not be resolved because the resources it refers to are not yet ready,
+// or the value it refers to is not yet available.
+func IsWaiting(err error) bool {
+	we := &WaitingError{}
+	return errors.As(err, &we)
+}
+
+// A CycleError indicates that a reference can never be resolved because the
+// referencing resource depends, directly or indirectly, on itself.
+type CycleError struct {
+	// Cycle is the names of the resources that form the cycle, in reference
+	// order, starting and ending with the referencing resource.
+	Cycle []string
+}
+
+// Error returns a message describing the cycle.
+func (e *CycleError) Error() string {
+	return fmt.Sprintf(errFmtCycle, strings.Join(e.Cycle, " -> "))
+}
+
+// IsCycle returns true if the supplied error indicates that a reference can
+// never be resolved because the referencing resource depends on itself.
+func IsCycle(err error) bool {
+	ce := &CycleError{}
+	return errors.As(err, &ce)
+}
+
+// A noValueError indicates that the value extracted from a referenced resource
+// was empty, presumably because the resource is not yet ready.
+type noValueError struct{}
+
+func (noValueError) Error() string { return errNoValue }
+
+// NotReady returns true.
+func (noValueError) NotReady() bool { return true }
+
+// IsNotReady returns true if the supplied error indicates that a reference
+// could not be resolved because a referenced resource, or a value extracted
+// from one, is not yet ready. This includes a WaitingError, the error returned
+// when an extracted value is empty, and any error with a NotReady method that
+// returns true, such as one returned by a TypedExtractValueFn.
+func IsNotReady(err error) bool {
+	if IsWaiting(err) {
+		return true
+	}
+	var nr interface{ NotReady() bool }
+	return errors.As(err, &nr) && nr.NotReady()
+}
+
+// isReady returns true if the supplied object has a Ready condition that is
+// True. Objects without conditions are never ready.
+func isReady(o client.Object) bool {
+	c, ok := o.(resource.Conditioned)
+	if !ok {
+		return false
+	}
+	return c.GetCondition(xpv1.TypeReady).Status == corev1.ConditionTrue
+}
+
 // NOTE(negz): There are many equivalents of FromPtrValue and ToPtrValue
 // throughout the Crossplane codebase. We duplicate them here to reduce the
 // number of packages our API types have to import to support references.
@@ -162,14 +255,75 @@ func ToIntPtrValues(v []string) []*int64 {
 	return res
 }
 
-// To indicates the kind of managed resource a reference is to.
+// To indicates the kind of object a reference is to. References are usually
+// to managed resources, but may be to any kind of object by setting Object and
+// ObjectList instead of Managed and List.
 type To struct {
 	Managed resource.Managed
 	List    resource.ManagedList
+
+	// Object and ObjectList take precedence over Managed and List when set.
+	// They may be any kind of object, for example a ConfigMap or an
+	// *unstructured.Unstructured with its GroupVersionKind set.
+	Object     client.Object
+	ObjectList client.ObjectList
+
+	// Namespace of the referenced objects, if they are namespaced.
+	Namespace string
+}
+
+// object returns the object a referenced object should be read into.
+func (t To) object() client.Object {
+	if t.Object != nil {
+		return t.Object
+	}
+	return t.Managed
+}
+
+// list returns the list referenced objects should be listed into.
+func (t To) list() client.ObjectList {
+	if t.ObjectList != nil {
+		return t.ObjectList
+	}
+	return t.List
+}
+
+// items returns the objects in the list referenced objects were listed into.
+func (t To) items() ([]client.Object, error) {
+	if t.ObjectList == nil {
+		items := t.List.GetItems()
+		objs := make([]client.Object, len(items))
+		for i := range items {
+			objs[i] = items[i]
+		}
+		return objs, nil
+	}
+
+	items, err := kmeta.ExtractList(t.ObjectList)
+	if err != nil {
+		return nil, errors.Wrap(err, errListManaged)
+	}
+	objs := make([]client.Object, 0, len(items))
+	for _, i := range items {
+		if o, ok := i.(client.Object); ok {
+			objs = append(objs, o)
+		}
+	}
+	return objs, nil
+}
+
+// ListObjects lists all objects of the kind indicated by the supplied To, in
+// its namespace if it has one.
+func ListObjects(ctx context.Context, c client.Reader, to To) ([]client.Object, error) {
+	if err := c.List(ctx, to.list(), client.InNamespace(to.Namespace)); err != nil {
+		return nil, errors.Wrap(err, errListObjects)
+	}
+	return to.items()
 }
 
 // An ExtractValueFn specifies how to extract a value from the resolved managed
-// resource.
+// resource. Use a TypedExtractValueFn to extract a value from an object that is
+// not a managed resource.
 type ExtractValueFn func(resource.Managed) string
 
 // ExternalName extracts the resolved managed resource's external name from its
@@ -180,6 +334,49 @@ func ExternalName() ExtractValueFn {
 	}
 }
 
+// An ExtractValueOrErrorFn specifies how to extract a value from the resolved
+// managed resource. It returns an error if the value cannot be extracted.
+type ExtractValueOrErrorFn func(resource.Managed) (string, error)
+
+// ExtractFieldPath extracts the value at the supplied field path of the
+// resolved managed resource, for example 'status.atProvider.arn'. Numbers and
+// booleans are converted to strings. A WaitingError is returned if the field
+// is unset or empty, since it's typically populated once the referenced
+// resource has been created.
+func ExtractFieldPath(path string) ExtractValueOrErrorFn {
+	return func(mg resource.Managed) (string, error) {
+		p, err := fieldpath.PaveObject(mg)
+		if err != nil {
+			return "", err
+		}
+		v, err := p.GetValue(path)
+		if fieldpath.IsNotFound(err) {
+			return "", &WaitingError{Field: path, Blockers: []string{mg.GetName()}}
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch val := v.(type) {
+		case string:
+			if val == "" {
+				return "", &WaitingError{Field: path, Blockers: []string{mg.GetName()}}
+			}
+			return val, nil
+		case bool:
+			return strconv.FormatBool(val), nil
+		case int64:
+			return strconv.FormatInt(val, 10), nil
+		case float64:
+			return strconv.FormatFloat(val, 'f', -1, 64), nil
+		case nil:
+			return "", &WaitingError{Field: path, Blockers: []string{mg.GetName()}}
+		default:
+			return "", errors.Errorf(errFmtUnsupportedValue, v, path)
+		}
+	}
+}
+
 // A ResolutionRequest requests that a reference to a particular kind of
 // managed resource be resolved.
 type ResolutionRequest struct {
@@ -188,34 +385,37 @@ type ResolutionRequest struct {
 	Selector     *xpv1.Selector
 	To           To
 	Extract      ExtractValueFn
+
+	// ExtractOrError is used instead of Extract when set.
+	ExtractOrError ExtractValueOrErrorFn
 }
 
-// IsNoOp returns true if the supplied ResolutionRequest cannot or should not be
-// processed.
-func (rr *ResolutionRequest) IsNoOp() bool {
-	isAlways := false
-	if rr.Selector != nil {
-		if rr.Selector.Policy.IsResolvePolicyAlways() {
-			rr.Reference = nil
-			isAlways = true
-		}
-	} else if rr.Reference != nil {
-		if rr.Reference.Policy.IsResolvePolicyAlways() {
-			isAlways = true
-		}
+// extract the requested value from the supplied managed resource.
+func (rr *ResolutionRequest) extract(mg resource.Managed) (string, error) {
+	if rr.ExtractOrError != nil {
+		return rr.ExtractOrError(mg)
 	}
+	return rr.Extract(mg), nil
+}
 
-	// We don't resolve values that are already set (if reference resolution policy
-	// is not set to Always); we effectively cache resolved values. The CR author
-	// can invalidate the cache and trigger a new resolution by explicitly clearing
-	// the resolved value.
-	if rr.CurrentValue != "" && !isAlways {
-		return true
+// typed returns the equivalent TypedResolutionRequest.
+func (rr *ResolutionRequest) typed() TypedResolutionRequest[string] {
+	return TypedResolutionRequest[string]{
+		CurrentValue: rr.CurrentValue,
+		Reference:    rr.Reference,
+		Selector:     rr.Selector,
+		To:           rr.To,
+		Extract:      managedOnly(rr.extract),
 	}
+}
 
-	// We can't resolve anything if neither a reference nor a selector were
-	// provided.
-	return rr.Reference == nil && rr.Selector == nil
+// IsNoOp returns true if the supplied ResolutionRequest cannot or should not be
+// processed.
+func (rr *ResolutionRequest) IsNoOp() bool {
+	t := rr.typed()
+	noop := t.IsNoOp()
+	rr.Reference = t.Reference
+	return noop
 }
 
 // A ResolutionResponse returns the result of a reference resolution. The
@@ -227,11 +427,7 @@ type ResolutionResponse struct {
 
 // Validate this ResolutionResponse.
 func (rr ResolutionResponse) Validate() error {
-	if rr.ResolvedValue == "" {
-		return errors.New(errNoValue)
-	}
-
-	return nil
+	return TypedResolutionResponse[string](rr).Validate()
 }
 
 // A MultiResolutionRequest requests that several references to a particular
@@ -242,38 +438,37 @@ type MultiResolutionRequest struct {
 	Selector      *xpv1.Selector
 	To            To
 	Extract       ExtractValueFn
+
+	// ExtractOrError is used instead of Extract when set.
+	ExtractOrError ExtractValueOrErrorFn
 }
 
-// IsNoOp returns true if the supplied MultiResolutionRequest cannot or should
-// not be processed.
-func (rr *MultiResolutionRequest) IsNoOp() bool {
-	isAlways := false
-	if rr.Selector != nil {
-		if rr.Selector.Policy.IsResolvePolicyAlways() {
-			rr.References = nil
-			isAlways = true
-		}
-	} else {
-		for _, r := range rr.References {
-			if r.Policy.IsResolvePolicyAlways() {
-				isAlways = true
-				break
-			}
-		}
+// extract the requested value from the supplied managed resource.
+func (rr *MultiResolutionRequest) extract(mg resource.Managed) (string, error) {
+	if rr.ExtractOrError != nil {
+		return rr.ExtractOrError(mg)
 	}
+	return rr.Extract(mg), nil
+}
 
-	// We don't resolve values that are already set (if reference resolution policy
-	// is not set to Always); we effectively cache resolved values. The CR author
-	// can invalidate the cache and trigger a new resolution by explicitly clearing
-	// the resolved values. This is a little unintuitive for the APIMultiResolver
-	// but mimics the UX of the APIResolver and simplifies the overall mental model.
-	if len(rr.CurrentValues) > 0 && !isAlways {
-		return true
+// typed returns the equivalent TypedMultiResolutionRequest.
+func (rr *MultiResolutionRequest) typed() TypedMultiResolutionRequest[string] {
+	return TypedMultiResolutionRequest[string]{
+		CurrentValues: rr.CurrentValues,
+		References:    rr.References,
+		Selector:      rr.Selector,
+		To:            rr.To,
+		Extract:       managedOnly(rr.extract),
 	}
+}
 
-	// We can't resolve anything if neither a reference nor a selector were
-	// provided.
-	return len(rr.References) == 0 && rr.Selector == nil
+// IsNoOp returns true if the supplied MultiResolutionRequest cannot or should
+// not be processed.
+func (rr *MultiResolutionRequest) IsNoOp() bool {
+	t := rr.typed()
+	noop := t.IsNoOp()
+	rr.References = t.References
+	return noop
 }
 
 // A MultiResolutionResponse returns the result of several reference
@@ -286,118 +481,69 @@ type MultiResolutionResponse struct {
 
 // Validate this MultiResolutionResponse.
 func (rr MultiResolutionResponse) Validate() error {
-	if len(rr.ResolvedValues) == 0 {
-		return errors.New(errNoMatches)
-	}
-
-	for i, v := range rr.ResolvedValues {
-		if v == "" {
-			return getResolutionError(rr.ResolvedReferences[i].Policy, errors.New(errNoValue))
-		}
-	}
-
-	return nil
+	return TypedMultiResolutionResponse[string](rr).Validate()
 }
 
 // An APIResolver selects and resolves references to managed resources in the
-// Kubernetes API server.
+// Kubernetes API server. An APIResolver reads each referenced resource at most
+// once, so it should be used to resolve all of a managed resource's references
+// during a reconcile, then discarded.
 type APIResolver struct {
 	client client.Reader
 	from   resource.Managed
+
+	maxConcurrentGets int
+	listThreshold     int
+	cache             objectCache
 }
 
 // NewAPIResolver returns a Resolver that selects and resolves references from
 // the supplied managed resource to other managed resources in the Kubernetes
 // API server.
-func NewAPIResolver(c client.Reader, from resource.Managed) *APIResolver {
-	return &APIResolver{client: c, from: from}
+func NewAPIResolver(c client.Reader, from resource.Managed, o ...APIResolverOption) *APIResolver {
+	r := &APIResolver{client: c, from: from, maxConcurrentGets: DefaultMaxConcurrentGets}
+	for _, ro := range o {
+		ro(r)
+	}
+	return r
 }
 
 // Resolve the supplied ResolutionRequest. The returned ResolutionResponse
 // always contains valid values unless an error was returned.
 func (r *APIResolver) Resolve(ctx context.Context, req ResolutionRequest) (ResolutionResponse, error) {
-	// Return early if from is being deleted, or the request is a no-op.
-	if meta.WasDeleted(r.from) || req.IsNoOp() {
-		return ResolutionResponse{ResolvedValue: req.CurrentValue, ResolvedReference: req.Reference}, nil
-	}
-
-	// The reference is already set - resolve it.
-	if req.Reference != nil {
-		if err := r.client.Get(ctx, types.NamespacedName{Name: req.Reference.Name}, req.To.Managed); err != nil {
-			if kerrors.IsNotFound(err) {
-				return ResolutionResponse{}, getResolutionError(req.Reference.Policy, errors.Wrap(err, errGetManaged))
-			}
-			return ResolutionResponse{}, errors.Wrap(err, errGetManaged)
-		}
-
-		rsp := ResolutionResponse{ResolvedValue: req.Extract(req.To.Managed), ResolvedReference: req.Reference}
-		return rsp, getResolutionError(req.Reference.Policy, rsp.Validate())
-	}
-
-	// The reference was not set, but a selector was. Select a reference.
-	if err := r.client.List(ctx, req.To.List, client.MatchingLabels(req.Selector.MatchLabels)); err != nil {
-		return ResolutionResponse{}, errors.Wrap(err, errListManaged)
-	}
-
-	for _, to := range req.To.List.GetItems() {
-		if ControllersMustMatch(req.Selector) && !meta.HaveSameController(r.from, to) {
-			continue
-		}
-
-		rsp := ResolutionResponse{ResolvedValue: req.Extract(to), ResolvedReference: &xpv1.Reference{Name: to.GetName()}}
-		return rsp, getResolutionError(req.Selector.Policy, rsp.Validate())
-	}
-
-	// We couldn't resolve anything.
-	return ResolutionResponse{}, getResolutionError(req.Selector.Policy, errors.New(errNoMatches))
-
+	rsp, err := ResolveTyped(ctx, r, req.typed())
+	return ResolutionResponse(rsp), err
 }
 
 // ResolveMultiple resolves the supplied MultiResolutionRequest. The returned
 // MultiResolutionResponse always contains valid values unless an error was
 // returned.
-func (r *APIResolver) ResolveMultiple(ctx context.Context, req MultiResolutionRequest) (MultiResolutionResponse, error) { //nolint: gocyclo // Only at 11.
-	// Return early if from is being deleted, or the request is a no-op.
-	if meta.WasDeleted(r.from) || req.IsNoOp() {
-		return MultiResolutionResponse{ResolvedValues: req.CurrentValues, ResolvedReferences: req.References}, nil
-	}
-
-	// The references are already set - resolve them.
-	if len(req.References) > 0 {
-		vals := make([]string, len(req.References))
-		for i := range req.References {
-			if err := r.client.Get(ctx, types.NamespacedName{Name: req.References[i].Name}, req.To.Managed); err != nil {
-				if kerrors.IsNotFound(err) {
-					return MultiResolutionResponse{}, getResolutionError(req.References[i].Policy, errors.Wrap(err, errGetManaged))
-				}
-				return MultiResolutionResponse{}, errors.Wrap(err, errGetManaged)
-			}
-			vals[i] = req.Extract(req.To.Managed)
-		}
-
-		rsp := MultiResolutionResponse{ResolvedValues: vals, ResolvedReferences: req.References}
-		return rsp, rsp.Validate()
-	}
-
-	// No references were set, but a selector was. Select and resolve references.
-	if err := r.client.List(ctx, req.To.List, client.MatchingLabels(req.Selector.MatchLabels)); err != nil {
-		return MultiResolutionResponse{}, errors.Wrap(err, errListManaged)
-	}
+func (r *APIResolver) ResolveMultiple(ctx context.Context, req MultiResolutionRequest) (MultiResolutionResponse, error) {
+	rsp, err := ResolveMultipleTyped(ctx, r, req.typed())
+	return MultiResolutionResponse(rsp), err
+}
 
-	items := req.To.List.GetItems()
-	refs := make([]xpv1.Reference, 0, len(items))
-	vals := make([]string, 0, len(items))
-	for _, to := range req.To.List.GetItems() {
-		if ControllersMustMatch(req.Selector) && !meta.HaveSameController(r.from, to) {
-			continue
+// managedOnly adapts the supplied ExtractValueOrErrorFn for use as a
+// TypedExtractValueFn. The adapted function returns an error if the referenced
+// object is not a managed resource.
+func managedOnly(fn ExtractValueOrErrorFn) TypedExtractValueFn[string] {
+	return func(o client.Object) (string, error) {
+		mg, ok := o.(resource.Managed)
+		if !ok {
+			return "", errors.New(errNotManaged)
 		}
-
-		vals = append(vals, req.Extract(to))
-		refs = append(refs, xpv1.Reference{Name: to.GetName()})
+		return fn(mg)
 	}
+}
 
-	rsp := MultiResolutionResponse{ResolvedValues: vals, ResolvedReferences: refs}
-	return rsp, getResolutionError(req.Selector.Policy, rsp.Validate())
+// mergeWaiting merges the blockers of the supplied WaitingError into the
+// existing WaitingError, if any.
+func mergeWaiting(existing, we *WaitingError) *WaitingError {
+	if existing == nil {
+		return &WaitingError{Field: we.Field, Blockers: append([]string{}, we.Blockers...)}
+	}
+	existing.Blockers = append(existing.Blockers, we.Blockers...)
+	return existing
 }
 
 func getResolutionError(p *xpv1.Policy, err error) error {
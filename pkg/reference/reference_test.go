@@ -23,6 +23,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -47,6 +48,10 @@ func (fml *FakeManagedList) GetItems() []resource.Managed {
 	return fml.Items
 }
 
+func (fml *FakeManagedList) DeepCopyObject() runtime.Object {
+	return &FakeManagedList{Items: append([]resource.Managed(nil), fml.Items...)}
+}
+
 func TestToAndFromPtr(t *testing.T) {
 	cases := map[string]struct {
 		want string
@@ -145,6 +150,97 @@ func TestToAndFromIntPtrValues(t *testing.T) {
 	}
 }
 
+// paramsManaged is a managed resource with fields of several types.
+type paramsManaged struct {
+	fake.Managed
+
+	Status paramsStatus `json:"status"`
+}
+
+func (m *paramsManaged) DeepCopyObject() runtime.Object {
+	out := &paramsManaged{Managed: *m.Managed.DeepCopyObject().(*fake.Managed), Status: m.Status}
+	out.Status.Zones = append([]string(nil), m.Status.Zones...)
+	return out
+}
+
+type paramsStatus struct {
+	ARN     string   `json:"arn,omitempty"`
+	Port    int64    `json:"port"`
+	Ratio   float64  `json:"ratio"`
+	Enabled bool     `json:"enabled"`
+	Zones   []string `json:"zones,omitempty"`
+}
+
+func TestExtractFieldPath(t *testing.T) {
+	mg := &paramsManaged{Status: paramsStatus{ARN: "arn:cool", Port: 5432, Ratio: 0.5, Enabled: true, Zones: []string{"a"}}}
+	mg.SetName("cool")
+
+	type want struct {
+		v   string
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		mg     resource.Managed
+		path   string
+		want   want
+	}{
+		"String": {
+			reason: "A string should be returned as is",
+			mg:     mg,
+			path:   "status.arn",
+			want:   want{v: "arn:cool"},
+		},
+		"Integer": {
+			reason: "An integer should be converted to a string",
+			mg:     mg,
+			path:   "status.port",
+			want:   want{v: "5432"},
+		},
+		"Float": {
+			reason: "A float should be converted to a string",
+			mg:     mg,
+			path:   "status.ratio",
+			want:   want{v: "0.5"},
+		},
+		"Boolean": {
+			reason: "A boolean should be converted to a string",
+			mg:     mg,
+			path:   "status.enabled",
+			want:   want{v: "true"},
+		},
+		"Missing": {
+			reason: "A WaitingError should be returned if the field is not set",
+			mg:     mg,
+			path:   "status.id",
+			want:   want{err: &WaitingError{Field: "status.id", Blockers: []string{"cool"}}},
+		},
+		"Empty": {
+			reason: "A WaitingError should be returned if the field is an empty string",
+			mg:     &paramsManaged{},
+			path:   "status.arn",
+			want:   want{err: &WaitingError{Field: "status.arn", Blockers: []string{""}}},
+		},
+		"Unsupported": {
+			reason: "An error should be returned if the field is not a scalar",
+			mg:     mg,
+			path:   "status.zones",
+			want:   want{err: errors.Errorf(errFmtUnsupportedValue, []any{"a"}, "status.zones")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v, err := ExtractFieldPath(tc.path)(tc.mg)
+			if diff := cmp.Diff(tc.want.v, v); diff != "" {
+				t.Errorf("\n%s\nExtractFieldPath(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nExtractFieldPath(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestResolve(t *testing.T) {
 	errBoom := errors.New("boom")
 	now := metav1.Now()
@@ -154,6 +250,9 @@ func TestResolve(t *testing.T) {
 	alwaysPolicy := xpv1.ResolvePolicyAlways
 	optionalRef := &xpv1.Reference{Name: "cool", Policy: &xpv1.Policy{Resolution: &optionalPolicy}}
 	alwaysRef := &xpv1.Reference{Name: "cool", Policy: &xpv1.Policy{Resolve: &alwaysPolicy}}
+	alphabetical := xpv1.SelectionStrategyAlphabetical
+	waitPolicy := xpv1.ReadinessPolicyWaitForReady
+	waitRef := &xpv1.Reference{Name: "cool", Policy: &xpv1.Policy{Readiness: &waitPolicy}}
 
 	controlled := &fake.Managed{}
 	controlled.SetName(value)
@@ -267,7 +366,7 @@ func TestResolve(t *testing.T) {
 				rsp: ResolutionResponse{
 					ResolvedReference: ref,
 				},
-				err: errors.New(errNoValue),
+				err: noValueError{},
 			},
 		},
 		"SuccessfulResolve": {
@@ -386,7 +485,7 @@ func TestResolve(t *testing.T) {
 			want: want{
 				rsp: ResolutionResponse{
 					ResolvedValue:     value,
-					ResolvedReference: &xpv1.Reference{Name: value},
+					ResolvedReference: &xpv1.Reference{Name: value, SelectionStrategy: &alphabetical},
 				},
 				err: nil,
 			},
@@ -415,7 +514,7 @@ func TestResolve(t *testing.T) {
 			want: want{
 				rsp: ResolutionResponse{
 					ResolvedValue:     value,
-					ResolvedReference: &xpv1.Reference{Name: value},
+					ResolvedReference: &xpv1.Reference{Name: value, SelectionStrategy: &alphabetical},
 				},
 				err: nil,
 			},
@@ -446,6 +545,93 @@ func TestResolve(t *testing.T) {
 				},
 			},
 		},
+		"ReferenceNotReady": {
+			reason: "Should return a WaitingError if the readiness policy is WaitForReady and the referenced resource is not ready",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					meta.SetExternalName(obj.(metav1.Object), value)
+					return nil
+				}),
+			},
+			from: &fake.Managed{},
+			args: args{
+				req: ResolutionRequest{
+					Reference: waitRef,
+					To:        To{Managed: &fake.Managed{}},
+					Extract:   ExternalName(),
+				},
+			},
+			want: want{
+				rsp: ResolutionResponse{},
+				err: &WaitingError{Blockers: []string{"cool"}},
+			},
+		},
+		"ReferenceReady": {
+			reason: "No error should be returned if the readiness policy is WaitForReady and the referenced resource is ready",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					meta.SetExternalName(obj.(metav1.Object), value)
+					obj.(resource.Managed).SetConditions(xpv1.Available())
+					return nil
+				}),
+			},
+			from: &fake.Managed{},
+			args: args{
+				req: ResolutionRequest{
+					Reference: waitRef,
+					To:        To{Managed: &fake.Managed{}},
+					Extract:   ExternalName(),
+				},
+			},
+			want: want{
+				rsp: ResolutionResponse{
+					ResolvedValue:     value,
+					ResolvedReference: waitRef,
+				},
+			},
+		},
+		"FieldNotAvailable": {
+			reason: "Should return a WaitingError if the field a value is extracted from is not yet available",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					obj.SetName("cool")
+					return nil
+				}),
+			},
+			from: &fake.Managed{},
+			args: args{
+				req: ResolutionRequest{
+					Reference:      ref,
+					To:             To{Managed: &fake.Managed{}},
+					ExtractOrError: ExtractFieldPath("status.atProvider.arn"),
+				},
+			},
+			want: want{
+				rsp: ResolutionResponse{},
+				err: &WaitingError{Field: "status.atProvider.arn", Blockers: []string{"cool"}},
+			},
+		},
+		"SelectedNotReady": {
+			reason: "Should return a WaitingError if the readiness policy is WaitForReady and the selected resource is not ready",
+			c: &test.MockClient{
+				MockList: test.NewMockListFn(nil),
+			},
+			from: controlled,
+			args: args{
+				req: ResolutionRequest{
+					Selector: &xpv1.Selector{
+						MatchControllerRef: func() *bool { t := true; return &t }(),
+						Policy:             &xpv1.Policy{Readiness: &waitPolicy},
+					},
+					To:      To{List: &FakeManagedList{Items: []resource.Managed{controlled}}},
+					Extract: ExternalName(),
+				},
+			},
+			want: want{
+				rsp: ResolutionResponse{},
+				err: &WaitingError{Blockers: []string{value}},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -469,6 +655,8 @@ func TestResolveMultiple(t *testing.T) {
 	alwaysPolicy := xpv1.ResolvePolicyAlways
 	optionalRef := xpv1.Reference{Name: "cool", Policy: &xpv1.Policy{Resolution: &optionalPolicy}}
 	alwaysRef := xpv1.Reference{Name: "cool", Policy: &xpv1.Policy{Resolve: &alwaysPolicy}}
+	alphabetical := xpv1.SelectionStrategyAlphabetical
+	waitPolicy := xpv1.ReadinessPolicyWaitForReady
 
 	controlled := &fake.Managed{}
 	controlled.SetName(value)
@@ -583,7 +771,7 @@ func TestResolveMultiple(t *testing.T) {
 					ResolvedValues:     []string{""},
 					ResolvedReferences: []xpv1.Reference{ref},
 				},
-				err: errors.New(errNoValue),
+				err: noValueError{},
 			},
 		},
 		"SuccessfulResolve": {
@@ -703,7 +891,7 @@ func TestResolveMultiple(t *testing.T) {
 			want: want{
 				rsp: MultiResolutionResponse{
 					ResolvedValues:     []string{value},
-					ResolvedReferences: []xpv1.Reference{{Name: value}},
+					ResolvedReferences: []xpv1.Reference{{Name: value, SelectionStrategy: &alphabetical}},
 				},
 				err: nil,
 			},
@@ -732,7 +920,7 @@ func TestResolveMultiple(t *testing.T) {
 			want: want{
 				rsp: MultiResolutionResponse{
 					ResolvedValues:     []string{value},
-					ResolvedReferences: []xpv1.Reference{{Name: value}},
+					ResolvedReferences: []xpv1.Reference{{Name: value, SelectionStrategy: &alphabetical}},
 				},
 				err: nil,
 			},
@@ -763,6 +951,52 @@ func TestResolveMultiple(t *testing.T) {
 				},
 			},
 		},
+		"ReferencesNotReady": {
+			reason: "Should return a WaitingError naming every referenced resource that is not ready when the readiness policy is WaitForReady",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					meta.SetExternalName(obj.(metav1.Object), value)
+					return nil
+				}),
+			},
+			from: &fake.Managed{},
+			args: args{
+				req: MultiResolutionRequest{
+					References: []xpv1.Reference{
+						{Name: "a", Policy: &xpv1.Policy{Readiness: &waitPolicy}},
+						{Name: "b"},
+						{Name: "c", Policy: &xpv1.Policy{Readiness: &waitPolicy}},
+					},
+					To:      To{Managed: &fake.Managed{}},
+					Extract: ExternalName(),
+				},
+			},
+			want: want{
+				rsp: MultiResolutionResponse{},
+				err: &WaitingError{Blockers: []string{"a", "c"}},
+			},
+		},
+		"FieldsNotAvailable": {
+			reason: "Should return a WaitingError naming every referenced resource whose field is not yet available",
+			c: &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+					obj.SetName(key.Name)
+					return nil
+				},
+			},
+			from: &fake.Managed{},
+			args: args{
+				req: MultiResolutionRequest{
+					References:     []xpv1.Reference{{Name: "a"}, {Name: "b"}},
+					To:             To{Managed: &fake.Managed{}},
+					ExtractOrError: ExtractFieldPath("status.atProvider.arn"),
+				},
+			},
+			want: want{
+				rsp: MultiResolutionResponse{},
+				err: &WaitingError{Field: "status.atProvider.arn", Blockers: []string{"a", "b"}},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -809,3 +1043,34 @@ func TestControllersMustMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestIsNotReady(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		err    error
+		want   bool
+	}{
+		"Waiting": {
+			reason: "A WaitingError should indicate a reference is not ready.",
+			err:    errors.Wrap(&WaitingError{Blockers: []string{"a"}}, "spec.ref"),
+			want:   true,
+		},
+		"NoValue": {
+			reason: "An empty extracted value should indicate a reference is not ready.",
+			err:    errors.Wrap(noValueError{}, "spec.ref"),
+			want:   true,
+		},
+		"Other": {
+			reason: "Other errors should not indicate a reference is not ready.",
+			err:    errors.New("boom"),
+			want:   false,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := IsNotReady(tc.err); got != tc.want {
+				t.Errorf("\n%s\nIsNotReady(...): want %t, got %t", tc.reason, tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+)
+
+// Error strings.
+const (
+	errInvalidSelector = "cannot parse selector"
+	errFmtAmbiguous    = "selector matched more than one resource: %s"
+)
+
+// LabelSelector returns a label selector that requires both the MatchLabels
+// and the MatchExpressions of the supplied Selector. A Selector without
+// either selects everything.
+func LabelSelector(s *xpv1.Selector) (labels.Selector, error) {
+	if s == nil {
+		return labels.Everything(), nil
+	}
+	sel, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchLabels: s.MatchLabels, MatchExpressions: s.MatchExpressions})
+	return sel, errors.Wrap(err, errInvalidSelector)
+}
+
+// AnnotationsMatch returns true if the supplied object has all of the
+// annotations required by the supplied Selector.
+func AnnotationsMatch(s *xpv1.Selector, o metav1.Object) bool {
+	if s == nil {
+		return true
+	}
+	a := o.GetAnnotations()
+	for k, v := range s.MatchAnnotations {
+		if got, ok := a[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Selects returns true if the supplied Selector of the supplied referencing
+// resource selects the supplied resource.
+func Selects(s *xpv1.Selector, from, to metav1.Object) bool {
+	if ControllersMustMatch(s) && !meta.HaveSameController(from, to) {
+		return false
+	}
+	sel, err := LabelSelector(s)
+	if err != nil {
+		return false
+	}
+	return sel.Matches(labels.Set(to.GetLabels())) && AnnotationsMatch(s, to)
+}
+
+// candidates returns the supplied listed objects that the supplied Selector
+// selects from the supplied referencing resource, ordered by the Selector's
+// strategy. Listed resources are assumed to match the Selector's labels.
+func candidates(s *xpv1.Selector, from metav1.Object, items []client.Object) []client.Object {
+	c := make([]client.Object, 0, len(items))
+	for _, to := range items {
+		if ControllersMustMatch(s) && !meta.HaveSameController(from, to) {
+			continue
+		}
+		if !AnnotationsMatch(s, to) {
+			continue
+		}
+		c = append(c, to)
+	}
+	sortByStrategy(s.GetStrategy(), c)
+	return c
+}
+
+// sortByStrategy sorts the supplied resources in the order the supplied
+// strategy selects them. Resources that were created at the same time are
+// ordered by name, so the order is always deterministic.
+func sortByStrategy[T metav1.Object](strategy xpv1.SelectionStrategy, objs []T) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		a, b := objs[i].GetCreationTimestamp().Time, objs[j].GetCreationTimestamp().Time
+		switch {
+		case strategy == xpv1.SelectionStrategyOldest && !a.Equal(b):
+			return a.Before(b)
+		case strategy == xpv1.SelectionStrategyNewest && !a.Equal(b):
+			return a.After(b)
+		}
+		return objs[i].GetName() < objs[j].GetName()
+	})
+}
+
+// ambiguous returns an error if the supplied Selector's strategy requires that
+// it match only one resource, and several candidates were found.
+func ambiguous(s *xpv1.Selector, c []client.Object) error {
+	if s.GetStrategy() != xpv1.SelectionStrategyFailIfAmbiguous || len(c) < 2 {
+		return nil
+	}
+	names := make([]string, len(c))
+	for i := range c {
+		names[i] = c[i].GetName()
+	}
+	return errors.Errorf(errFmtAmbiguous, strings.Join(names, ", "))
+}
+
+// selected returns a reference to a resource selected by the supplied
+// Selector, recording the strategy it was selected by.
+func selected(s *xpv1.Selector, to metav1.Object) xpv1.Reference {
+	strategy := s.GetStrategy()
+	return xpv1.Reference{Name: to.GetName(), SelectionStrategy: &strategy}
+}
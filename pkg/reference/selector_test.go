@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestResolveSelector(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mg := func(name string, created time.Time, annotations map[string]string) resource.Managed {
+		m := &fake.Managed{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+			Annotations:       annotations,
+		}}
+		meta.SetExternalName(m, name)
+		return m
+	}
+
+	// List order should never determine which resource is selected.
+	items := func() []resource.Managed {
+		return []resource.Managed{
+			mg("b", t0, map[string]string{"team": "a"}),
+			mg("c", t0.Add(-time.Hour), nil),
+			mg("a", t0.Add(time.Hour), nil),
+		}
+	}
+
+	strategy := func(s xpv1.SelectionStrategy) *xpv1.SelectionStrategy { return &s }
+
+	type want struct {
+		rsp ResolutionResponse
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		s      *xpv1.Selector
+		want   want
+	}{
+		"Alphabetical": {
+			reason: "The resource whose name sorts first should be selected by default.",
+			s:      &xpv1.Selector{},
+			want: want{
+				rsp: ResolutionResponse{ResolvedValue: "a", ResolvedReference: &xpv1.Reference{Name: "a", SelectionStrategy: strategy(xpv1.SelectionStrategyAlphabetical)}},
+			},
+		},
+		"Oldest": {
+			reason: "The resource that was created first should be selected.",
+			s:      &xpv1.Selector{Strategy: strategy(xpv1.SelectionStrategyOldest)},
+			want: want{
+				rsp: ResolutionResponse{ResolvedValue: "c", ResolvedReference: &xpv1.Reference{Name: "c", SelectionStrategy: strategy(xpv1.SelectionStrategyOldest)}},
+			},
+		},
+		"Newest": {
+			reason: "The resource that was created last should be selected.",
+			s:      &xpv1.Selector{Strategy: strategy(xpv1.SelectionStrategyNewest)},
+			want: want{
+				rsp: ResolutionResponse{ResolvedValue: "a", ResolvedReference: &xpv1.Reference{Name: "a", SelectionStrategy: strategy(xpv1.SelectionStrategyNewest)}},
+			},
+		},
+		"Ambiguous": {
+			reason: "Selection should fail if several resources match and the strategy is FailIfAmbiguous.",
+			s:      &xpv1.Selector{Strategy: strategy(xpv1.SelectionStrategyFailIfAmbiguous)},
+			want: want{
+				err: errors.Errorf(errFmtAmbiguous, "a, b, c"),
+			},
+		},
+		"Unambiguous": {
+			reason: "The only resource with matching annotations should be selected when the strategy is FailIfAmbiguous.",
+			s: &xpv1.Selector{
+				MatchAnnotations: map[string]string{"team": "a"},
+				Strategy:         strategy(xpv1.SelectionStrategyFailIfAmbiguous),
+			},
+			want: want{
+				rsp: ResolutionResponse{ResolvedValue: "b", ResolvedReference: &xpv1.Reference{Name: "b", SelectionStrategy: strategy(xpv1.SelectionStrategyFailIfAmbiguous)}},
+			},
+		},
+		"NoMatchingAnnotations": {
+			reason: "An error should be returned if no resource has matching annotations.",
+			s:      &xpv1.Selector{MatchAnnotations: map[string]string{"team": "b"}},
+			want: want{
+				err: errors.New(errNoMatches),
+			},
+		},
+		"InvalidExpression": {
+			reason: "An error should be returned if the selector's expressions are invalid.",
+			s: &xpv1.Selector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "cool", Operator: metav1.LabelSelectorOpIn},
+			}},
+			want: want{
+				err: errors.Wrap(errors.New(`values: Invalid value: []string(nil): for 'in', 'notin' operators, values set can't be empty`), errInvalidSelector),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := &test.MockClient{
+				MockList: func(_ context.Context, l client.ObjectList, _ ...client.ListOption) error {
+					l.(*FakeManagedList).Items = items()
+					return nil
+				},
+			}
+			r := NewAPIResolver(c, &fake.Managed{})
+			got, err := r.Resolve(context.Background(), ResolutionRequest{
+				Selector: tc.s,
+				To:       To{List: &FakeManagedList{}},
+				Extract:  ExternalName(),
+			})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.Resolve(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.rsp, got); diff != "" {
+				t.Errorf("\n%s\nr.Resolve(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestLabelSelector(t *testing.T) {
+	s := &xpv1.Selector{
+		MatchLabels: map[string]string{"cool": "true"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "env", Operator: metav1.LabelSelectorOpIn, Values: []string{"dev", "staging"}},
+		},
+	}
+	sel, err := LabelSelector(s)
+	if err != nil {
+		t.Fatalf("LabelSelector(...): %s", err)
+	}
+	if diff := cmp.Diff("cool=true,env in (dev,staging)", sel.String()); diff != "" {
+		t.Errorf("LabelSelector(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,366 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"context"
+	"reflect"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+)
+
+// A TypedExtractValueFn specifies how to extract a value of type V from the
+// resolved object, which is usually a managed resource. It returns an error if
+// the value cannot be extracted.
+type TypedExtractValueFn[V any] func(client.Object) (V, error)
+
+// ExtractFieldPathAs extracts the value at the supplied field path of the
+// resolved object as a value of type V, for example an *int64 port
+// number, a []string of CIDR blocks, or a struct. The value is converted from
+// its JSON representation. A WaitingError is returned if the field is unset,
+// since it's typically populated once the referenced resource has been
+// created. Values can't be extracted from Secrets; use ExtractSecretKey.
+func ExtractFieldPathAs[V any](path string) TypedExtractValueFn[V] {
+	return func(o client.Object) (V, error) {
+		var v V
+		if isSecret(o) {
+			return v, errors.New(errSecretFieldPath)
+		}
+		p, err := fieldpath.PaveObject(o)
+		if err != nil {
+			return v, err
+		}
+		raw, err := p.GetValue(path)
+		if fieldpath.IsNotFound(err) || (err == nil && raw == nil) {
+			return v, &WaitingError{Field: path, Blockers: []string{o.GetName()}}
+		}
+		if err != nil {
+			return v, err
+		}
+		return v, p.GetValueInto(path, &v)
+	}
+}
+
+// isZero returns true if the supplied value is the zero value of its type.
+func isZero[V any](v V) bool {
+	return reflect.ValueOf(&v).Elem().IsZero()
+}
+
+// A TypedResolutionRequest requests that a reference to a particular kind of
+// object be resolved to a value of type V. A zero value of V is
+// considered unset, so V is typically a pointer, slice, or map type, for
+// example *int64 rather than int64.
+type TypedResolutionRequest[V any] struct {
+	CurrentValue V
+	Reference    *xpv1.Reference
+	Selector     *xpv1.Selector
+	To           To
+	Extract      TypedExtractValueFn[V]
+}
+
+// IsNoOp returns true if the supplied TypedResolutionRequest cannot or should
+// not be processed.
+func (rr *TypedResolutionRequest[V]) IsNoOp() bool {
+	isAlways := false
+	if rr.Selector != nil {
+		if rr.Selector.Policy.IsResolvePolicyAlways() {
+			rr.Reference = nil
+			isAlways = true
+		}
+	} else if rr.Reference != nil {
+		if rr.Reference.Policy.IsResolvePolicyAlways() {
+			isAlways = true
+		}
+	}
+
+	// We don't resolve values that are already set (if reference resolution policy
+	// is not set to Always); we effectively cache resolved values. The CR author
+	// can invalidate the cache and trigger a new resolution by explicitly clearing
+	// the resolved value.
+	if !isZero(rr.CurrentValue) && !isAlways {
+		return true
+	}
+
+	// We can't resolve anything if neither a reference nor a selector were
+	// provided.
+	return rr.Reference == nil && rr.Selector == nil
+}
+
+// A TypedResolutionResponse returns the result of a reference resolution. The
+// returned values are always safe to set if resolution was successful.
+type TypedResolutionResponse[V any] struct {
+	ResolvedValue     V
+	ResolvedReference *xpv1.Reference
+}
+
+// Validate this TypedResolutionResponse.
+func (rr TypedResolutionResponse[V]) Validate() error {
+	if isZero(rr.ResolvedValue) {
+		return noValueError{}
+	}
+
+	return nil
+}
+
+// A TypedMultiResolutionRequest requests that several references to a
+// particular kind of object be resolved to values of type V. A zero
+// value of V is considered unset.
+type TypedMultiResolutionRequest[V any] struct {
+	CurrentValues []V
+	References    []xpv1.Reference
+	Selector      *xpv1.Selector
+	To            To
+	Extract       TypedExtractValueFn[V]
+}
+
+// IsNoOp returns true if the supplied TypedMultiResolutionRequest cannot or
+// should not be processed.
+func (rr *TypedMultiResolutionRequest[V]) IsNoOp() bool {
+	isAlways := false
+	if rr.Selector != nil {
+		if rr.Selector.Policy.IsResolvePolicyAlways() {
+			rr.References = nil
+			isAlways = true
+		}
+	} else {
+		for _, r := range rr.References {
+			if r.Policy.IsResolvePolicyAlways() {
+				isAlways = true
+				break
+			}
+		}
+	}
+
+	// We don't resolve values that are already set (if reference resolution policy
+	// is not set to Always); we effectively cache resolved values. The CR author
+	// can invalidate the cache and trigger a new resolution by explicitly clearing
+	// the resolved values. This is a little unintuitive for the APIMultiResolver
+	// but mimics the UX of the APIResolver and simplifies the overall mental model.
+	if len(rr.CurrentValues) > 0 && !isAlways {
+		return true
+	}
+
+	// We can't resolve anything if neither a reference nor a selector were
+	// provided.
+	return len(rr.References) == 0 && rr.Selector == nil
+}
+
+// A TypedMultiResolutionResponse returns the result of several reference
+// resolutions. The returned values are always safe to set if resolution was
+// successful.
+type TypedMultiResolutionResponse[V any] struct {
+	ResolvedValues     []V
+	ResolvedReferences []xpv1.Reference
+}
+
+// Validate this TypedMultiResolutionResponse.
+func (rr TypedMultiResolutionResponse[V]) Validate() error {
+	if len(rr.ResolvedValues) == 0 {
+		return errors.New(errNoMatches)
+	}
+
+	for i, v := range rr.ResolvedValues {
+		if isZero(v) {
+			return getResolutionError(rr.ResolvedReferences[i].Policy, noValueError{})
+		}
+	}
+
+	return nil
+}
+
+// ResolveTyped resolves the supplied TypedResolutionRequest using the supplied
+// APIResolver. The returned TypedResolutionResponse always contains valid
+// values unless an error was returned.
+func ResolveTyped[V any](ctx context.Context, r *APIResolver, req TypedResolutionRequest[V]) (TypedResolutionResponse[V], error) { //nolint:gocyclo // Only at 16.
+	// Return early if from is being deleted, or the request is a no-op.
+	if meta.WasDeleted(r.from) || req.IsNoOp() {
+		return TypedResolutionResponse[V]{ResolvedValue: req.CurrentValue, ResolvedReference: req.Reference}, nil
+	}
+
+	// The reference is already set - resolve it.
+	if req.Reference != nil {
+		to, err := r.get(ctx, req.To, req.Reference.Name)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return TypedResolutionResponse[V]{}, getResolutionError(req.Reference.Policy, errors.Wrap(err, errGetManaged))
+			}
+			return TypedResolutionResponse[V]{}, errors.Wrap(err, errGetManaged)
+		}
+
+		if req.Reference.Policy.IsReadinessPolicyWaitForReady() && !isReady(to) {
+			return TypedResolutionResponse[V]{}, getResolutionError(req.Reference.Policy, &WaitingError{Blockers: []string{req.Reference.Name}})
+		}
+
+		v, err := req.Extract(to)
+		if err != nil {
+			return TypedResolutionResponse[V]{}, getResolutionError(req.Reference.Policy, err)
+		}
+
+		rsp := TypedResolutionResponse[V]{ResolvedValue: v, ResolvedReference: req.Reference}
+		return rsp, getResolutionError(req.Reference.Policy, rsp.Validate())
+	}
+
+	// The reference was not set, but a selector was. Select a reference.
+	sel, err := LabelSelector(req.Selector)
+	if err != nil {
+		return TypedResolutionResponse[V]{}, err
+	}
+	if err := r.client.List(ctx, req.To.list(), client.MatchingLabelsSelector{Selector: sel}, client.InNamespace(req.To.Namespace)); err != nil {
+		return TypedResolutionResponse[V]{}, errors.Wrap(err, errListManaged)
+	}
+	items, err := req.To.items()
+	if err != nil {
+		return TypedResolutionResponse[V]{}, err
+	}
+
+	c := candidates(req.Selector, r.from, items)
+	if len(c) == 0 {
+		// We couldn't resolve anything.
+		return TypedResolutionResponse[V]{}, getResolutionError(req.Selector.Policy, errors.New(errNoMatches))
+	}
+	if err := ambiguous(req.Selector, c); err != nil {
+		return TypedResolutionResponse[V]{}, getResolutionError(req.Selector.Policy, err)
+	}
+
+	to := c[0]
+	if req.Selector.Policy.IsReadinessPolicyWaitForReady() && !isReady(to) {
+		return TypedResolutionResponse[V]{}, getResolutionError(req.Selector.Policy, &WaitingError{Blockers: []string{to.GetName()}})
+	}
+
+	v, err := req.Extract(to)
+	if err != nil {
+		return TypedResolutionResponse[V]{}, getResolutionError(req.Selector.Policy, err)
+	}
+
+	ref := selected(req.Selector, to)
+	rsp := TypedResolutionResponse[V]{ResolvedValue: v, ResolvedReference: &ref}
+	return rsp, getResolutionError(req.Selector.Policy, rsp.Validate())
+}
+
+// ResolveMultipleTyped resolves the supplied TypedMultiResolutionRequest using
+// the supplied APIResolver. The returned TypedMultiResolutionResponse always
+// contains valid values unless an error was returned.
+func ResolveMultipleTyped[V any](ctx context.Context, r *APIResolver, req TypedMultiResolutionRequest[V]) (TypedMultiResolutionResponse[V], error) { //nolint:gocyclo // Only at 14.
+	// Return early if from is being deleted, or the request is a no-op.
+	if meta.WasDeleted(r.from) || req.IsNoOp() {
+		return TypedMultiResolutionResponse[V]{ResolvedValues: req.CurrentValues, ResolvedReferences: req.References}, nil
+	}
+
+	// The references are already set - resolve them.
+	if len(req.References) > 0 {
+		return resolveReferences(ctx, r, req)
+	}
+
+	// No references were set, but a selector was. Select and resolve references.
+	sel, err := LabelSelector(req.Selector)
+	if err != nil {
+		return TypedMultiResolutionResponse[V]{}, err
+	}
+	if err := r.client.List(ctx, req.To.list(), client.MatchingLabelsSelector{Selector: sel}, client.InNamespace(req.To.Namespace)); err != nil {
+		return TypedMultiResolutionResponse[V]{}, errors.Wrap(err, errListManaged)
+	}
+	listed, err := req.To.items()
+	if err != nil {
+		return TypedMultiResolutionResponse[V]{}, err
+	}
+
+	items := candidates(req.Selector, r.from, listed)
+	refs := make([]xpv1.Reference, 0, len(items))
+	vals := make([]V, 0, len(items))
+	var blockers []string
+	var unavailable *WaitingError
+	for _, to := range items {
+		if req.Selector.Policy.IsReadinessPolicyWaitForReady() && !isReady(to) {
+			blockers = append(blockers, to.GetName())
+			continue
+		}
+
+		v, err := req.Extract(to)
+		if err != nil {
+			we := &WaitingError{}
+			if !errors.As(err, &we) {
+				return TypedMultiResolutionResponse[V]{}, getResolutionError(req.Selector.Policy, err)
+			}
+			unavailable = mergeWaiting(unavailable, we)
+			continue
+		}
+
+		vals = append(vals, v)
+		refs = append(refs, selected(req.Selector, to))
+	}
+
+	if len(blockers) > 0 {
+		return TypedMultiResolutionResponse[V]{}, getResolutionError(req.Selector.Policy, &WaitingError{Blockers: blockers})
+	}
+	if unavailable != nil {
+		return TypedMultiResolutionResponse[V]{}, getResolutionError(req.Selector.Policy, unavailable)
+	}
+
+	rsp := TypedMultiResolutionResponse[V]{ResolvedValues: vals, ResolvedReferences: refs}
+	return rsp, getResolutionError(req.Selector.Policy, rsp.Validate())
+}
+
+// resolveReferences resolves the references of the supplied
+// TypedMultiResolutionRequest.
+func resolveReferences[V any](ctx context.Context, r *APIResolver, req TypedMultiResolutionRequest[V]) (TypedMultiResolutionResponse[V], error) { //nolint:gocyclo // Only at 12.
+	names := make([]string, len(req.References))
+	for i := range req.References {
+		names[i] = req.References[i].Name
+	}
+	objs, errs := r.getAll(ctx, req.To, names)
+
+	vals := make([]V, len(req.References))
+	var blockers []string
+	var unavailable *WaitingError
+	for i, to := range objs {
+		if err := errs[i]; err != nil {
+			if kerrors.IsNotFound(err) {
+				return TypedMultiResolutionResponse[V]{}, getResolutionError(req.References[i].Policy, errors.Wrap(err, errGetManaged))
+			}
+			return TypedMultiResolutionResponse[V]{}, errors.Wrap(err, errGetManaged)
+		}
+		if req.References[i].Policy.IsReadinessPolicyWaitForReady() && !isReady(to) && !req.References[i].Policy.IsResolutionPolicyOptional() {
+			blockers = append(blockers, req.References[i].Name)
+		}
+		v, err := req.Extract(to)
+		if err != nil && !req.References[i].Policy.IsResolutionPolicyOptional() {
+			we := &WaitingError{}
+			if !errors.As(err, &we) {
+				return TypedMultiResolutionResponse[V]{}, err
+			}
+			unavailable = mergeWaiting(unavailable, we)
+		}
+		vals[i] = v
+	}
+
+	// Report every reference we're waiting for, not just the first.
+	if len(blockers) > 0 {
+		return TypedMultiResolutionResponse[V]{}, &WaitingError{Blockers: blockers}
+	}
+	if unavailable != nil {
+		return TypedMultiResolutionResponse[V]{}, unavailable
+	}
+
+	rsp := TypedMultiResolutionResponse[V]{ResolvedValues: vals, ResolvedReferences: req.References}
+	return rsp, rsp.Validate()
+}
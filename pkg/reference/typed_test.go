@@ -0,0 +1,184 @@
+/*
+Copyright 2024 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestExtractFieldPathAs(t *testing.T) {
+	mg := &paramsManaged{Status: paramsStatus{Port: 5432, Zones: []string{"a", "b"}}}
+	mg.SetName("cool")
+
+	t.Run("Integer", func(t *testing.T) {
+		v, err := ExtractFieldPathAs[*int64]("status.port")(mg)
+		if err != nil {
+			t.Fatalf("ExtractFieldPathAs(...): %s", err)
+		}
+		if diff := cmp.Diff(int64(5432), *v); diff != "" {
+			t.Errorf("ExtractFieldPathAs(...): -want, +got:\n%s", diff)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		v, err := ExtractFieldPathAs[[]string]("status.zones")(mg)
+		if err != nil {
+			t.Fatalf("ExtractFieldPathAs(...): %s", err)
+		}
+		if diff := cmp.Diff([]string{"a", "b"}, v); diff != "" {
+			t.Errorf("ExtractFieldPathAs(...): -want, +got:\n%s", diff)
+		}
+	})
+
+	t.Run("Object", func(t *testing.T) {
+		v, err := ExtractFieldPathAs[*paramsStatus]("status")(mg)
+		if err != nil {
+			t.Fatalf("ExtractFieldPathAs(...): %s", err)
+		}
+		if diff := cmp.Diff(&mg.Status, v); diff != "" {
+			t.Errorf("ExtractFieldPathAs(...): -want, +got:\n%s", diff)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := ExtractFieldPathAs[*string]("status.arn")(mg)
+		want := &WaitingError{Field: "status.arn", Blockers: []string{"cool"}}
+		if diff := cmp.Diff(want, err, test.EquateErrors()); diff != "" {
+			t.Errorf("ExtractFieldPathAs(...): -want error, +got error:\n%s", diff)
+		}
+	})
+}
+
+func TestResolveTyped(t *testing.T) {
+	errBoom := errors.New("boom")
+	port := int64(5432)
+	current := int64(80)
+	ref := &xpv1.Reference{Name: "cool"}
+
+	get := func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+		*obj.(*paramsManaged) = paramsManaged{Status: paramsStatus{Port: port}}
+		obj.SetName(key.Name)
+		return nil
+	}
+
+	type want struct {
+		rsp TypedResolutionResponse[*int64]
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		c      client.Reader
+		req    TypedResolutionRequest[*int64]
+		want   want
+	}{
+		"AlreadyResolved": {
+			reason: "Should return early if the current value is non-zero",
+			req:    TypedResolutionRequest[*int64]{CurrentValue: &current, Reference: ref},
+			want: want{
+				rsp: TypedResolutionResponse[*int64]{ResolvedValue: &current, ResolvedReference: ref},
+			},
+		},
+		"GetError": {
+			reason: "Should return errors encountered while getting the referenced resource",
+			c:      &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+			req: TypedResolutionRequest[*int64]{
+				Reference: ref,
+				To:        To{Managed: &paramsManaged{}},
+				Extract:   ExtractFieldPathAs[*int64]("status.port"),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGetManaged),
+			},
+		},
+		"ResolvedReference": {
+			reason: "A typed value should be extracted from the referenced resource",
+			c:      &test.MockClient{MockGet: get},
+			req: TypedResolutionRequest[*int64]{
+				Reference: ref,
+				To:        To{Managed: &paramsManaged{}},
+				Extract:   ExtractFieldPathAs[*int64]("status.port"),
+			},
+			want: want{
+				rsp: TypedResolutionResponse[*int64]{ResolvedValue: &port, ResolvedReference: ref},
+			},
+		},
+		"NoValue": {
+			reason: "Should return an error if the extracted value is the zero value",
+			c:      &test.MockClient{MockGet: get},
+			req: TypedResolutionRequest[*int64]{
+				Reference: ref,
+				To:        To{Managed: &paramsManaged{}},
+				Extract:   func(client.Object) (*int64, error) { return nil, nil },
+			},
+			want: want{
+				rsp: TypedResolutionResponse[*int64]{ResolvedReference: ref},
+				err: noValueError{},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewAPIResolver(tc.c, &fake.Managed{})
+			got, err := ResolveTyped(context.Background(), r, tc.req)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nResolveTyped(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.rsp, got); diff != "" {
+				t.Errorf("\n%s\nResolveTyped(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestResolveMultipleTyped(t *testing.T) {
+	c := &test.MockClient{
+		MockList: test.NewMockListFn(nil),
+	}
+	a := &paramsManaged{Status: paramsStatus{Zones: []string{"a"}}}
+	a.SetName("a")
+	b := &paramsManaged{Status: paramsStatus{Zones: []string{"b", "c"}}}
+	b.SetName("b")
+
+	alphabetical := xpv1.SelectionStrategyAlphabetical
+
+	r := NewAPIResolver(c, &fake.Managed{})
+	got, err := ResolveMultipleTyped(context.Background(), r, TypedMultiResolutionRequest[[]string]{
+		Selector: &xpv1.Selector{},
+		To:       To{List: &FakeManagedList{Items: []resource.Managed{a, b}}},
+		Extract:  ExtractFieldPathAs[[]string]("status.zones"),
+	})
+	if err != nil {
+		t.Fatalf("ResolveMultipleTyped(...): %s", err)
+	}
+	want := TypedMultiResolutionResponse[[]string]{
+		ResolvedValues:     [][]string{{"a"}, {"b", "c"}},
+		ResolvedReferences: []xpv1.Reference{{Name: "a", SelectionStrategy: &alphabetical}, {Name: "b", SelectionStrategy: &alphabetical}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ResolveMultipleTyped(...): -want, +got:\n%s", diff)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
@@ -445,10 +446,13 @@ type Manager struct {
 
 	Cache      cache.Cache
 	Client     client.Client
+	APIReader  client.Reader
 	Scheme     *runtime.Scheme
 	Config     *rest.Config
 	RESTMapper meta.RESTMapper
 	Logger     logr.Logger
+
+	ControllerOptions config.Controller
 }
 
 // Elected returns a closed channel.
@@ -464,6 +468,9 @@ func (m *Manager) GetCache() cache.Cache { return m.Cache }
 // GetClient returns the client.
 func (m *Manager) GetClient() client.Client { return m.Client }
 
+// GetAPIReader returns the API reader.
+func (m *Manager) GetAPIReader() client.Reader { return m.APIReader }
+
 // GetScheme returns the scheme.
 func (m *Manager) GetScheme() *runtime.Scheme { return m.Scheme }
 
@@ -476,6 +483,9 @@ func (m *Manager) GetRESTMapper() meta.RESTMapper { return m.RESTMapper }
 // GetLogger returns the logger.
 func (m *Manager) GetLogger() logr.Logger { return m.Logger }
 
+// GetControllerOptions returns the controller options.
+func (m *Manager) GetControllerOptions() config.Controller { return m.ControllerOptions }
+
 // GV returns a mock schema.GroupVersion.
 var GV = schema.GroupVersion{Group: "g", Version: "v"}
 